@@ -0,0 +1,186 @@
+package webhookrelay
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// AccessTokenRotateOptions control RotateAccessToken
+type AccessTokenRotateOptions struct {
+	// Deliver receives the new key and secret, e.g. to write them to a
+	// secret store. If it fails the new token is deleted and the old one
+	// is kept.
+	Deliver func(ctx context.Context, created *AccessTokenCreateResponse) error
+	// GracePeriod to wait before the old token is deleted so that clients
+	// can pick up the new credentials, zero deletes it right away
+	GracePeriod time.Duration
+	// Description of the new token, defaults to the old description
+	Description string
+	// ExpiresAt of the new token, defaults to the lifetime of the old token
+	// counted from the rotation. The new token only never expires if the
+	// old one didn't or NoExpiry is set.
+	ExpiresAt time.Time
+	// NoExpiry creates a token that never expires, whatever the old token
+	// lifetime was
+	NoExpiry bool
+}
+
+// expiresAt returns the expiry of the new token
+func (o *AccessTokenRotateOptions) expiresAt(previous *AccessToken, now time.Time) (time.Time, error) {
+	switch {
+	case o.NoExpiry && !o.ExpiresAt.IsZero():
+		return time.Time{}, fmt.Errorf("expiry and no expiry can't be both set")
+	case o.NoExpiry:
+		return time.Time{}, nil
+	case !o.ExpiresAt.IsZero():
+		return o.ExpiresAt, nil
+	case previous.ExpiresAt.IsZero():
+		return time.Time{}, nil
+	case previous.CreatedAt.IsZero() || !previous.ExpiresAt.After(previous.CreatedAt):
+		// lifetime unknown, keep the old expiry
+		return previous.ExpiresAt, nil
+	}
+	return now.Add(previous.ExpiresAt.Sub(previous.CreatedAt)), nil
+}
+
+// AccessTokenRotation is the result of RotateAccessToken
+type AccessTokenRotation struct {
+	Previous *AccessToken
+	Current  *AccessTokenCreateResponse
+	// DeleteAfter - when the previous token is deleted
+	DeleteAfter time.Time
+	// PreviousDeleted is false if the grace period was interrupted, use
+	// FinishAccessTokenRotation to delete the previous token later
+	PreviousDeleted bool
+}
+
+// RotateAccessToken replaces the token with a new one with the same scopes,
+// API access and lifetime. The new credentials are passed to Deliver, then the old
+// token is deleted after the grace period. If ctx is cancelled during the
+// grace period the rotation is returned with the context error and the old
+// token is kept.
+func (api *API) RotateAccessToken(ctx context.Context, id string, opts *AccessTokenRotateOptions) (*AccessTokenRotation, error) {
+	if opts == nil || opts.Deliver == nil {
+		return nil, fmt.Errorf("deliver function is required to rotate a token")
+	}
+
+	previous, err := api.getAccessToken(id)
+	if err != nil {
+		return nil, err
+	}
+	expiresAt, err := opts.expiresAt(previous, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	description := opts.Description
+	if description == "" {
+		description = previous.Description
+	}
+	created, err := api.CreateAccessToken(&AccessTokenCreateOptions{
+		Description: description,
+		Scopes:      previous.Scopes,
+		APIAccess:   previous.APIAccess,
+		ExpiresAt:   expiresAt,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create token: %w", err)
+	}
+
+	if err := opts.Deliver(ctx, created); err != nil {
+		if delErr := api.DeleteAccessToken(&AccessTokenDeleteOptions{ID: created.Key}); delErr != nil {
+			return nil, fmt.Errorf("failed to deliver token: %w (failed to delete new token %s: %s)", err, created.Key, delErr)
+		}
+		return nil, fmt.Errorf("failed to deliver token: %w", err)
+	}
+
+	rotation := &AccessTokenRotation{
+		Previous:    previous,
+		Current:     created,
+		DeleteAfter: time.Now().Add(opts.GracePeriod),
+	}
+
+	if opts.GracePeriod > 0 {
+		timer := time.NewTimer(opts.GracePeriod)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return rotation, ctx.Err()
+		case <-timer.C:
+		}
+	}
+
+	return rotation, api.FinishAccessTokenRotation(rotation)
+}
+
+// FinishAccessTokenRotation deletes the previous token of the rotation
+func (api *API) FinishAccessTokenRotation(rotation *AccessTokenRotation) error {
+	if rotation.PreviousDeleted {
+		return nil
+	}
+	if err := api.DeleteAccessToken(&AccessTokenDeleteOptions{ID: rotation.Previous.ID}); err != nil {
+		return fmt.Errorf("failed to delete previous token: %w", err)
+	}
+	rotation.PreviousDeleted = true
+	return nil
+}
+
+func (api *API) getAccessToken(id string) (*AccessToken, error) {
+	tokens, err := api.ListAccessTokens(&AccessTokenListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range tokens {
+		if t.ID == id {
+			return t, nil
+		}
+	}
+	return nil, &NotFoundError{Resource: "access token", Ref: id}
+}
+
+// UnusedAccessToken is an entry of the unused token report
+type UnusedAccessToken struct {
+	Token *AccessToken
+	// LastUsed is the last login, or creation time if the token was never
+	// used
+	LastUsed  time.Time
+	NeverUsed bool
+	Idle      time.Duration
+}
+
+// FindUnusedAccessTokens returns tokens not used for at least unusedFor,
+// most idle first. Tokens that were never used count from their creation.
+func FindUnusedAccessTokens(tokens []*AccessToken, unusedFor time.Duration, now time.Time) ([]*UnusedAccessToken, error) {
+	var unused []*UnusedAccessToken
+	for _, t := range tokens {
+		lastLogin, err := t.LastLoginTime()
+		if err != nil {
+			return nil, err
+		}
+		entry := &UnusedAccessToken{Token: t, LastUsed: lastLogin}
+		if lastLogin.IsZero() {
+			entry.LastUsed = t.CreatedAt
+			entry.NeverUsed = true
+		}
+		entry.Idle = now.Sub(entry.LastUsed)
+		if entry.Idle >= unusedFor {
+			unused = append(unused, entry)
+		}
+	}
+	sort.SliceStable(unused, func(a, b int) bool {
+		return unused[a].Idle > unused[b].Idle
+	})
+	return unused, nil
+}
+
+// UnusedAccessTokens lists account tokens not used for the given number
+// of days
+func (api *API) UnusedAccessTokens(days int) ([]*UnusedAccessToken, error) {
+	tokens, err := api.ListAccessTokens(&AccessTokenListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return FindUnusedAccessTokens(tokens, time.Duration(days)*24*time.Hour, time.Now())
+}
@@ -0,0 +1,247 @@
+package webhookrelay
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccessToken_JSON(t *testing.T) {
+	expires := time.Unix(1800000000, 0)
+	data, err := json.Marshal(&AccessToken{ID: "t1", ExpiresAt: expires})
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"expires_at":1800000000`)
+
+	var token AccessToken
+	require.NoError(t, json.Unmarshal(data, &token))
+	assert.True(t, token.ExpiresAt.Equal(expires))
+
+	data, err = json.Marshal(&AccessToken{ID: "t2"})
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "expires_at")
+	require.NoError(t, json.Unmarshal([]byte(`{"id":"t2","expires_at":null}`), &token))
+	assert.True(t, token.ExpiresAt.IsZero())
+
+	data, err = json.Marshal(&AccessTokenCreateOptions{Description: "ci", ExpiresAt: expires})
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"expires_at":1800000000`)
+}
+
+func TestAccessToken_Expired(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	assert.False(t, (&AccessToken{}).Expired(now))
+	assert.False(t, (&AccessToken{ExpiresAt: now.Add(time.Second)}).Expired(now))
+	assert.True(t, (&AccessToken{ExpiresAt: now}).Expired(now))
+
+	client, err := New("test-key", "test-secret")
+	require.NoError(t, err)
+	_, err = client.CreateAccessToken(&AccessTokenCreateOptions{ExpiresAt: time.Now().Add(-time.Hour)})
+	assert.Error(t, err)
+}
+
+func TestAccessToken_LastLoginTime(t *testing.T) {
+	want := time.Date(2026, 10, 1, 8, 30, 0, 0, time.UTC)
+
+	for _, lastLogin := range []string{
+		"2026-10-01T08:30:00Z",
+		"1790843400",
+		"2026-10-01 08:30:00 +0000 UTC",
+		"2026-10-01 08:30:00.000000001 +0000 UTC",
+	} {
+		parsed, err := (&AccessToken{LastLogin: lastLogin}).LastLoginTime()
+		require.NoError(t, err, lastLogin)
+		assert.True(t, parsed.Truncate(time.Second).Equal(want), lastLogin)
+	}
+
+	for _, never := range []string{"", "never", "0", "0001-01-01 00:00:00 +0000 UTC"} {
+		parsed, err := (&AccessToken{LastLogin: never}).LastLoginTime()
+		require.NoError(t, err, never)
+		assert.True(t, parsed.IsZero(), never)
+	}
+
+	_, err := (&AccessToken{ID: "t1", LastLogin: "yesterday"}).LastLoginTime()
+	assert.EqualError(t, err, "token t1: unable to parse last login 'yesterday'")
+}
+
+func TestFindUnusedAccessTokens(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	tokens := []*AccessToken{
+		{ID: "recent", CreatedAt: now.AddDate(0, -6, 0), LastLogin: now.AddDate(0, 0, -1).Format(time.RFC3339)},
+		{ID: "stale", CreatedAt: now.AddDate(0, -6, 0), LastLogin: now.AddDate(0, 0, -45).Format(time.RFC3339)},
+		{ID: "never", CreatedAt: now.AddDate(0, 0, -100)},
+		{ID: "new", CreatedAt: now.AddDate(0, 0, -2)},
+	}
+
+	unused, err := FindUnusedAccessTokens(tokens, 30*24*time.Hour, now)
+	require.NoError(t, err)
+	require.Len(t, unused, 2)
+	assert.Equal(t, "never", unused[0].Token.ID)
+	assert.True(t, unused[0].NeverUsed)
+	assert.Equal(t, 100*24*time.Hour, unused[0].Idle)
+	assert.Equal(t, "stale", unused[1].Token.ID)
+	assert.False(t, unused[1].NeverUsed)
+	assert.Equal(t, 45*24*time.Hour, unused[1].Idle)
+
+	_, err = FindUnusedAccessTokens([]*AccessToken{{ID: "bad", LastLogin: "?"}}, time.Hour, now)
+	assert.Error(t, err)
+}
+
+const (
+	oldTokenID = "00000000-0000-0000-0000-0000000000a1"
+	newTokenID = "00000000-0000-0000-0000-0000000000a2"
+)
+
+// tokenServer is a minimal token API for rotation tests
+type tokenServer struct {
+	tokens  []*AccessToken
+	created []AccessTokenCreateOptions
+	deleted []string
+}
+
+func (s *tokenServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/tokens":
+		_ = json.NewEncoder(w).Encode(s.tokens)
+	case r.Method == http.MethodPost && r.URL.Path == "/tokens":
+		var opts AccessTokenCreateOptions
+		_ = json.NewDecoder(r.Body).Decode(&opts)
+		s.created = append(s.created, opts)
+		s.tokens = append(s.tokens, &AccessToken{ID: newTokenID, Description: opts.Description, Scopes: opts.Scopes, APIAccess: opts.APIAccess})
+		_ = json.NewEncoder(w).Encode(&AccessTokenCreateResponse{Key: newTokenID, Secret: "new-secret"})
+	case r.Method == http.MethodDelete:
+		s.deleted = append(s.deleted, strings.TrimPrefix(r.URL.Path, "/tokens/"))
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func newTokenServer(t *testing.T) (*tokenServer, *API) {
+	ts := &tokenServer{tokens: []*AccessToken{{
+		ID:          oldTokenID,
+		Description: "ci",
+		Scopes:      AccessTokenScopes{Buckets: []string{scopeBucket}},
+		APIAccess:   AccessTokenAPIAccessDisabled,
+		Active:      true,
+	}}}
+	server := httptest.NewServer(ts)
+	t.Cleanup(server.Close)
+
+	client, err := New("test-key", "test-secret", WithAPIEndpointURL(server.URL))
+	require.NoError(t, err)
+	return ts, client
+}
+
+func TestRotateAccessToken(t *testing.T) {
+	ts, client := newTokenServer(t)
+
+	var delivered *AccessTokenCreateResponse
+	rotation, err := client.RotateAccessToken(context.Background(), oldTokenID, &AccessTokenRotateOptions{
+		Deliver: func(ctx context.Context, created *AccessTokenCreateResponse) error {
+			delivered = created
+			assert.Empty(t, ts.deleted, "old token is deleted after delivery")
+			return nil
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "new-secret", delivered.Secret)
+	assert.Equal(t, oldTokenID, rotation.Previous.ID)
+	assert.True(t, rotation.PreviousDeleted)
+	assert.Equal(t, []string{oldTokenID}, ts.deleted)
+
+	require.Len(t, ts.created, 1)
+	assert.Equal(t, "ci", ts.created[0].Description)
+	assert.Equal(t, []string{scopeBucket}, ts.created[0].Scopes.Buckets)
+	assert.Equal(t, AccessTokenAPIAccessDisabled, ts.created[0].APIAccess)
+}
+
+func TestRotateAccessToken_Expiry(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	weekly := &AccessToken{CreatedAt: now.AddDate(0, 0, -1), ExpiresAt: now.AddDate(0, 0, 6)}
+
+	expiresAt, err := (&AccessTokenRotateOptions{}).expiresAt(weekly, now)
+	require.NoError(t, err)
+	assert.Equal(t, now.AddDate(0, 0, 7), expiresAt, "lifetime is carried over")
+
+	expiresAt, err = (&AccessTokenRotateOptions{}).expiresAt(&AccessToken{ExpiresAt: now.Add(time.Hour)}, now)
+	require.NoError(t, err)
+	assert.Equal(t, now.Add(time.Hour), expiresAt, "old expiry is kept when the lifetime is unknown")
+
+	expiresAt, err = (&AccessTokenRotateOptions{}).expiresAt(&AccessToken{}, now)
+	require.NoError(t, err)
+	assert.True(t, expiresAt.IsZero())
+
+	expiresAt, err = (&AccessTokenRotateOptions{NoExpiry: true}).expiresAt(weekly, now)
+	require.NoError(t, err)
+	assert.True(t, expiresAt.IsZero())
+
+	expiresAt, err = (&AccessTokenRotateOptions{ExpiresAt: now.AddDate(0, 1, 0)}).expiresAt(weekly, now)
+	require.NoError(t, err)
+	assert.Equal(t, now.AddDate(0, 1, 0), expiresAt)
+
+	_, err = (&AccessTokenRotateOptions{NoExpiry: true, ExpiresAt: now}).expiresAt(weekly, now)
+	assert.Error(t, err)
+
+	// the created token expires
+	ts, client := newTokenServer(t)
+	ts.tokens[0].CreatedAt = time.Now().Add(-time.Hour)
+	ts.tokens[0].ExpiresAt = time.Now().Add(23 * time.Hour)
+	_, err = client.RotateAccessToken(context.Background(), oldTokenID, &AccessTokenRotateOptions{
+		Deliver: func(ctx context.Context, created *AccessTokenCreateResponse) error { return nil },
+	})
+	require.NoError(t, err)
+	require.Len(t, ts.created, 1)
+	assert.WithinDuration(t, time.Now().Add(24*time.Hour), ts.created[0].ExpiresAt, time.Minute)
+}
+
+func TestRotateAccessToken_DeliverFails(t *testing.T) {
+	ts, client := newTokenServer(t)
+
+	_, err := client.RotateAccessToken(context.Background(), oldTokenID, &AccessTokenRotateOptions{
+		Deliver: func(ctx context.Context, created *AccessTokenCreateResponse) error {
+			return errors.New("vault sealed")
+		},
+	})
+	assert.EqualError(t, err, "failed to deliver token: vault sealed")
+	assert.Equal(t, []string{newTokenID}, ts.deleted, "new token is removed, old one kept")
+}
+
+func TestRotateAccessToken_GracePeriodCancelled(t *testing.T) {
+	ts, client := newTokenServer(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	rotation, err := client.RotateAccessToken(ctx, oldTokenID, &AccessTokenRotateOptions{
+		GracePeriod: time.Hour,
+		Deliver: func(ctx context.Context, created *AccessTokenCreateResponse) error {
+			cancel()
+			return nil
+		},
+	})
+	assert.Equal(t, context.Canceled, err)
+	require.NotNil(t, rotation)
+	assert.False(t, rotation.PreviousDeleted)
+	assert.Empty(t, ts.deleted)
+
+	require.NoError(t, client.FinishAccessTokenRotation(rotation))
+	require.NoError(t, client.FinishAccessTokenRotation(rotation))
+	assert.Equal(t, []string{oldTokenID}, ts.deleted)
+}
+
+func TestRotateAccessToken_NotFound(t *testing.T) {
+	_, client := newTokenServer(t)
+
+	_, err := client.RotateAccessToken(context.Background(), "missing", &AccessTokenRotateOptions{
+		Deliver: func(ctx context.Context, created *AccessTokenCreateResponse) error { return nil },
+	})
+	var notFound *NotFoundError
+	assert.True(t, errors.As(err, &notFound))
+}
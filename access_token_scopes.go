@@ -0,0 +1,158 @@
+package webhookrelay
+
+import (
+	"fmt"
+	"time"
+)
+
+// AccessPermission is the access a token has to a resource kind
+type AccessPermission string
+
+// Available permissions, they are ordered from the least to the most
+// access
+const (
+	AccessPermissionNone AccessPermission = "none"
+	AccessPermissionRead AccessPermission = "read"
+	// AccessPermissionConfig allows reading functions and changing their
+	// configuration variables, but not their code. Functions only.
+	AccessPermissionConfig    AccessPermission = "config"
+	AccessPermissionReadWrite AccessPermission = "read_write"
+)
+
+// AccessAction is an operation checked by AccessTokenScopes.Allows
+type AccessAction string
+
+// Available actions
+const (
+	AccessActionRead AccessAction = "read"
+	// AccessActionConfigure changes function configuration variables
+	AccessActionConfigure AccessAction = "configure"
+	AccessActionWrite     AccessAction = "write"
+)
+
+// AccessResource is an object checked by AccessTokenScopes.Allows. Scope
+// entries match either the ID or the name. Inputs and outputs are covered
+// by their bucket's scope, pass the bucket ID or name for them.
+type AccessResource struct {
+	Kind ResourceKind
+	ID   string
+	Name string
+}
+
+// Allows reports whether a token with these scopes can perform the action
+// on the resource. It's a client-side check, the API enforces the scopes.
+func (s *AccessTokenScopes) Allows(action AccessAction, resource AccessResource) bool {
+	refs, permission, scoped := s.forKind(resource.Kind)
+	if !scoped {
+		return true
+	}
+	if !permission.allows(action) {
+		return false
+	}
+	if len(refs) == 0 {
+		return true
+	}
+	for _, ref := range refs {
+		if ref != "" && (ref == resource.ID || ref == resource.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Allows reports whether the token is active, not expired and its scopes
+// allow the action on the resource
+func (t *AccessToken) Allows(action AccessAction, resource AccessResource) bool {
+	return t.Active && !t.Expired(time.Now()) && t.APIAccess != AccessTokenAPIAccessDisabled && t.Scopes.Allows(action, resource)
+}
+
+// Validate checks permissions and scope entries
+func (s *AccessTokenScopes) Validate() error {
+	for _, kind := range []ResourceKind{ResourceBucket, ResourceTunnel, ResourceFunction} {
+		refs, permission, _ := s.forKind(kind)
+		switch permission {
+		case AccessPermissionNone, AccessPermissionRead, AccessPermissionReadWrite:
+		case AccessPermissionConfig:
+			if kind != ResourceFunction {
+				return fmt.Errorf("%s access: '%s' is only available for functions", kind, permission)
+			}
+		default:
+			return fmt.Errorf("%s access: unknown permission '%s'", kind, permission)
+		}
+		for _, ref := range refs {
+			if ref == "" {
+				return fmt.Errorf("%s scope: empty reference", kind)
+			}
+		}
+	}
+	return nil
+}
+
+// forKind returns scope entries and permission for the kind, scoped is
+// false for kinds tokens can't be limited to
+func (s *AccessTokenScopes) forKind(kind ResourceKind) (refs []string, permission AccessPermission, scoped bool) {
+	switch kind {
+	case ResourceBucket, ResourceInput, ResourceOutput:
+		refs, permission = s.Buckets, s.BucketAccess
+	case ResourceTunnel:
+		refs, permission = s.Tunnels, s.TunnelAccess
+	case ResourceFunction:
+		refs, permission = s.Functions, s.FunctionAccess
+	default:
+		return nil, "", false
+	}
+	if permission == "" {
+		permission = AccessPermissionReadWrite
+	}
+	return refs, permission, true
+}
+
+func (p AccessPermission) allows(action AccessAction) bool {
+	switch p {
+	case AccessPermissionReadWrite:
+		return true
+	case AccessPermissionConfig:
+		return action == AccessActionRead || action == AccessActionConfigure
+	case AccessPermissionRead:
+		return action == AccessActionRead
+	}
+	return false
+}
+
+// resolveTokenScopes validates the scopes and returns a copy with names
+// replaced by IDs
+func (api *API) resolveTokenScopes(scopes *AccessTokenScopes) (*AccessTokenScopes, error) {
+	if err := scopes.Validate(); err != nil {
+		return nil, err
+	}
+
+	resolved := *scopes
+	var err error
+	if resolved.Buckets, err = api.resolveScopeRefs(ResourceBucket, scopes.Buckets); err != nil {
+		return nil, err
+	}
+	if resolved.Tunnels, err = api.resolveScopeRefs(ResourceTunnel, scopes.Tunnels); err != nil {
+		return nil, err
+	}
+	if resolved.Functions, err = api.resolveScopeRefs(ResourceFunction, scopes.Functions); err != nil {
+		return nil, err
+	}
+	return &resolved, nil
+}
+
+func (api *API) resolveScopeRefs(kind ResourceKind, refs []string) ([]string, error) {
+	if refs == nil {
+		return nil, nil
+	}
+	ids := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		id, err := api.resolveRef(kind, "", ref)
+		if err != nil {
+			return nil, fmt.Errorf("%s scope: %w", kind, err)
+		}
+		if !containsString(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
@@ -0,0 +1,119 @@
+package webhookrelay
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	scopeFunction = "00000000-0000-0000-0000-0000000000f1"
+	scopeBucket   = "00000000-0000-0000-0000-0000000000b1"
+)
+
+func TestAccessTokenScopes_Allows(t *testing.T) {
+	deployFn := AccessResource{Kind: ResourceFunction, ID: scopeFunction, Name: "deploy"}
+	otherFn := AccessResource{Kind: ResourceFunction, ID: "00000000-0000-0000-0000-0000000000f2", Name: "other"}
+	bucket := AccessResource{Kind: ResourceBucket, ID: scopeBucket, Name: "github"}
+	output := AccessResource{Kind: ResourceOutput, ID: scopeBucket}
+	domain := AccessResource{Kind: ResourceDomain, Name: "hooks.example.com"}
+
+	unscoped := &AccessTokenScopes{}
+	assert.True(t, unscoped.Allows(AccessActionWrite, deployFn))
+	assert.True(t, unscoped.Allows(AccessActionWrite, bucket))
+
+	// CI token that can only update one function's config
+	ci := &AccessTokenScopes{
+		Functions:      []string{"deploy"},
+		FunctionAccess: AccessPermissionConfig,
+		BucketAccess:   AccessPermissionNone,
+		TunnelAccess:   AccessPermissionNone,
+	}
+	assert.True(t, ci.Allows(AccessActionRead, deployFn))
+	assert.True(t, ci.Allows(AccessActionConfigure, deployFn))
+	assert.False(t, ci.Allows(AccessActionWrite, deployFn))
+	assert.False(t, ci.Allows(AccessActionConfigure, otherFn))
+	assert.False(t, ci.Allows(AccessActionRead, bucket))
+	assert.False(t, ci.Allows(AccessActionRead, output))
+	assert.True(t, ci.Allows(AccessActionRead, domain), "domains can't be scoped")
+
+	readOnly := &AccessTokenScopes{Buckets: []string{scopeBucket}, BucketAccess: AccessPermissionRead}
+	assert.True(t, readOnly.Allows(AccessActionRead, bucket))
+	assert.True(t, readOnly.Allows(AccessActionRead, output))
+	assert.False(t, readOnly.Allows(AccessActionWrite, output))
+	assert.False(t, readOnly.Allows(AccessActionRead, AccessResource{Kind: ResourceBucket, Name: "stripe"}))
+
+	token := &AccessToken{Active: true, Scopes: *readOnly}
+	assert.True(t, token.Allows(AccessActionRead, bucket))
+	token.ExpiresAt = time.Now().Add(time.Hour)
+	assert.True(t, token.Allows(AccessActionRead, bucket))
+	token.ExpiresAt = time.Now().Add(-time.Second)
+	assert.False(t, token.Allows(AccessActionRead, bucket))
+	token.ExpiresAt = time.Time{}
+	token.APIAccess = AccessTokenAPIAccessDisabled
+	assert.False(t, token.Allows(AccessActionRead, bucket))
+}
+
+func TestAccessTokenScopes_Validate(t *testing.T) {
+	assert.NoError(t, (&AccessTokenScopes{}).Validate())
+	assert.NoError(t, (&AccessTokenScopes{FunctionAccess: AccessPermissionConfig, BucketAccess: AccessPermissionRead}).Validate())
+	assert.EqualError(t, (&AccessTokenScopes{BucketAccess: AccessPermissionConfig}).Validate(), "bucket access: 'config' is only available for functions")
+	assert.EqualError(t, (&AccessTokenScopes{TunnelAccess: "admin"}).Validate(), "tunnel access: unknown permission 'admin'")
+	assert.EqualError(t, (&AccessTokenScopes{Functions: []string{""}}).Validate(), "function scope: empty reference")
+}
+
+func TestCreateAccessToken_ResolvesScopes(t *testing.T) {
+	var created AccessTokenCreateOptions
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/functions":
+			_ = json.NewEncoder(w).Encode([]*Function{{Id: scopeFunction, Name: "deploy"}})
+		case "/buckets":
+			_ = json.NewEncoder(w).Encode([]*Bucket{{ID: scopeBucket, Name: "github"}})
+		case "/tunnels":
+			_, _ = w.Write([]byte("[]"))
+		case "/tokens":
+			if err := json.NewDecoder(r.Body).Decode(&created); err != nil {
+				t.Error(err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(&AccessTokenCreateResponse{Key: "k", Secret: "s"})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client, err := New("test-key", "test-secret", WithAPIEndpointURL(server.URL))
+	require.NoError(t, err)
+
+	opts := &AccessTokenCreateOptions{
+		Description: "ci",
+		Scopes: AccessTokenScopes{
+			Functions:      []string{"deploy", scopeFunction},
+			Buckets:        []string{"github"},
+			FunctionAccess: AccessPermissionConfig,
+			BucketAccess:   AccessPermissionRead,
+		},
+	}
+	_, err = client.CreateAccessToken(opts)
+	require.NoError(t, err)
+	assert.Equal(t, []string{scopeFunction}, created.Scopes.Functions)
+	assert.Equal(t, []string{scopeBucket}, created.Scopes.Buckets)
+	assert.Equal(t, AccessPermissionConfig, created.Scopes.FunctionAccess)
+	assert.Equal(t, AccessPermissionRead, created.Scopes.BucketAccess)
+	assert.Equal(t, []string{"deploy", scopeFunction}, opts.Scopes.Functions, "options are not modified")
+
+	opts.Scopes.Tunnels = []string{"missing"}
+	_, err = client.CreateAccessToken(opts)
+	var notFound *NotFoundError
+	assert.True(t, errors.As(err, &notFound))
+}
@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/pkg/errors"
@@ -14,7 +16,7 @@ type AccessToken struct {
 	ID          string            `json:"id"`         // read-only
 	CreatedAt   time.Time         `json:"created_at"` // read-only
 	UpdatedAt   time.Time         `json:"updated_at"` // read-only
-	LastLogin   string            `json:"last_login"` // read-only
+	LastLogin   string            `json:"last_login"` // read-only, see LastLoginTime
 	Description string            `json:"description"`
 	Scopes      AccessTokenScopes `json:"scopes"`
 	// APIAccess allows to enable/disabled API access. Tokens that have disabled
@@ -22,6 +24,42 @@ type AccessToken struct {
 	// Defaults to "enabled"
 	APIAccess AccessTokenAPIAccess `json:"api_access"`
 	Active    bool                 `json:"active"`
+	// ExpiresAt - the token stops working after this time, zero never expires
+	ExpiresAt time.Time `json:"-"`
+}
+
+// Expired reports whether the token has expired at the given time
+func (t *AccessToken) Expired(now time.Time) bool {
+	return !t.ExpiresAt.IsZero() && !now.Before(t.ExpiresAt)
+}
+
+// lastLoginLayouts are accepted LastLogin formats, the API has returned
+// both RFC3339 and Go's default time formatting
+var lastLoginLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999 -0700 MST",
+	"2006-01-02 15:04:05 -0700 MST",
+}
+
+// LastLoginTime parses LastLogin, zero time is returned if the token was
+// never used
+func (t *AccessToken) LastLoginTime() (time.Time, error) {
+	s := strings.TrimSpace(t.LastLogin)
+	if s == "" || s == "never" || s == "0" {
+		return time.Time{}, nil
+	}
+	if unix, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(unix, 0), nil
+	}
+	for _, layout := range lastLoginLayouts {
+		if parsed, err := time.Parse(layout, s); err == nil {
+			if parsed.Year() <= 1 {
+				return time.Time{}, nil
+			}
+			return parsed, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("token %s: unable to parse last login '%s'", t.ID, t.LastLogin)
 }
 
 // MarshalJSON helper to marshal unix time
@@ -30,10 +68,12 @@ func (t *AccessToken) MarshalJSON() ([]byte, error) {
 	return json.Marshal(&struct {
 		CreatedAt int64 `json:"created_at"`
 		UpdatedAt int64 `json:"updated_at"`
+		ExpiresAt int64 `json:"expires_at,omitempty"`
 		*Alias
 	}{
 		CreatedAt: t.CreatedAt.Unix(),
 		UpdatedAt: t.UpdatedAt.Unix(),
+		ExpiresAt: optionalUnix(t.ExpiresAt),
 		Alias:     (*Alias)(t),
 	})
 }
@@ -44,6 +84,7 @@ func (t *AccessToken) UnmarshalJSON(data []byte) error {
 	aux := &struct {
 		CreatedAt json.RawMessage `json:"created_at"`
 		UpdatedAt json.RawMessage `json:"updated_at"`
+		ExpiresAt json.RawMessage `json:"expires_at"`
 		*Alias
 	}{
 		Alias: (*Alias)(t),
@@ -61,6 +102,10 @@ func (t *AccessToken) UnmarshalJSON(data []byte) error {
 	if err != nil {
 		return err
 	}
+	t.ExpiresAt, err = parseOptionalTime(aux.ExpiresAt)
+	if err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -84,12 +129,51 @@ type AccessTokenCreateOptions struct {
 	Description string               `json:"description"`
 	Scopes      AccessTokenScopes    `json:"scopes"`
 	APIAccess   AccessTokenAPIAccess `json:"api_access"`
+	// ExpiresAt - optional expiry, zero never expires
+	ExpiresAt time.Time `json:"-"`
+}
+
+// MarshalJSON helper to marshal unix time
+func (o *AccessTokenCreateOptions) MarshalJSON() ([]byte, error) {
+	type Alias AccessTokenCreateOptions
+	return json.Marshal(&struct {
+		ExpiresAt int64 `json:"expires_at,omitempty"`
+		*Alias
+	}{
+		ExpiresAt: optionalUnix(o.ExpiresAt),
+		Alias:     (*Alias)(o),
+	})
+}
+
+// UnmarshalJSON helper to unmarshal unix time or RFC3339 string
+func (o *AccessTokenCreateOptions) UnmarshalJSON(data []byte) error {
+	type Alias AccessTokenCreateOptions
+	aux := &struct {
+		ExpiresAt json.RawMessage `json:"expires_at"`
+		*Alias
+	}{
+		Alias: (*Alias)(o),
+	}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	var err error
+	o.ExpiresAt, err = parseOptionalTime(aux.ExpiresAt)
+	return err
 }
 
-// AccessTokenScopes define optional limits for tokens
+// AccessTokenScopes define optional limits for tokens. Empty lists allow
+// all objects of the kind, entries can be names or IDs, names are resolved
+// to IDs when the token is created or updated. See Allows.
 type AccessTokenScopes struct {
-	Tunnels []string `json:"tunnels"`
-	Buckets []string `json:"buckets"`
+	Tunnels   []string `json:"tunnels"`
+	Buckets   []string `json:"buckets"`
+	Functions []string `json:"functions,omitempty"`
+	// Access per resource kind, empty defaults to read-write
+	TunnelAccess   AccessPermission `json:"tunnel_access,omitempty"`
+	BucketAccess   AccessPermission `json:"bucket_access,omitempty"`
+	FunctionAccess AccessPermission `json:"function_access,omitempty"`
 }
 
 // AccessTokenListOptions - TODO
@@ -120,7 +204,17 @@ func (api *API) ListAccessTokens(options *AccessTokenListOptions) ([]*AccessToke
 // should be saved on user's side. Server has already hashed the secret so it can't
 // be recovered. If the secret is lost, just create a new access token.
 func (api *API) CreateAccessToken(options *AccessTokenCreateOptions) (*AccessTokenCreateResponse, error) {
-	resp, err := api.makeRequest(http.MethodPost, "/tokens", options)
+	if !options.ExpiresAt.IsZero() && !options.ExpiresAt.After(time.Now()) {
+		return nil, fmt.Errorf("access token expiry %s is in the past", options.ExpiresAt.UTC().Format(time.RFC3339))
+	}
+	scopes, err := api.resolveTokenScopes(&options.Scopes)
+	if err != nil {
+		return nil, err
+	}
+	opts := *options
+	opts.Scopes = *scopes
+
+	resp, err := api.makeRequest(http.MethodPost, "/tokens", &opts)
 	if err != nil {
 		return nil, err
 	}
@@ -148,8 +242,14 @@ func (api *API) UpdateAccessToken(options *AccessToken) (*AccessToken, error) {
 	if !IsUUID(options.ID) {
 		return nil, fmt.Errorf("invalid access token ID '%s'", options.ID)
 	}
+	scopes, err := api.resolveTokenScopes(&options.Scopes)
+	if err != nil {
+		return nil, err
+	}
+	token := *options
+	token.Scopes = *scopes
 
-	resp, err := api.makeRequest(http.MethodPut, "/tokens/"+options.ID, options)
+	resp, err := api.makeRequest(http.MethodPut, "/tokens/"+options.ID, &token)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,79 @@
+package webhookrelay
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// generatedSecretBytes is the entropy of generated passwords and tokens
+const generatedSecretBytes = 32
+
+// GenerateSecret returns a random URL safe string suitable as a bucket
+// password or token
+func GenerateSecret() (string, error) {
+	b := make([]byte, generatedSecretBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate secret: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// NewBasicAuth returns basic auth with a generated password. A bucket
+// accepts a single set of credentials, there is no window in which both the
+// old and new ones work, so set them with PatchBucket once senders are
+// ready to switch.
+func NewBasicAuth(username string) (BucketAuth, error) {
+	if username == "" {
+		return BucketAuth{}, fmt.Errorf("username must not be empty")
+	}
+	password, err := GenerateSecret()
+	if err != nil {
+		return BucketAuth{}, err
+	}
+	return BucketAuth{Type: AuthTypeBasic, Username: username, Password: password}, nil
+}
+
+// NewTokenAuth returns token auth with a generated token
+func NewTokenAuth() (BucketAuth, error) {
+	token, err := GenerateSecret()
+	if err != nil {
+		return BucketAuth{}, err
+	}
+	return BucketAuth{Type: AuthTypeToken, Token: token}, nil
+}
+
+// Header returns the header senders must add to their requests, it's empty
+// when the bucket doesn't require auth
+func (a BucketAuth) Header() http.Header {
+	header := http.Header{}
+	switch a.Type {
+	case AuthTypeBasic:
+		credentials := base64.StdEncoding.EncodeToString([]byte(a.Username + ":" + a.Password))
+		header.Set("Authorization", "Basic "+credentials)
+	case AuthTypeToken:
+		header.Set("Authorization", "Bearer "+a.Token)
+	}
+	return header
+}
+
+// SenderURL adds the credentials to an input endpoint URL for senders that
+// can't set headers. Basic auth is added as user info, tokens as the token
+// query parameter.
+func (a BucketAuth) SenderURL(endpoint string) (*url.URL, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	switch a.Type {
+	case AuthTypeBasic:
+		u.User = url.UserPassword(a.Username, a.Password)
+	case AuthTypeToken:
+		q := u.Query()
+		q.Set("token", a.Token)
+		u.RawQuery = q.Encode()
+	}
+	return u, nil
+}
@@ -0,0 +1,168 @@
+package webhookrelay
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// BucketRateLimit limits how fast inputs of the bucket accept webhooks.
+// Requests over the limit are rejected with 429 Too Many Requests and
+// logged with RequestStatusRejected.
+type BucketRateLimit struct {
+	// RequestsPerSecond for the whole bucket, 0 means no bucket limit
+	RequestsPerSecond float64 `json:"requests_per_second,omitempty"`
+	// Burst allows short spikes above RequestsPerSecond, defaults to
+	// RequestsPerSecond rounded up
+	Burst int `json:"burst,omitempty"`
+	// PerIPRequestsPerSecond limits each source IP separately so that a
+	// single sender can't use up the bucket limit, 0 means no limit
+	PerIPRequestsPerSecond float64 `json:"per_ip_requests_per_second,omitempty"`
+	PerIPBurst             int     `json:"per_ip_burst,omitempty"`
+}
+
+// Validate checks the rate limit
+func (l *BucketRateLimit) Validate() error {
+	if l.RequestsPerSecond < 0 || l.PerIPRequestsPerSecond < 0 {
+		return fmt.Errorf("rate limit: requests per second must not be negative")
+	}
+	if l.Burst < 0 || l.PerIPBurst < 0 {
+		return fmt.Errorf("rate limit: burst must not be negative")
+	}
+	if l.Burst > 0 && l.RequestsPerSecond == 0 {
+		return fmt.Errorf("rate limit: burst requires requests per second")
+	}
+	if l.PerIPBurst > 0 && l.PerIPRequestsPerSecond == 0 {
+		return fmt.Errorf("rate limit: per IP burst requires per IP requests per second")
+	}
+	if l.RequestsPerSecond > 0 && l.PerIPRequestsPerSecond > l.RequestsPerSecond {
+		return fmt.Errorf("rate limit: per IP limit is higher than the bucket limit")
+	}
+	return nil
+}
+
+// BucketQuota caps the number of webhooks a bucket accepts per day (UTC).
+// Once used up, inputs reject webhooks until the next day.
+type BucketQuota struct {
+	// DailyRequests, 0 means no limit
+	DailyRequests int `json:"daily_requests,omitempty"`
+	// DailyBytes of request bodies, 0 means no limit
+	DailyBytes int64 `json:"daily_bytes,omitempty"`
+}
+
+// Validate checks the quota
+func (q *BucketQuota) Validate() error {
+	if q.DailyRequests < 0 || q.DailyBytes < 0 {
+		return fmt.Errorf("quota: limits must not be negative")
+	}
+	return nil
+}
+
+// validateBucketLimits checks optional rate limit and quota
+func validateBucketLimits(rateLimit *BucketRateLimit, quota *BucketQuota) error {
+	if rateLimit != nil {
+		if err := rateLimit.Validate(); err != nil {
+			return err
+		}
+	}
+	if quota != nil {
+		return quota.Validate()
+	}
+	return nil
+}
+
+// BucketUsage - usage counters of the current quota period
+type BucketUsage struct {
+	BucketID    string    `json:"bucket_id"`
+	PeriodStart time.Time `json:"period_start"`
+	PeriodEnd   time.Time `json:"period_end"`
+
+	Requests int   `json:"requests"`
+	Bytes    int64 `json:"bytes"`
+	// RateLimited - requests rejected by the rate limit in this period
+	RateLimited int `json:"rate_limited"`
+	// QuotaRejected - requests rejected after the quota was used up
+	QuotaRejected int `json:"quota_rejected"`
+
+	Quota *BucketQuota `json:"quota,omitempty"`
+}
+
+// RemainingRequests returns requests left in the period, -1 if there's no
+// request quota
+func (u *BucketUsage) RemainingRequests() int {
+	if u.Quota == nil || u.Quota.DailyRequests == 0 {
+		return -1
+	}
+	if remaining := u.Quota.DailyRequests - u.Requests; remaining > 0 {
+		return remaining
+	}
+	return 0
+}
+
+// RemainingBytes returns bytes left in the period, -1 if there's no byte
+// quota
+func (u *BucketUsage) RemainingBytes() int64 {
+	if u.Quota == nil || u.Quota.DailyBytes == 0 {
+		return -1
+	}
+	if remaining := u.Quota.DailyBytes - u.Bytes; remaining > 0 {
+		return remaining
+	}
+	return 0
+}
+
+// Exceeded reports whether any quota of the period is used up
+func (u *BucketUsage) Exceeded() bool {
+	return u.RemainingRequests() == 0 || u.RemainingBytes() == 0
+}
+
+// UnmarshalJSON helper to unmarshal unix time or RFC3339 string
+func (u *BucketUsage) UnmarshalJSON(data []byte) error {
+	type Alias BucketUsage
+	aux := &struct {
+		PeriodStart json.RawMessage `json:"period_start"`
+		PeriodEnd   json.RawMessage `json:"period_end"`
+		*Alias
+	}{
+		Alias: (*Alias)(u),
+	}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	var err error
+	u.PeriodStart, err = parseTime(aux.PeriodStart)
+	if err != nil {
+		return err
+	}
+	u.PeriodEnd, err = parseTime(aux.PeriodEnd)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetBucketUsage returns usage counters of the bucket for the current day
+func (api *API) GetBucketUsage(ref string) (*BucketUsage, error) {
+	bucketID, err := api.ensureBucketID(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := api.makeRequest(http.MethodGet, "/buckets/"+bucketID+"/usage", nil)
+	if err != nil {
+		return nil, errors.Wrap(err, errMakeRequestError)
+	}
+
+	var usage BucketUsage
+	if err := json.Unmarshal(resp, &usage); err != nil {
+		return nil, errors.Wrap(err, errUnmarshalError)
+	}
+	if usage.BucketID == "" {
+		usage.BucketID = bucketID
+	}
+	return &usage, nil
+}
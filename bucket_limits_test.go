@@ -0,0 +1,77 @@
+package webhookrelay
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBucketLimitsValidate(t *testing.T) {
+	assert.NoError(t, (&BucketRateLimit{RequestsPerSecond: 10, Burst: 20, PerIPRequestsPerSecond: 2}).Validate())
+	assert.NoError(t, (&BucketRateLimit{PerIPRequestsPerSecond: 1, PerIPBurst: 5}).Validate())
+	assert.EqualError(t, (&BucketRateLimit{RequestsPerSecond: -1}).Validate(), "rate limit: requests per second must not be negative")
+	assert.EqualError(t, (&BucketRateLimit{Burst: 5}).Validate(), "rate limit: burst requires requests per second")
+	assert.EqualError(t, (&BucketRateLimit{RequestsPerSecond: 1, PerIPRequestsPerSecond: 5}).Validate(), "rate limit: per IP limit is higher than the bucket limit")
+
+	assert.NoError(t, (&BucketQuota{DailyRequests: 1000}).Validate())
+	assert.EqualError(t, (&BucketQuota{DailyBytes: -1}).Validate(), "quota: limits must not be negative")
+
+	client, err := New("test-key", "test-secret", WithAPIEndpointURL("http://127.0.0.1:1"))
+	require.NoError(t, err)
+	_, err = client.CreateBucket(&BucketCreateOptions{Name: "b", Quota: &BucketQuota{DailyRequests: -5}})
+	assert.EqualError(t, err, "quota: limits must not be negative")
+}
+
+func TestGetBucketUsage(t *testing.T) {
+	var created BucketCreateOptions
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/buckets":
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&created))
+			_ = json.NewEncoder(w).Encode(&Bucket{ID: maintBucket1, Name: "partners", RateLimit: created.RateLimit, Quota: created.Quota})
+		case r.Method == http.MethodGet && r.URL.Path == "/buckets/"+maintBucket1+"/usage":
+			_, _ = w.Write([]byte(`{
+				"bucket_id": "` + maintBucket1 + `",
+				"period_start": "2026-10-18T00:00:00Z",
+				"period_end": 1792368000,
+				"requests": 1000,
+				"bytes": 2048,
+				"rate_limited": 42,
+				"quota_rejected": 7,
+				"quota": {"daily_requests": 1000, "daily_bytes": 4096}
+			}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client, err := New("test-key", "test-secret", WithAPIEndpointURL(server.URL))
+	require.NoError(t, err)
+
+	_, err = client.CreateBucket(&BucketCreateOptions{
+		Name:      "partners",
+		RateLimit: &BucketRateLimit{RequestsPerSecond: 50, Burst: 100, PerIPRequestsPerSecond: 5},
+		Quota:     &BucketQuota{DailyRequests: 1000},
+	})
+	require.NoError(t, err)
+	require.NotNil(t, created.RateLimit)
+	assert.Equal(t, 5.0, created.RateLimit.PerIPRequestsPerSecond)
+	assert.Equal(t, 1000, created.Quota.DailyRequests)
+
+	usage, err := client.GetBucketUsage(maintBucket1)
+	require.NoError(t, err)
+	assert.Equal(t, 1000, usage.Requests)
+	assert.Equal(t, 42, usage.RateLimited)
+	assert.Equal(t, 7, usage.QuotaRejected)
+	assert.Equal(t, int64(1792368000), usage.PeriodEnd.Unix())
+	assert.Equal(t, 0, usage.RemainingRequests())
+	assert.Equal(t, int64(2048), usage.RemainingBytes())
+	assert.True(t, usage.Exceeded())
+}
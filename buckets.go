@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"reflect"
+	"strings"
 	"time"
 
 	"github.com/pkg/errors"
@@ -25,8 +27,14 @@ type Bucket struct {
 	// to be sent to the bucket. This is useful for large file uploads
 	LargeWebhooks bool       `json:"large_webhooks"`
 	Auth          BucketAuth `json:"auth"`
-	Inputs        []*Input   `json:"inputs"`  // readonly
-	Outputs       []*Output  `json:"outputs"` // readonly
+	// RetryPolicy and DeadLetter apply to outputs without their own policy
+	RetryPolicy *WebhookRetryPolicy `json:"retry_policy,omitempty"`
+	DeadLetter  *DeadLetterPolicy   `json:"dead_letter,omitempty"`
+	// RateLimit and Quota limit inbound webhooks, see GetBucketUsage
+	RateLimit *BucketRateLimit `json:"rate_limit,omitempty"`
+	Quota     *BucketQuota     `json:"quota,omitempty"`
+	Inputs    []*Input         `json:"inputs"`  // readonly
+	Outputs   []*Output        `json:"outputs"` // readonly
 }
 
 // MarshalJSON helper to marshal unix time
@@ -77,10 +85,86 @@ type BucketAuth struct {
 	Token    string   `json:"token,omitempty"`
 }
 
-// BucketCreateOptions create opts
+// Validate checks that credentials required by the auth type are set
+func (a *BucketAuth) Validate() error {
+	switch a.Type {
+	case AuthTypeNone:
+	case AuthTypeBasic:
+		if a.Username == "" || a.Password == "" {
+			return fmt.Errorf("basic auth requires username and password")
+		}
+	case AuthTypeToken:
+		if a.Token == "" {
+			return fmt.Errorf("token auth requires a token")
+		}
+	default:
+		return fmt.Errorf("unknown auth type %d", a.Type)
+	}
+	return nil
+}
+
+// BucketCreateOptions create opts. All configurable bucket settings are
+// applied during creation so the bucket never accepts traffic without its auth.
 type BucketCreateOptions struct {
-	Name        string `json:"name"`
-	Description string `json:"description"`
+	Name          string              `json:"name"`
+	Description   string              `json:"description"`
+	Stream        bool                `json:"stream,omitempty"`
+	Ephemeral     bool                `json:"ephemeral,omitempty"`
+	Suspended     bool                `json:"suspended,omitempty"`
+	LargeWebhooks bool                `json:"large_webhooks,omitempty"`
+	Auth          *BucketAuth         `json:"auth,omitempty"`
+	RetryPolicy   *WebhookRetryPolicy `json:"retry_policy,omitempty"`
+	DeadLetter    *DeadLetterPolicy   `json:"dead_letter,omitempty"`
+	RateLimit     *BucketRateLimit    `json:"rate_limit,omitempty"`
+	Quota         *BucketQuota        `json:"quota,omitempty"`
+	// DefaultInput controls the public input the server creates together
+	// with the bucket, leave empty to keep the server default
+	DefaultInput *DefaultInputOptions `json:"default_input,omitempty"`
+}
+
+// DefaultInputOptions skip or customize the default bucket input. Empty
+// fields keep the server defaults.
+type DefaultInputOptions struct {
+	// Skip - don't create the default input
+	Skip        bool                `json:"skip,omitempty"`
+	Name        string              `json:"name,omitempty"`
+	Description string              `json:"description,omitempty"`
+	FunctionID  string              `json:"function_id,omitempty"`
+	Headers     map[string][]string `json:"headers,omitempty"`
+	StatusCode  int                 `json:"status_code,omitempty"`
+	Body        string              `json:"body,omitempty"`
+	// ResponseFromOutput can only be AnyResponseFromOutput as the bucket
+	// has no outputs yet
+	ResponseFromOutput string `json:"response_from_output,omitempty"`
+	CustomDomain       string `json:"custom_domain,omitempty"`
+	PathPrefix         string `json:"path_prefix,omitempty"`
+}
+
+// apply sets non-empty options on the input and reports whether it changed
+func (o *DefaultInputOptions) apply(input *Input) bool {
+	changed := false
+	set := func(dst *string, v string) {
+		if v != "" && *dst != v {
+			*dst = v
+			changed = true
+		}
+	}
+	set(&input.Name, o.Name)
+	set(&input.Description, o.Description)
+	set(&input.FunctionID, o.FunctionID)
+	set(&input.Body, o.Body)
+	set(&input.ResponseFromOutput, o.ResponseFromOutput)
+	set(&input.CustomDomain, o.CustomDomain)
+	set(&input.PathPrefix, o.PathPrefix)
+	if o.StatusCode != 0 && input.StatusCode != o.StatusCode {
+		input.StatusCode = o.StatusCode
+		changed = true
+	}
+	if o.Headers != nil && !reflect.DeepEqual(input.Headers, o.Headers) {
+		input.Headers = o.Headers
+		changed = true
+	}
+	return changed
 }
 
 // BucketDeleteOptions are used to delete bucket
@@ -128,22 +212,163 @@ func (api *API) GetBucket(ref string) (*Bucket, error) {
 	return &result, nil
 }
 
-// CreateBucket creates a Bucket and returns the newly object.
+// CreateBucket creates a Bucket and returns the newly object. When the
+// default input can't be skipped or customized, the created bucket is
+// returned together with the error.
 func (api *API) CreateBucket(options *BucketCreateOptions) (*Bucket, error) {
+	if options.Auth != nil {
+		if err := options.Auth.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	if err := validateDeliveryPolicies(options.RetryPolicy, options.DeadLetter); err != nil {
+		return nil, err
+	}
+	if err := validateBucketLimits(options.RateLimit, options.Quota); err != nil {
+		return nil, err
+	}
+	if in := options.DefaultInput; in != nil && in.ResponseFromOutput != "" && in.ResponseFromOutput != AnyResponseFromOutput {
+		return nil, fmt.Errorf("default input can only respond from '%s'", AnyResponseFromOutput)
+	}
+
 	resp, err := api.makeRequest("POST", "/buckets", options)
 	if err != nil {
 		return nil, err
 	}
+	api.invalidateRefs(ResourceBucket, "")
 
 	var bucket Bucket
 	if err := json.Unmarshal(resp, &bucket); err != nil {
 		return nil, err
 	}
+
+	if !options.authApplied(&bucket) {
+		if err := api.DeleteBucket(&BucketDeleteOptions{Ref: bucket.ID, Force: true}); err != nil {
+			return &bucket, fmt.Errorf("bucket '%s': %w and it could not be deleted: %v", bucket.Name, ErrAuthNotApplied, err)
+		}
+		return nil, fmt.Errorf("bucket '%s': %w, it was deleted", bucket.Name, ErrAuthNotApplied)
+	}
+	if err := api.ensureBucketSettings(&bucket, options); err != nil {
+		return &bucket, err
+	}
+	if err := api.ensureDefaultInput(&bucket, options.DefaultInput); err != nil {
+		return &bucket, err
+	}
 	return &bucket, nil
 }
 
+// unapplied returns settings set in the options that the bucket doesn't
+// have. Secrets are only compared when the server returns them.
+func (o *BucketCreateOptions) unapplied(b *Bucket) []string {
+	var fields []string
+	check := func(name string, ok bool) {
+		if !ok {
+			fields = append(fields, name)
+		}
+	}
+	check("stream", !o.Stream || b.Stream)
+	check("ephemeral", !o.Ephemeral || b.Ephemeral)
+	check("suspended", !o.Suspended || b.Suspended)
+	check("large_webhooks", !o.LargeWebhooks || b.LargeWebhooks)
+	check("auth", o.authApplied(b))
+	check("retry_policy", o.RetryPolicy == nil || b.RetryPolicy != nil)
+	check("dead_letter", o.DeadLetter == nil || b.DeadLetter != nil)
+	check("rate_limit", o.RateLimit == nil || b.RateLimit != nil)
+	check("quota", o.Quota == nil || b.Quota != nil)
+	return fields
+}
+
+// authApplied reports whether the bucket has the requested auth. Secrets
+// are only compared when the server returns them.
+func (o *BucketCreateOptions) authApplied(b *Bucket) bool {
+	if o.Auth == nil {
+		return true
+	}
+	return b.Auth.Type == o.Auth.Type && b.Auth.Username == o.Auth.Username &&
+		(b.Auth.Password == "" || b.Auth.Password == o.Auth.Password) &&
+		(b.Auth.Token == "" || b.Auth.Token == o.Auth.Token)
+}
+
+// ensureBucketSettings updates the bucket with settings the server ignored
+// on create. Auth is checked before, a bucket without it is never kept.
+func (api *API) ensureBucketSettings(bucket *Bucket, options *BucketCreateOptions) error {
+	if len(options.unapplied(bucket)) == 0 {
+		return nil
+	}
+
+	update := *bucket
+	update.Inputs, update.Outputs = nil, nil
+	update.Stream = update.Stream || options.Stream
+	update.Ephemeral = update.Ephemeral || options.Ephemeral
+	update.Suspended = update.Suspended || options.Suspended
+	update.LargeWebhooks = update.LargeWebhooks || options.LargeWebhooks
+	if options.Auth != nil {
+		// the response may leave out secrets, resend the applied auth
+		update.Auth = *options.Auth
+	}
+	if options.RetryPolicy != nil {
+		update.RetryPolicy = options.RetryPolicy
+	}
+	if options.DeadLetter != nil {
+		update.DeadLetter = options.DeadLetter
+	}
+	if options.RateLimit != nil {
+		update.RateLimit = options.RateLimit
+	}
+	if options.Quota != nil {
+		update.Quota = options.Quota
+	}
+
+	updated, err := api.UpdateBucket(&update)
+	if err != nil {
+		return fmt.Errorf("bucket was created without %s: %w", strings.Join(options.unapplied(bucket), ", "), err)
+	}
+	updated.Inputs, updated.Outputs = bucket.Inputs, bucket.Outputs
+	*bucket = *updated
+	if fields := options.unapplied(bucket); len(fields) > 0 {
+		return fmt.Errorf("server did not apply bucket settings: %s", strings.Join(fields, ", "))
+	}
+	return nil
+}
+
+// ensureDefaultInput applies default input options the server didn't
+func (api *API) ensureDefaultInput(bucket *Bucket, options *DefaultInputOptions) error {
+	if options == nil || len(bucket.Inputs) == 0 {
+		return nil
+	}
+
+	if options.Skip {
+		for _, input := range bucket.Inputs {
+			err := api.DeleteInput(&InputDeleteOptions{Bucket: bucket.ID, Input: input.ID})
+			if err != nil {
+				return fmt.Errorf("failed to delete default input: %w", err)
+			}
+		}
+		bucket.Inputs = nil
+		return nil
+	}
+
+	input := *bucket.Inputs[0]
+	if !options.apply(&input) {
+		return nil
+	}
+	input.BucketID = bucket.ID
+	updated, err := api.UpdateInput(&input)
+	if err != nil {
+		return fmt.Errorf("failed to update default input: %w", err)
+	}
+	bucket.Inputs[0] = updated
+	return nil
+}
+
 // UpdateBucket updates a Bucket on the server and returns the updated object.
 func (api *API) UpdateBucket(options *Bucket) (*Bucket, error) {
+	if err := validateDeliveryPolicies(options.RetryPolicy, options.DeadLetter); err != nil {
+		return nil, err
+	}
+	if err := validateBucketLimits(options.RateLimit, options.Quota); err != nil {
+		return nil, err
+	}
 	bucketID, err := api.ensureBucketID(options.ID)
 	if err != nil {
 		return nil, err
@@ -154,6 +379,7 @@ func (api *API) UpdateBucket(options *Bucket) (*Bucket, error) {
 	if err != nil {
 		return nil, err
 	}
+	api.invalidateRefs(ResourceBucket, "")
 
 	var bucket Bucket
 	if err := json.Unmarshal(resp, &bucket); err != nil {
@@ -174,31 +400,28 @@ func (api *API) DeleteBucket(options *BucketDeleteOptions) error {
 	if err != nil {
 		return err
 	}
+	api.invalidateRefs(ResourceBucket, "")
+	api.invalidateRefs(ResourceInput, bucketID)
+	api.invalidateRefs(ResourceOutput, bucketID)
 
 	return nil
 }
 
 // ensureBucketID - takes name/id and always returns ID (when it not fails)
 func (api *API) ensureBucketID(ref string) (string, error) {
-	if !IsUUID(ref) {
-		id, err := api.bucketIDFromName(ref)
-		if err != nil {
-			return "", err
-		}
-		return id, nil
-	}
-	return ref, nil
+	return api.resolveRef(ResourceBucket, "", ref)
 }
 
-func (api *API) bucketIDFromName(name string) (id string, err error) {
-	buckets, err := api.ListBuckets(&BucketListOptions{})
-	if err != nil {
-		return
-	}
-	for _, b := range buckets {
-		if b.Name == name {
-			return b.ID, nil
-		}
-	}
-	return "", fmt.Errorf("no such bucket '%s'", name)
+// SuspendBucket stops the bucket from accepting webhooks
+func (api *API) SuspendBucket(ref string) (*Bucket, error) {
+	return api.setBucketSuspended(ref, true)
+}
+
+// ResumeBucket resumes a suspended bucket
+func (api *API) ResumeBucket(ref string) (*Bucket, error) {
+	return api.setBucketSuspended(ref, false)
+}
+
+func (api *API) setBucketSuspended(ref string, suspended bool) (*Bucket, error) {
+	return api.PatchBucket(ref, &BucketPatch{Suspended: Bool(suspended)}, nil)
 }
@@ -2,36 +2,59 @@ package webhookrelay
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
-	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
-)
-
-func getIntegrationTestClient() (*API, error) {
+	"github.com/stretchr/testify/require"
 
-	key := os.Getenv("RELAY_KEY")
-	secret := os.Getenv("RELAY_SECRET")
+	"github.com/webhookrelay/webhookrelay-go/recorder"
+)
 
-	if key == "" {
-		return nil, errors.New("RELAY_KEY must be set")
-	}
-	if secret == "" {
-		return nil, errors.New("RELAY_SECRET must be set")
+// getIntegrationTestClient returns a client replaying the interactions in
+// testdata/fixtures/<fixture>.yaml. With RELAY_RECORD set the requests are
+// sent to the API with RELAY_KEY and RELAY_SECRET and the fixture is
+// recorded again.
+func getIntegrationTestClient(t *testing.T, fixture string) *API {
+	t.Helper()
+
+	mode := recorder.ModeFromEnv("RELAY_RECORD")
+	key, secret := "fixture-key", "fixture-secret"
+	if mode == recorder.ModeRecord {
+		key = os.Getenv("RELAY_KEY")
+		secret = os.Getenv("RELAY_SECRET")
+		if key == "" || secret == "" {
+			t.Fatal("RELAY_KEY and RELAY_SECRET must be set to record fixtures")
+		}
 	}
 
-	return New(key, secret)
-}
+	rec, err := recorder.New(filepath.Join("testdata", "fixtures", fixture+".yaml"), mode)
+	if err != nil {
+		t.Fatalf("failed to create recorder: %s", err)
+	}
+	t.Cleanup(func() {
+		if err := rec.Stop(); err != nil {
+			t.Errorf("failed to save fixture: %s", err)
+		}
+		if unused := rec.Unused(); len(unused) > 0 {
+			t.Errorf("%d recorded interactions were not replayed", len(unused))
+		}
+	})
 
-func TestListBuckets(t *testing.T) {
-	client, err := getIntegrationTestClient()
+	client, err := New(key, secret, WithHTTPClient(rec.Client()))
 	if err != nil {
 		t.Fatalf("failed to get API client: %s", err)
 	}
+	return client
+}
+
+func TestListBuckets(t *testing.T) {
+	client := getIntegrationTestClient(t, "list_buckets")
 
 	buckets, err := client.ListBuckets(&BucketListOptions{})
 	assert.Nil(t, err)
@@ -117,3 +140,114 @@ func TestListBuckets_TimeFormats(t *testing.T) {
 	assert.Equal(t, testTime.Unix(), buckets[0].CreatedAt.Unix())
 	assert.Equal(t, testTime.Unix(), buckets[0].UpdatedAt.Unix())
 }
+
+func TestCreateBucket_Options(t *testing.T) {
+	var requests []string
+	var created map[string]interface{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/buckets":
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&created))
+			// server that doesn't know about default input options
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"id":     "11111111-1111-1111-1111-111111111111",
+				"name":   created["name"],
+				"stream": created["stream"],
+				"auth":   created["auth"],
+				"inputs": []interface{}{
+					map[string]interface{}{"id": "22222222-2222-2222-2222-222222222222", "name": "Default public endpoint"},
+				},
+			})
+		case r.Method == http.MethodPut:
+			var input Input
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&input))
+			_ = json.NewEncoder(w).Encode(&input)
+		default:
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write([]byte("{}"))
+		}
+	}))
+	defer server.Close()
+
+	client, err := New("test-key", "test-secret", WithAPIEndpointURL(server.URL))
+	assert.NoError(t, err)
+
+	_, err = client.CreateBucket(&BucketCreateOptions{Name: "b", Auth: &BucketAuth{Type: AuthTypeBasic, Username: "u"}})
+	assert.EqualError(t, err, "basic auth requires username and password")
+	assert.Empty(t, requests)
+
+	bucket, err := client.CreateBucket(&BucketCreateOptions{
+		Name:         "b",
+		Stream:       true,
+		Auth:         &BucketAuth{Type: AuthTypeToken, Token: "secret"},
+		DefaultInput: &DefaultInputOptions{Skip: true},
+	})
+	assert.NoError(t, err)
+	assert.True(t, bucket.Stream)
+	assert.Empty(t, bucket.Inputs)
+	assert.Equal(t, true, created["stream"])
+	assert.Equal(t, map[string]interface{}{"type": "token", "token": "secret"}, created["auth"])
+	assert.Equal(t, map[string]interface{}{"skip": true}, created["default_input"])
+	assert.Equal(t, []string{
+		"POST /buckets",
+		"DELETE /buckets/11111111-1111-1111-1111-111111111111/inputs/22222222-2222-2222-2222-222222222222",
+	}, requests)
+
+	requests = nil
+	bucket, err = client.CreateBucket(&BucketCreateOptions{
+		Name:         "b",
+		DefaultInput: &DefaultInputOptions{Name: "public", StatusCode: 202},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "public", bucket.Inputs[0].Name)
+	assert.Equal(t, 202, bucket.Inputs[0].StatusCode)
+	assert.Equal(t, []string{
+		"POST /buckets",
+		"PUT /buckets/11111111-1111-1111-1111-111111111111/inputs/22222222-2222-2222-2222-222222222222",
+	}, requests)
+}
+
+func TestCreateBucket_UnappliedSettings(t *testing.T) {
+	var requests []string
+	bucket := map[string]interface{}{"id": "11111111-1111-1111-1111-111111111111", "name": "b"}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		// server drops settings on create
+		if r.Method == http.MethodPut {
+			var update Bucket
+			if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+				t.Error(err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			bucket["stream"] = update.Stream
+		}
+		_ = json.NewEncoder(w).Encode(bucket)
+	}))
+	defer server.Close()
+
+	client, err := New("test-key", "test-secret", WithAPIEndpointURL(server.URL))
+	require.NoError(t, err)
+
+	created, err := client.CreateBucket(&BucketCreateOptions{Name: "b", Stream: true})
+	require.NoError(t, err)
+	assert.True(t, created.Stream)
+	assert.Equal(t, []string{"POST /buckets", "PUT /buckets/11111111-1111-1111-1111-111111111111"}, requests)
+
+	// a bucket without the requested auth is deleted, not patched
+	requests = nil
+	created, err = client.CreateBucket(&BucketCreateOptions{
+		Name: "b",
+		Auth: &BucketAuth{Type: AuthTypeToken, Token: "secret"},
+	})
+	assert.True(t, errors.Is(err, ErrAuthNotApplied))
+	assert.EqualError(t, err, "bucket 'b': server did not apply the bucket auth, it was deleted")
+	assert.Nil(t, created)
+	assert.Equal(t, []string{"POST /buckets", "DELETE /buckets/11111111-1111-1111-1111-111111111111"}, requests)
+}
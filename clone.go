@@ -0,0 +1,202 @@
+package webhookrelay
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"text/template"
+)
+
+// BucketCloneOptions customize the copy made by CloneBucket
+type BucketCloneOptions struct {
+	// Description of the new bucket, defaults to the source description
+	Description string
+	// TemplateData, when set, is used to execute output destinations as
+	// text/template templates, e.g. https://{{.Customer}}.example.com/webhooks
+	TemplateData interface{}
+	// Output is called with each output copy before it's created, it can
+	// rewrite the destination or any other setting
+	Output func(output *Output) error
+	// Input is called with each input copy before it's created.
+	// ResponseFromOutput already refers to the new output.
+	Input func(input *Input) error
+}
+
+// CloneBucket copies the bucket settings, outputs and inputs into a new
+// bucket. Inputs get new endpoints, custom domains and path prefixes are not
+// copied as they are unique. Functions are shared with the source bucket.
+// Output destinations are rendered and validated before the bucket is
+// created, if a later step fails the new bucket is deleted. The context is
+// checked between API calls.
+func (api *API) CloneBucket(ctx context.Context, srcRef, newName string, opts *BucketCloneOptions) (*Bucket, error) {
+	if newName == "" {
+		return nil, fmt.Errorf("new bucket name not specified")
+	}
+	if opts == nil {
+		opts = &BucketCloneOptions{}
+	}
+
+	src, err := api.GetBucket(srcRef)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get source bucket: %w", err)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	description := opts.Description
+	if description == "" {
+		description = src.Description
+	}
+	createOpts := &BucketCreateOptions{
+		Name:          newName,
+		Description:   description,
+		Stream:        src.Stream,
+		Ephemeral:     src.Ephemeral,
+		Suspended:     src.Suspended,
+		LargeWebhooks: src.LargeWebhooks,
+		RetryPolicy:   src.RetryPolicy,
+		DeadLetter:    src.DeadLetter,
+		RateLimit:     src.RateLimit,
+		Quota:         src.Quota,
+		DefaultInput:  &DefaultInputOptions{Skip: true},
+	}
+	if src.Auth.Type != AuthTypeNone {
+		auth := src.Auth
+		createOpts.Auth = &auth
+	}
+
+	// outputs are rendered and validated before anything is created
+	outputs, err := cloneOutputs(src, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	created, err := api.CreateBucket(createOpts)
+	if err != nil {
+		if created != nil {
+			return nil, api.rollbackClone(created.ID, err)
+		}
+		return nil, fmt.Errorf("failed to create bucket: %w", err)
+	}
+
+	if err := api.cloneBucketContents(ctx, src, created, outputs, opts); err != nil {
+		return nil, api.rollbackClone(created.ID, err)
+	}
+	return created, nil
+}
+
+// cloneOutputs copies the source outputs with rendered destinations
+func cloneOutputs(src *Bucket, opts *BucketCloneOptions) ([]*Output, error) {
+	outputs := make([]*Output, 0, len(src.Outputs))
+	for _, o := range src.Outputs {
+		output := &Output{
+			Name:        o.Name,
+			FunctionID:  o.FunctionID,
+			Headers:     o.Headers,
+			Destination: o.Destination,
+			Disabled:    o.Disabled,
+			LockPath:    o.LockPath,
+			Internal:    o.Internal,
+			Timeout:     o.Timeout,
+			Description: o.Description,
+			Rules:       o.Rules,
+			RetryPolicy: o.RetryPolicy,
+			DeadLetter:  o.DeadLetter,
+		}
+		if opts.TemplateData != nil {
+			destination, err := executeTemplate(o.Destination, opts.TemplateData)
+			if err != nil {
+				return nil, fmt.Errorf("output '%s': %w", o.Name, err)
+			}
+			output.Destination = destination
+		}
+		if opts.Output != nil {
+			if err := opts.Output(output); err != nil {
+				return nil, fmt.Errorf("output '%s': %w", o.Name, err)
+			}
+		}
+		if err := output.validate(); err != nil {
+			return nil, fmt.Errorf("output '%s': %w", o.Name, err)
+		}
+		outputs = append(outputs, output)
+	}
+	return outputs, nil
+}
+
+// cloneBucketContents creates the outputs, which are in the same order as
+// the source outputs, and copies of the source inputs in the new bucket
+func (api *API) cloneBucketContents(ctx context.Context, src, dst *Bucket, outputs []*Output, opts *BucketCloneOptions) error {
+	outputIDs := make(map[string]string, len(src.Outputs))
+	for idx, output := range outputs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		output.BucketID = dst.ID
+		created, err := api.CreateOutput(output)
+		if err != nil {
+			return fmt.Errorf("failed to create output '%s': %w", output.Name, err)
+		}
+		outputIDs[src.Outputs[idx].ID] = created.ID
+		dst.Outputs = append(dst.Outputs, created)
+	}
+
+	for _, i := range src.Inputs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		input := &Input{
+			Name:               i.Name,
+			FunctionID:         i.FunctionID,
+			BucketID:           dst.ID,
+			Headers:            i.Headers,
+			StatusCode:         i.StatusCode,
+			Body:               i.Body,
+			ResponseFromOutput: i.ResponseFromOutput,
+			Description:        i.Description,
+			AllowedIPs:         i.AllowedIPs,
+			AllowedMethods:     i.AllowedMethods,
+			MaxBodySize:        i.MaxBodySize,
+			RequiredHeaders:    i.RequiredHeaders,
+		}
+		if id, ok := outputIDs[i.ResponseFromOutput]; ok {
+			input.ResponseFromOutput = id
+		}
+		if opts.Input != nil {
+			if err := opts.Input(input); err != nil {
+				return fmt.Errorf("input '%s': %w", i.Name, err)
+			}
+		}
+
+		created, err := api.CreateInput(input)
+		if err != nil {
+			return fmt.Errorf("failed to create input '%s': %w", i.Name, err)
+		}
+		dst.Inputs = append(dst.Inputs, created)
+	}
+	return nil
+}
+
+// rollbackClone deletes the partially cloned bucket together with its
+// inputs and outputs
+func (api *API) rollbackClone(bucketID string, cause error) error {
+	err := api.DeleteBucket(&BucketDeleteOptions{Ref: bucketID, Force: true})
+	if err != nil {
+		return fmt.Errorf("%w (rollback failed, bucket %s was left behind: %s)", cause, bucketID, err)
+	}
+	return cause
+}
+
+func executeTemplate(text string, data interface{}) (string, error) {
+	tmpl, err := template.New("").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
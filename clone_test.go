@@ -0,0 +1,162 @@
+package webhookrelay
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const cloneSrcID = "00000000-0000-0000-0000-000000000001"
+
+func newCloneTestServer(t *testing.T, requests *[]string, failOutput string) *httptest.Server {
+	nextID := 100
+	newID := func() string {
+		nextID++
+		return fmt.Sprintf("00000000-0000-0000-0000-%012d", nextID)
+	}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests = append(*requests, r.Method+" "+r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/buckets/"+cloneSrcID:
+			_, _ = w.Write([]byte(`{
+				"id": "` + cloneSrcID + `",
+				"name": "template",
+				"description": "customer template",
+				"stream": true,
+				"auth": {"type": "token", "token": "secret"},
+				"outputs": [
+					{"id": "out-1", "name": "api", "destination": "https://{{.Customer}}.example.com/hooks", "function_id": "fn-1"},
+					{"id": "out-2", "name": "audit", "destination": "http://audit:8080", "internal": true}
+				],
+				"inputs": [
+					{"id": "in-1", "name": "public", "status_code": 202, "body": "ok", "headers": {"X-Test": ["1"]},
+					 "response_from_output": "out-1", "custom_domain": "template.hooks.example.com"}
+				]
+			}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/buckets":
+			var opts BucketCreateOptions
+			if err := json.NewDecoder(r.Body).Decode(&opts); err != nil {
+				t.Error(err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			bucket := &Bucket{ID: newID(), Name: opts.Name, Description: opts.Description, Stream: opts.Stream}
+			if opts.Auth != nil {
+				bucket.Auth = *opts.Auth
+			}
+			_ = json.NewEncoder(w).Encode(bucket)
+		case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/outputs"):
+			var output Output
+			if err := json.NewDecoder(r.Body).Decode(&output); err != nil {
+				t.Error(err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			if output.Name == failOutput {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"error": "invalid destination"}`))
+				return
+			}
+			*requests = append(*requests, "  "+output.Name+" -> "+output.Destination)
+			output.ID = newID()
+			_ = json.NewEncoder(w).Encode(&output)
+		case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/inputs"):
+			var input Input
+			if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+				t.Error(err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			*requests = append(*requests, fmt.Sprintf("  %s %d %q response from %s domain %q", input.Name, input.StatusCode, input.Body, input.ResponseFromOutput, input.CustomDomain))
+			input.ID = newID()
+			_ = json.NewEncoder(w).Encode(&input)
+		default:
+			_, _ = w.Write([]byte(`{}`))
+		}
+	}))
+}
+
+func TestCloneBucket(t *testing.T) {
+	var requests []string
+	server := newCloneTestServer(t, &requests, "")
+	defer server.Close()
+
+	client, err := New("test-key", "test-secret", WithAPIEndpointURL(server.URL))
+	require.NoError(t, err)
+
+	bucket, err := client.CloneBucket(context.Background(), cloneSrcID, "acme", &BucketCloneOptions{
+		TemplateData: map[string]string{"Customer": "acme"},
+		Output: func(o *Output) error {
+			if o.Internal {
+				o.Destination = "http://audit-acme:8080"
+			}
+			return nil
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "acme", bucket.Name)
+	assert.Equal(t, "customer template", bucket.Description)
+	assert.True(t, bucket.Stream)
+	assert.Equal(t, BucketAuth{Type: AuthTypeToken, Token: "secret"}, bucket.Auth)
+	assert.Len(t, bucket.Outputs, 2)
+	assert.Len(t, bucket.Inputs, 1)
+	assert.Equal(t, map[string][]string{"X-Test": {"1"}}, bucket.Inputs[0].Headers)
+
+	assert.Equal(t, []string{
+		"GET /buckets/" + cloneSrcID,
+		"POST /buckets",
+		"POST /buckets/00000000-0000-0000-0000-000000000101/outputs",
+		"  api -> https://acme.example.com/hooks",
+		"POST /buckets/00000000-0000-0000-0000-000000000101/outputs",
+		"  audit -> http://audit-acme:8080",
+		"POST /buckets/00000000-0000-0000-0000-000000000101/inputs",
+		`  public 202 "ok" response from 00000000-0000-0000-0000-000000000102 domain ""`,
+	}, requests)
+}
+
+func TestCloneBucket_Rollback(t *testing.T) {
+	var requests []string
+	server := newCloneTestServer(t, &requests, "audit")
+	defer server.Close()
+
+	client, err := New("test-key", "test-secret", WithAPIEndpointURL(server.URL))
+	require.NoError(t, err)
+
+	_, err = client.CloneBucket(context.Background(), cloneSrcID, "acme", &BucketCloneOptions{
+		TemplateData: map[string]string{"Customer": "acme"},
+	})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to create output 'audit'")
+	assert.Equal(t, "DELETE /buckets/00000000-0000-0000-0000-000000000101", requests[len(requests)-1])
+
+	// missing template data fails before anything is created
+	requests = nil
+	_, err = client.CloneBucket(context.Background(), cloneSrcID, "acme", &BucketCloneOptions{
+		TemplateData: map[string]string{},
+	})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "output 'api'")
+	assert.Equal(t, []string{"GET /buckets/" + cloneSrcID}, requests)
+
+	// so does a destination that doesn't validate after rendering
+	requests = nil
+	_, err = client.CloneBucket(context.Background(), cloneSrcID, "acme", &BucketCloneOptions{
+		TemplateData: map[string]string{"Customer": "acme"},
+		Output: func(o *Output) error {
+			o.Destination = "ftp://" + o.Name
+			return nil
+		},
+	})
+	assert.Error(t, err)
+	assert.Equal(t, []string{"GET /buckets/" + cloneSrcID}, requests)
+}
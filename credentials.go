@@ -0,0 +1,310 @@
+package webhookrelay
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Environment variables read by EnvCredentials
+const (
+	EnvKey    = "RELAY_KEY"
+	EnvSecret = "RELAY_SECRET"
+	EnvToken  = "RELAY_TOKEN"
+)
+
+// ErrNoCredentials is returned by providers that have no credentials to
+// offer, e.g. unset environment variables or a missing file. ChainCredentials
+// moves on to the next provider on this error.
+var ErrNoCredentials = errors.New("no credentials found")
+
+// Credentials authenticate API requests, either with an access token key and
+// secret (basic auth) or with a bearer token
+type Credentials struct {
+	Key    string `json:"key,omitempty" yaml:"key,omitempty"`
+	Secret string `json:"secret,omitempty" yaml:"secret,omitempty"`
+	Token  string `json:"token,omitempty" yaml:"token,omitempty"`
+	// ExpiresAt is when the credentials should be fetched again, zero
+	// means they don't expire
+	ExpiresAt time.Time `json:"expires_at,omitempty" yaml:"expires_at,omitempty"`
+}
+
+// Validate checks that either the token or both key and secret are set
+func (c *Credentials) Validate() error {
+	if c.Token != "" || (c.Key != "" && c.Secret != "") {
+		return nil
+	}
+	return ErrEmptyCredentials
+}
+
+// AuthType returns AuthBearer for tokens and AuthToken for key and secret
+func (c *Credentials) AuthType() int {
+	if c.Token != "" {
+		return AuthBearer
+	}
+	return AuthToken
+}
+
+func (c *Credentials) expired(now time.Time) bool {
+	return !c.ExpiresAt.IsZero() && !now.Before(c.ExpiresAt)
+}
+
+// CredentialProvider supplies credentials for each request. Providers must
+// be safe for concurrent use. Providers that cache credentials can also
+// implement Invalidate() which the client calls when the API rejects the
+// credentials, the request is then retried once with fresh ones.
+type CredentialProvider interface {
+	Credentials(ctx context.Context) (*Credentials, error)
+}
+
+type credentialInvalidator interface {
+	Invalidate()
+}
+
+type staticCredentials struct {
+	credentials Credentials
+}
+
+func (p *staticCredentials) Credentials(ctx context.Context) (*Credentials, error) {
+	creds := p.credentials
+	return &creds, nil
+}
+
+// StaticCredentials always returns the same key and secret
+func StaticCredentials(key, secret string) CredentialProvider {
+	return &staticCredentials{credentials: Credentials{Key: key, Secret: secret}}
+}
+
+// StaticBearerToken always returns the same bearer token
+func StaticBearerToken(token string) CredentialProvider {
+	return &staticCredentials{credentials: Credentials{Token: token}}
+}
+
+type envCredentials struct{}
+
+// EnvCredentials reads RELAY_TOKEN, or RELAY_KEY and RELAY_SECRET, on each
+// request
+func EnvCredentials() CredentialProvider {
+	return envCredentials{}
+}
+
+func (envCredentials) Credentials(ctx context.Context) (*Credentials, error) {
+	creds := &Credentials{
+		Key:    os.Getenv(EnvKey),
+		Secret: os.Getenv(EnvSecret),
+		Token:  os.Getenv(EnvToken),
+	}
+	if creds.Key == "" && creds.Secret == "" && creds.Token == "" {
+		return nil, fmt.Errorf("%w: %s and %s are not set", ErrNoCredentials, EnvKey, EnvSecret)
+	}
+	if err := creds.Validate(); err != nil {
+		return nil, fmt.Errorf("environment: %w", err)
+	}
+	return creds, nil
+}
+
+// FileCredentials reads credentials from a YAML or JSON file with key and
+// secret, or token, fields. The file is read again when it changes so that
+// rotated credentials are picked up without restarting.
+type FileCredentials struct {
+	path string
+	// parse defaults to parseCredentials
+	parse func(data []byte) (*Credentials, error)
+
+	mu          sync.Mutex
+	modTime     time.Time
+	size        int64
+	credentials *Credentials
+}
+
+// NewFileCredentials creates a provider reading the file at path
+func NewFileCredentials(path string) *FileCredentials {
+	return &FileCredentials{path: path}
+}
+
+// Credentials returns the file contents, reading it again if it changed
+func (p *FileCredentials) Credentials(ctx context.Context) (*Credentials, error) {
+	info, err := os.Stat(p.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%w: %s", ErrNoCredentials, err)
+		}
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.credentials != nil && info.ModTime().Equal(p.modTime) && info.Size() == p.size {
+		creds := *p.credentials
+		return &creds, nil
+	}
+
+	data, err := os.ReadFile(p.path)
+	if err != nil {
+		return nil, err
+	}
+	parse := p.parse
+	if parse == nil {
+		parse = parseCredentials
+	}
+	creds, err := parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("credentials file %s: %w", p.path, err)
+	}
+	p.credentials = creds
+	p.modTime = info.ModTime()
+	p.size = info.Size()
+
+	result := *creds
+	return &result, nil
+}
+
+// Invalidate makes the next call read the file again
+func (p *FileCredentials) Invalidate() {
+	p.mu.Lock()
+	p.credentials = nil
+	p.mu.Unlock()
+}
+
+// ExecCredentials runs a helper command that prints credentials as YAML or
+// JSON (key and secret, or token, with an optional expires_at) to stdout.
+// The output is cached until it expires or the API rejects it.
+type ExecCredentials struct {
+	Command string
+	Args    []string
+	// Env is added to the environment of the command
+	Env []string
+	// Timeout of a single run, defaults to 30 seconds
+	Timeout time.Duration
+
+	now         func() time.Time
+	parse       func(data []byte) (*Credentials, error) // defaults to parseCredentials
+	mu          sync.Mutex
+	credentials *Credentials
+}
+
+// NewExecCredentials creates a provider running the command
+func NewExecCredentials(command string, args ...string) *ExecCredentials {
+	return &ExecCredentials{Command: command, Args: args}
+}
+
+// Credentials returns cached credentials or runs the command
+func (p *ExecCredentials) Credentials(ctx context.Context) (*Credentials, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now
+	if p.now != nil {
+		now = p.now
+	}
+	if p.credentials != nil && !p.credentials.expired(now()) {
+		creds := *p.credentials
+		return &creds, nil
+	}
+
+	timeout := p.Timeout
+	if timeout == 0 {
+		timeout = 30 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, p.Command, p.Args...)
+	cmd.Env = append(os.Environ(), p.Env...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("credential helper %s failed: %w: %s", p.Command, err, msg)
+		}
+		return nil, fmt.Errorf("credential helper %s failed: %w", p.Command, err)
+	}
+
+	parse := p.parse
+	if parse == nil {
+		parse = parseCredentials
+	}
+	creds, err := parse(stdout.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("credential helper %s: %w", p.Command, err)
+	}
+	p.credentials = creds
+
+	result := *creds
+	return &result, nil
+}
+
+// Invalidate makes the next call run the command again
+func (p *ExecCredentials) Invalidate() {
+	p.mu.Lock()
+	p.credentials = nil
+	p.mu.Unlock()
+}
+
+type chainCredentials struct {
+	providers []CredentialProvider
+}
+
+// ChainCredentials returns credentials of the first provider that has them.
+// Providers returning ErrNoCredentials are skipped, other errors are
+// returned.
+func ChainCredentials(providers ...CredentialProvider) CredentialProvider {
+	return &chainCredentials{providers: providers}
+}
+
+func (p *chainCredentials) Credentials(ctx context.Context) (*Credentials, error) {
+	for _, provider := range p.providers {
+		creds, err := provider.Credentials(ctx)
+		if errors.Is(err, ErrNoCredentials) {
+			continue
+		}
+		return creds, err
+	}
+	return nil, ErrNoCredentials
+}
+
+// Invalidate invalidates all providers in the chain
+func (p *chainCredentials) Invalidate() {
+	for _, provider := range p.providers {
+		if inv, ok := provider.(credentialInvalidator); ok {
+			inv.Invalidate()
+		}
+	}
+}
+
+// canInvalidate reports whether Invalidate can make the provider return
+// fresh credentials, chains only can if one of their providers does
+func canInvalidate(provider CredentialProvider) bool {
+	if chain, ok := provider.(*chainCredentials); ok {
+		for _, p := range chain.providers {
+			if canInvalidate(p) {
+				return true
+			}
+		}
+		return false
+	}
+	_, ok := provider.(credentialInvalidator)
+	return ok
+}
+
+// parseCredentials parses YAML, which includes JSON
+func parseCredentials(data []byte) (*Credentials, error) {
+	var creds Credentials
+	if err := yaml.Unmarshal(data, &creds); err != nil {
+		return nil, err
+	}
+	if err := creds.Validate(); err != nil {
+		return nil, err
+	}
+	return &creds, nil
+}
@@ -0,0 +1,215 @@
+package webhookrelay
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCredentials_Validate(t *testing.T) {
+	assert.NoError(t, (&Credentials{Key: "k", Secret: "s"}).Validate())
+	assert.NoError(t, (&Credentials{Token: "t"}).Validate())
+	assert.Equal(t, ErrEmptyCredentials, (&Credentials{Key: "k"}).Validate())
+	assert.Equal(t, AuthBearer, (&Credentials{Token: "t"}).AuthType())
+	assert.Equal(t, AuthToken, (&Credentials{Key: "k", Secret: "s"}).AuthType())
+}
+
+func TestEnvCredentials(t *testing.T) {
+	t.Setenv(EnvKey, "")
+	t.Setenv(EnvSecret, "")
+	t.Setenv(EnvToken, "")
+
+	_, err := EnvCredentials().Credentials(context.Background())
+	assert.True(t, errors.Is(err, ErrNoCredentials))
+
+	t.Setenv(EnvKey, "k")
+	_, err = EnvCredentials().Credentials(context.Background())
+	assert.EqualError(t, err, "environment: "+ErrEmptyCredentials.Error())
+
+	t.Setenv(EnvSecret, "s")
+	creds, err := EnvCredentials().Credentials(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, &Credentials{Key: "k", Secret: "s"}, creds)
+}
+
+func TestFileCredentials(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "credentials.yaml")
+	provider := NewFileCredentials(path)
+
+	_, err := provider.Credentials(context.Background())
+	assert.True(t, errors.Is(err, ErrNoCredentials))
+
+	require.NoError(t, os.WriteFile(path, []byte("key: k1\nsecret: s1\n"), 0600))
+	creds, err := provider.Credentials(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "k1", creds.Key)
+
+	// rotated file is picked up
+	require.NoError(t, os.WriteFile(path, []byte(`{"token": "rotated-token"}`), 0600))
+	require.NoError(t, os.Chtimes(path, time.Now(), time.Now().Add(time.Minute)))
+	creds, err = provider.Credentials(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, &Credentials{Token: "rotated-token"}, creds)
+
+	require.NoError(t, os.WriteFile(path, []byte("key: only\n"), 0600))
+	provider.Invalidate()
+	_, err = provider.Credentials(context.Background())
+	assert.Error(t, err)
+}
+
+func TestExecCredentials(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires sh")
+	}
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	counter := filepath.Join(t.TempDir(), "runs")
+
+	provider := NewExecCredentials("sh", "-c", `echo run >> "$RUNS"; echo '{"key":"k","secret":"s","expires_at":"2026-10-18T13:00:00Z"}'`)
+	provider.Env = []string{"RUNS=" + counter}
+	provider.now = func() time.Time { return now }
+
+	runs := func() int {
+		data, _ := os.ReadFile(counter)
+		return len(data) / len("run\n")
+	}
+
+	creds, err := provider.Credentials(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "k", creds.Key)
+	assert.True(t, creds.ExpiresAt.Equal(now.Add(time.Hour)))
+
+	_, err = provider.Credentials(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, runs(), "cached until expiry")
+
+	now = now.Add(2 * time.Hour)
+	_, err = provider.Credentials(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, runs())
+
+	provider.Invalidate()
+	_, err = provider.Credentials(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 3, runs())
+
+	failing := NewExecCredentials("sh", "-c", "echo vault sealed >&2; exit 1")
+	_, err = failing.Credentials(context.Background())
+	assert.EqualError(t, err, "credential helper sh failed: exit status 1: vault sealed")
+}
+
+func TestChainCredentials(t *testing.T) {
+	t.Setenv(EnvKey, "")
+	t.Setenv(EnvSecret, "")
+	t.Setenv(EnvToken, "")
+
+	chain := ChainCredentials(EnvCredentials(), NewFileCredentials(filepath.Join(t.TempDir(), "missing")), StaticBearerToken("fallback"))
+	creds, err := chain.Credentials(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "fallback", creds.Token)
+
+	t.Setenv(EnvToken, "from-env")
+	creds, err = chain.Credentials(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "from-env", creds.Token)
+
+	_, err = ChainCredentials(EnvCredentials()).Credentials(context.Background())
+	assert.NoError(t, err)
+	t.Setenv(EnvToken, "")
+	_, err = ChainCredentials(EnvCredentials()).Credentials(context.Background())
+	assert.Equal(t, ErrNoCredentials, err)
+}
+
+// rotatingProvider returns the current credentials after Invalidate
+type rotatingProvider struct {
+	mu          sync.Mutex
+	current     Credentials
+	cached      *Credentials
+	invalidated int
+}
+
+func (p *rotatingProvider) Credentials(ctx context.Context) (*Credentials, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.cached == nil {
+		creds := p.current
+		p.cached = &creds
+	}
+	return p.cached, nil
+}
+
+func (p *rotatingProvider) Invalidate() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.invalidated++
+	p.cached = nil
+}
+
+func TestNewWithCredentials(t *testing.T) {
+	_, err := NewWithCredentials(nil)
+	assert.Equal(t, ErrEmptyCredentials, err)
+
+	var authorization string
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		authorization = r.Header.Get("Authorization")
+		if key, _, ok := r.BasicAuth(); ok && key != "new" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte("[]"))
+	}))
+	defer server.Close()
+
+	client, err := NewWithCredentials(StaticBearerToken("t0ken"), WithAPIEndpointURL(server.URL))
+	require.NoError(t, err)
+	_, err = client.ListBuckets(&BucketListOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer t0ken", authorization)
+
+	provider := &rotatingProvider{current: Credentials{Key: "old", Secret: "s"}}
+	client, err = NewWithCredentials(provider, WithAPIEndpointURL(server.URL))
+	require.NoError(t, err)
+	_, err = client.ListBuckets(&BucketListOptions{})
+	assert.EqualError(t, err, "error from makeRequest: HTTP status 401: invalid credentials")
+	assert.Equal(t, 1, provider.invalidated, "retried once with fresh credentials")
+
+	provider.mu.Lock()
+	provider.current = Credentials{Key: "new", Secret: "s"}
+	provider.mu.Unlock()
+	_, err = client.ListBuckets(&BucketListOptions{})
+	require.NoError(t, err, "rotated credentials are picked up after a 401")
+	assert.Equal(t, 2, provider.invalidated)
+
+	// a chain retries only if one of its providers can refresh
+	requests = 0
+	client, err = NewWithCredentials(ChainCredentials(StaticCredentials("old", "s")), WithAPIEndpointURL(server.URL))
+	require.NoError(t, err)
+	_, err = client.ListBuckets(&BucketListOptions{})
+	assert.Error(t, err)
+	assert.Equal(t, 1, requests)
+
+	requests = 0
+	chained := &rotatingProvider{current: Credentials{Key: "old", Secret: "s"}}
+	client, err = NewWithCredentials(ChainCredentials(chained, StaticCredentials("fallback", "s")), WithAPIEndpointURL(server.URL))
+	require.NoError(t, err)
+	_, err = client.ListBuckets(&BucketListOptions{})
+	assert.Error(t, err)
+	assert.Equal(t, 2, requests)
+	assert.Equal(t, 1, chained.invalidated)
+
+	client, err = NewWithCredentials(ChainCredentials(), WithAPIEndpointURL(server.URL))
+	require.NoError(t, err)
+	_, err = client.ListBuckets(&BucketListOptions{})
+	assert.True(t, errors.Is(err, ErrNoCredentials))
+}
@@ -0,0 +1,136 @@
+package webhookrelay
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// DeadLetterListOptions filter dead-lettered webhooks
+type DeadLetterListOptions struct {
+	Bucket string // bucket ID or name
+	Output string // output ID or name, requires Bucket
+	From   *time.Time
+	To     *time.Time
+	Limit  int
+	Offset int
+}
+
+// ListDeadLetters lists webhooks that exhausted their delivery retries
+func (api *API) ListDeadLetters(options *DeadLetterListOptions) (*WebhookLogsResponse, error) {
+	query, err := api.deadLetterQuery(options)
+	if err != nil {
+		return nil, err
+	}
+	query.Limit = options.Limit
+	query.Offset = options.Offset
+	return api.ListWebhookLogs(query)
+}
+
+func (api *API) deadLetterQuery(options *DeadLetterListOptions) (*WebhookLogsListOptions, error) {
+	query := &WebhookLogsListOptions{
+		Status: RequestStatusDeadLetter,
+		From:   options.From,
+		To:     options.To,
+	}
+	if options.Bucket != "" {
+		bucketID, err := api.ensureBucketID(options.Bucket)
+		if err != nil {
+			return nil, err
+		}
+		query.BucketID = bucketID
+
+		if options.Output != "" {
+			if query.OutputID, err = api.ensureOutputID(bucketID, options.Output); err != nil {
+				return nil, err
+			}
+		}
+	} else if options.Output != "" {
+		return nil, fmt.Errorf("bucket not specified")
+	}
+	return query, nil
+}
+
+// DeadLetterDrainOptions control DrainDeadLetters
+type DeadLetterDrainOptions struct {
+	DeadLetterListOptions
+	// Handler processes a dead-lettered webhook, e.g. redelivers it.
+	// Returning an error stops the drain, the webhook stays dead-lettered.
+	Handler func(ctx context.Context, log *Log) error
+	// PageSize defaults to 100
+	PageSize int
+}
+
+// DrainDeadLetters passes dead-lettered webhooks to the handler and marks
+// each handled one as sent. It returns the number of drained webhooks.
+// Handled webhooks that a lagging index still lists are skipped over, the
+// list is read again from the start until a pass finds nothing new.
+func (api *API) DrainDeadLetters(ctx context.Context, options *DeadLetterDrainOptions) (int, error) {
+	if options.Handler == nil {
+		return 0, fmt.Errorf("handler not specified")
+	}
+	query, err := api.deadLetterQuery(&options.DeadLetterListOptions)
+	if err != nil {
+		return 0, err
+	}
+	query.Limit = options.PageSize
+	if query.Limit == 0 {
+		query.Limit = 100
+	}
+	query.Offset = options.Offset
+	start := query.Offset
+
+	drained := 0
+	progress := false
+	seen := make(map[string]bool)
+	for {
+		page, err := api.ListWebhookLogs(query)
+		if err != nil {
+			return drained, err
+		}
+
+		stale := 0
+		for _, l := range page.Data {
+			// marked logs can still be returned by a lagging index
+			if seen[l.ID] {
+				stale++
+				continue
+			}
+			seen[l.ID] = true
+			progress = true
+
+			if err := ctx.Err(); err != nil {
+				return drained, err
+			}
+			if err := options.Handler(ctx, l); err != nil {
+				return drained, fmt.Errorf("failed to handle webhook %s: %w", l.ID, err)
+			}
+			err := api.UpdateWebhookLog(&WebhookLogsUpdateRequest{
+				ID:              l.ID,
+				StatusCode:      l.StatusCode,
+				ResponseBody:    l.ResponseBody,
+				ResponseHeaders: l.ResponseHeaders,
+				Status:          RequestStatusSent,
+				Retries:         l.Retries,
+				DurationMs:      l.DurationMs,
+			})
+			if err != nil {
+				return drained, fmt.Errorf("failed to mark webhook %s as sent: %w", l.ID, err)
+			}
+			drained++
+		}
+
+		if len(page.Data) >= query.Limit {
+			// handled webhooks leave the list, stale ones still take up
+			// their positions
+			query.Offset += stale
+			continue
+		}
+		if query.Offset == start || !progress {
+			return drained, nil
+		}
+		// webhooks move into skipped positions once the index catches up
+		query.Offset = start
+		progress = false
+	}
+}
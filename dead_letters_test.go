@@ -0,0 +1,176 @@
+package webhookrelay
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const deadLetterBucket = "00000000-0000-0000-0000-0000000000e1"
+
+func TestDrainDeadLetters(t *testing.T) {
+	var mu sync.Mutex
+	statuses := map[string]RequestStatus{}
+	var order []string
+	for i := 1; i <= 5; i++ {
+		id := fmt.Sprintf("log-%d", i)
+		statuses[id] = RequestStatusDeadLetter
+		order = append(order, id)
+	}
+	var queries []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+
+		switch r.Method {
+		case http.MethodGet:
+			queries = append(queries, r.URL.RawQuery)
+			assert.Equal(t, "dead_letter", r.URL.Query().Get("status"))
+			limit := 2
+			resp := WebhookLogsResponse{Limit: limit}
+			for _, id := range order {
+				if statuses[id] == RequestStatusDeadLetter && len(resp.Data) < limit {
+					resp.Data = append(resp.Data, &Log{ID: id, BucketID: deadLetterBucket, Status: RequestStatusDeadLetter})
+				}
+			}
+			_ = json.NewEncoder(w).Encode(&resp)
+		case http.MethodPut:
+			var update WebhookLogsUpdateRequest
+			if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+				t.Error(err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			statuses[update.ID] = update.Status
+			_, _ = w.Write([]byte(`{}`))
+		}
+	}))
+	defer server.Close()
+
+	client, err := New("test-key", "test-secret", WithAPIEndpointURL(server.URL))
+	require.NoError(t, err)
+
+	page, err := client.ListDeadLetters(&DeadLetterListOptions{Bucket: deadLetterBucket, Limit: 2})
+	require.NoError(t, err)
+	assert.Len(t, page.Data, 2)
+	assert.Equal(t, "bucket="+deadLetterBucket+"&limit=2&status=dead_letter", queries[0])
+
+	var handled []string
+	failOn := "log-4"
+	drained, err := client.DrainDeadLetters(context.Background(), &DeadLetterDrainOptions{
+		DeadLetterListOptions: DeadLetterListOptions{Bucket: deadLetterBucket},
+		PageSize:              2,
+		Handler: func(ctx context.Context, l *Log) error {
+			if l.ID == failOn {
+				return errors.New("destination down")
+			}
+			handled = append(handled, l.ID)
+			return nil
+		},
+	})
+	assert.EqualError(t, err, "failed to handle webhook log-4: destination down")
+	assert.Equal(t, 3, drained)
+	assert.Equal(t, RequestStatusDeadLetter, statuses["log-4"])
+
+	failOn = ""
+	drained, err = client.DrainDeadLetters(context.Background(), &DeadLetterDrainOptions{
+		DeadLetterListOptions: DeadLetterListOptions{Bucket: deadLetterBucket},
+		PageSize:              2,
+		Handler: func(ctx context.Context, l *Log) error {
+			handled = append(handled, l.ID)
+			return nil
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, drained)
+	assert.Equal(t, []string{"log-1", "log-2", "log-3", "log-4", "log-5"}, handled)
+	for id, status := range statuses {
+		assert.Equal(t, RequestStatusSent, status, id)
+	}
+}
+
+// lagIndexServer lists dead letters with limit and offset, marked logs stay
+// listed for the next lag list requests
+func lagIndexServer(t *testing.T, count, lag int) (*httptest.Server, map[string]RequestStatus) {
+	var mu sync.Mutex
+	statuses := map[string]RequestStatus{}
+	markedAt := map[string]int{}
+	var order []string
+	for i := 1; i <= count; i++ {
+		id := fmt.Sprintf("log-%d", i)
+		statuses[id] = RequestStatusDeadLetter
+		order = append(order, id)
+	}
+	lists := 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+
+		switch r.Method {
+		case http.MethodGet:
+			lists++
+			limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+			offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+			var listed []*Log
+			for _, id := range order {
+				if marked, ok := markedAt[id]; !ok || lists <= marked+lag {
+					listed = append(listed, &Log{ID: id, Status: RequestStatusDeadLetter})
+				}
+			}
+			resp := WebhookLogsResponse{Limit: limit, Offset: offset}
+			for i := offset; i < len(listed) && len(resp.Data) < limit; i++ {
+				resp.Data = append(resp.Data, listed[i])
+			}
+			_ = json.NewEncoder(w).Encode(&resp)
+		case http.MethodPut:
+			var update WebhookLogsUpdateRequest
+			if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+				t.Error(err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			statuses[update.ID] = update.Status
+			markedAt[update.ID] = lists
+			_, _ = w.Write([]byte(`{}`))
+		}
+	}))
+	return server, statuses
+}
+
+func TestDrainDeadLetters_LaggingIndex(t *testing.T) {
+	for _, lag := range []int{1, 1000} {
+		server, statuses := lagIndexServer(t, 5, lag)
+
+		client, err := New("test-key", "test-secret", WithAPIEndpointURL(server.URL))
+		require.NoError(t, err)
+
+		var handled []string
+		drained, err := client.DrainDeadLetters(context.Background(), &DeadLetterDrainOptions{
+			PageSize: 2,
+			Handler: func(ctx context.Context, l *Log) error {
+				handled = append(handled, l.ID)
+				return nil
+			},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 5, drained, "lag %d", lag)
+		assert.ElementsMatch(t, []string{"log-1", "log-2", "log-3", "log-4", "log-5"}, handled)
+		for id, status := range statuses {
+			assert.Equal(t, RequestStatusSent, status, id)
+		}
+		server.Close()
+	}
+}
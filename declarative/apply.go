@@ -0,0 +1,288 @@
+package declarative
+
+import (
+	"fmt"
+
+	"github.com/webhookrelay/webhookrelay-go"
+)
+
+// Apply makes the account match the config. It returns the plan that was
+// executed, with DryRun set nothing is changed. Apply stops at the first
+// failed change, changes applied before it are kept and running Apply
+// again continues from there.
+func Apply(client Client, cfg *Config, opts *Options) (*Plan, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+
+	plan, remote, err := buildPlan(client, cfg, opts)
+	if err != nil {
+		return nil, err
+	}
+	if opts.DryRun || plan.Empty() {
+		return plan, nil
+	}
+
+	st := newApplyState(client, remote)
+
+	for _, change := range plan.Changes {
+		if err := change.apply(st); err != nil {
+			return plan, fmt.Errorf("failed to %s %s '%s': %w", change.Action, change.Kind, change.Name, err)
+		}
+	}
+	return plan, nil
+}
+
+// applyState tracks IDs of objects by name while changes are applied so
+// that objects created earlier in the run can be referenced
+type applyState struct {
+	client Client
+
+	functions map[string]string            // function name -> ID
+	buckets   map[string]string            // bucket name -> ID
+	outputs   map[string]map[string]string // bucket name -> output name -> ID
+	// inputs created by the server together with a new bucket, bucket
+	// name -> input name -> ID
+	defaultInputs map[string]map[string]string
+}
+
+func newApplyState(client Client, remote *remoteState) *applyState {
+	st := &applyState{
+		client:        client,
+		functions:     make(map[string]string),
+		buckets:       make(map[string]string),
+		outputs:       make(map[string]map[string]string),
+		defaultInputs: make(map[string]map[string]string),
+	}
+
+	for name, f := range remote.functions {
+		st.functions[name] = f.Id
+	}
+	for name, b := range remote.buckets {
+		st.buckets[name] = b.ID
+		st.outputs[name] = make(map[string]string)
+		for _, o := range b.Outputs {
+			st.outputs[name][o.Name] = o.ID
+		}
+	}
+	return st
+}
+
+func (st *applyState) functionID(name string) (string, error) {
+	id, ok := st.functions[name]
+	if !ok {
+		return "", fmt.Errorf("unknown function '%s'", name)
+	}
+	return id, nil
+}
+
+func (st *applyState) bucketID(name string) (string, error) {
+	id, ok := st.buckets[name]
+	if !ok {
+		return "", fmt.Errorf("unknown bucket '%s'", name)
+	}
+	return id, nil
+}
+
+func (st *applyState) outputID(bucket, name string) (string, error) {
+	if name == "" || name == webhookrelay.AnyResponseFromOutput {
+		return name, nil
+	}
+	id, ok := st.outputs[bucket][name]
+	if !ok {
+		return "", fmt.Errorf("unknown output '%s/%s'", bucket, name)
+	}
+	return id, nil
+}
+
+// createBucket creates the bucket with all of its settings. When pruning,
+// the server is asked to skip the default input, otherwise default inputs
+// named like inputs in the config are reused. Flags and default inputs the
+// server didn't honour are fixed up afterwards.
+func (st *applyState) createBucket(b Bucket, prune bool) error {
+	opts := &webhookrelay.BucketCreateOptions{
+		Name:          b.Name,
+		Description:   b.Description,
+		Stream:        b.Stream,
+		Ephemeral:     b.Ephemeral,
+		Suspended:     b.Suspended,
+		LargeWebhooks: b.LargeWebhooks,
+	}
+	if auth := bucketAuth(b.Auth, webhookrelay.BucketAuth{}); auth.Type != webhookrelay.AuthTypeNone {
+		opts.Auth = &auth
+	}
+	if prune {
+		opts.DefaultInput = &webhookrelay.DefaultInputOptions{Skip: true}
+	}
+
+	created, err := st.client.CreateBucket(opts)
+	if err != nil {
+		return err
+	}
+	st.buckets[b.Name] = created.ID
+	st.outputs[b.Name] = make(map[string]string)
+
+	desired := make(map[string]bool)
+	for _, in := range b.Inputs {
+		desired[in.Name] = true
+	}
+	st.defaultInputs[b.Name] = make(map[string]string)
+	for _, in := range created.Inputs {
+		if desired[in.Name] {
+			st.defaultInputs[b.Name][in.Name] = in.ID
+			continue
+		}
+		if !prune {
+			continue
+		}
+		err := st.client.DeleteInput(&webhookrelay.InputDeleteOptions{Bucket: created.ID, Input: in.ID})
+		if err != nil {
+			return fmt.Errorf("failed to delete default input '%s': %w", in.Name, err)
+		}
+	}
+
+	// CreateBucket fails instead of returning a bucket without the auth,
+	// only flags are fixed up here
+	update := *created
+	update.Inputs, update.Outputs = nil, nil
+	applyBucket(&update, b)
+	if update.Stream == created.Stream && update.Ephemeral == created.Ephemeral &&
+		update.Suspended == created.Suspended && update.LargeWebhooks == created.LargeWebhooks {
+		return nil
+	}
+	_, err = st.client.UpdateBucket(&update)
+	return err
+}
+
+// createInput creates the input, when the server already created an input
+// with this name for a new bucket it is updated instead
+func (st *applyState) createInput(bucket string, in Input) error {
+	input := &webhookrelay.Input{}
+	if err := st.applyInput(input, bucket, in); err != nil {
+		return err
+	}
+
+	if id, ok := st.defaultInputs[bucket][in.Name]; ok {
+		input.ID = id
+		_, err := st.client.UpdateInput(input)
+		return err
+	}
+
+	_, err := st.client.CreateInput(input)
+	return err
+}
+
+// applyInput sets fields managed by the config, references are resolved to IDs
+func (st *applyState) applyInput(input *webhookrelay.Input, bucket string, in Input) error {
+	bucketID, err := st.bucketID(bucket)
+	if err != nil {
+		return err
+	}
+	functionID := ""
+	if in.Function != "" {
+		if functionID, err = st.functionID(in.Function); err != nil {
+			return err
+		}
+	}
+	responseFromOutput, err := st.outputID(bucket, in.ResponseFromOutput)
+	if err != nil {
+		return err
+	}
+
+	input.BucketID = bucketID
+	input.Name = in.Name
+	input.Description = in.Description
+	input.FunctionID = functionID
+	input.Headers = in.Headers
+	input.StatusCode = in.StatusCode
+	input.Body = in.Body
+	input.ResponseFromOutput = responseFromOutput
+	if in.CustomDomain != "" {
+		input.CustomDomain = in.CustomDomain
+	}
+	if in.PathPrefix != "" {
+		input.PathPrefix = in.PathPrefix
+	}
+	return nil
+}
+
+// applyOutput sets fields managed by the config, references are resolved to IDs
+func (st *applyState) applyOutput(output *webhookrelay.Output, bucket string, o Output) error {
+	bucketID, err := st.bucketID(bucket)
+	if err != nil {
+		return err
+	}
+	functionID := ""
+	if o.Function != "" {
+		if functionID, err = st.functionID(o.Function); err != nil {
+			return err
+		}
+	}
+
+	output.BucketID = bucketID
+	output.Name = o.Name
+	output.Description = o.Description
+	output.Destination = o.Destination
+	output.FunctionID = functionID
+	output.Headers = o.Headers
+	output.Disabled = o.Disabled
+	output.LockPath = o.LockPath
+	output.Internal = o.Internal
+	output.Timeout = o.Timeout
+	return nil
+}
+
+func applyBucket(bucket *webhookrelay.Bucket, b Bucket) {
+	bucket.Name = b.Name
+	bucket.Description = b.Description
+	bucket.Stream = b.Stream
+	bucket.Ephemeral = b.Ephemeral
+	bucket.Suspended = b.Suspended
+	bucket.LargeWebhooks = b.LargeWebhooks
+	bucket.Auth = bucketAuth(b.Auth, bucket.Auth)
+}
+
+func applyTunnel(tunnel *webhookrelay.Tunnel, t Tunnel) {
+	tunnel.Name = t.Name
+	tunnel.Description = t.Description
+	tunnel.Group = t.Group
+	tunnel.Destination = t.Destination
+	if t.Region != "" {
+		tunnel.Region = t.Region
+	}
+	if t.Host != "" {
+		tunnel.Host = t.Host
+	}
+	if t.Protocol != "" {
+		tunnel.Protocol = t.Protocol
+	}
+	if t.Crypto != "" {
+		tunnel.Crypto = t.Crypto
+	}
+	tunnel.Mode = tunnelMode(t.Mode)
+	tunnel.Auth = webhookrelay.TunnelAuth(bucketAuth(t.Auth, webhookrelay.BucketAuth(tunnel.Auth)))
+	tunnel.Features.RewriteHostHeader = t.RewriteHostHeader
+	tunnel.IngressRules.Rules = ingressRules(t.Ingress)
+}
+
+// bucketAuth converts desired auth (tunnels use the same structure),
+// redacted secrets are kept from the current value
+func bucketAuth(auth *Auth, current webhookrelay.BucketAuth) webhookrelay.BucketAuth {
+	if auth == nil {
+		return webhookrelay.BucketAuth{Type: webhookrelay.AuthTypeNone}
+	}
+	authType, _ := parseAuthType(auth.Type) // validated with the config
+	result := webhookrelay.BucketAuth{
+		Type:     authType,
+		Username: auth.Username,
+		Password: auth.Password,
+		Token:    auth.Token,
+	}
+	if result.Password == Redacted {
+		result.Password = current.Password
+	}
+	if result.Token == Redacted {
+		result.Token = current.Token
+	}
+	return result
+}
@@ -0,0 +1,371 @@
+package declarative
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/webhookrelay/webhookrelay-go"
+)
+
+// fakeClient keeps objects in memory and records mutating calls
+type fakeClient struct {
+	calls   []string
+	nextID  int
+	domains []*webhookrelay.Domain
+	funcs   []*webhookrelay.Function
+	buckets []*webhookrelay.Bucket
+	tunnels []*webhookrelay.Tunnel
+	tokens  []*webhookrelay.AccessToken
+}
+
+func (c *fakeClient) id() string {
+	c.nextID++
+	return fmt.Sprintf("id-%d", c.nextID)
+}
+
+func (c *fakeClient) bucket(id string) *webhookrelay.Bucket {
+	for _, b := range c.buckets {
+		if b.ID == id {
+			return b
+		}
+	}
+	return nil
+}
+
+func (c *fakeClient) ListDomainReservations(*webhookrelay.DomainListOptions) ([]*webhookrelay.Domain, error) {
+	return c.domains, nil
+}
+
+func (c *fakeClient) ReserveDomain(d *webhookrelay.Domain) (*webhookrelay.Domain, error) {
+	c.calls = append(c.calls, "reserve domain "+d.Domain)
+	d.ID = c.id()
+	c.domains = append(c.domains, d)
+	return d, nil
+}
+
+func (c *fakeClient) DeleteDomainReservation(o *webhookrelay.DomainDeleteOptions) error {
+	c.calls = append(c.calls, "delete domain "+o.Ref)
+	return nil
+}
+
+func (c *fakeClient) ListFunctions(*webhookrelay.FunctionListOptions) ([]*webhookrelay.Function, error) {
+	return c.funcs, nil
+}
+
+func (c *fakeClient) CreateFunction(o *webhookrelay.CreateFunctionRequest) (*webhookrelay.Function, error) {
+	payload, _ := ioutil.ReadAll(o.Payload)
+	c.calls = append(c.calls, "create function "+o.Name)
+	f := &webhookrelay.Function{Id: c.id(), Name: o.Name, Driver: o.Driver, Payload: payload}
+	c.funcs = append(c.funcs, f)
+	return f, nil
+}
+
+func (c *fakeClient) UpdateFunction(o *webhookrelay.UpdateFunctionRequest) (*webhookrelay.Function, error) {
+	payload, _ := ioutil.ReadAll(o.Payload)
+	c.calls = append(c.calls, "update function "+o.ID)
+	for _, f := range c.funcs {
+		if f.Id == o.ID {
+			f.Driver = o.Driver
+			f.Payload = payload
+			return f, nil
+		}
+	}
+	return &webhookrelay.Function{Id: o.ID}, nil
+}
+
+func (c *fakeClient) DeleteFunction(o *webhookrelay.FunctionDeleteOptions) error {
+	c.calls = append(c.calls, "delete function "+o.ID)
+	return nil
+}
+
+func (c *fakeClient) ListFunctionConfigurationVariables(*webhookrelay.FunctionConfigurationVariablesListOptions) ([]*webhookrelay.Variable, error) {
+	return nil, nil
+}
+
+func (c *fakeClient) SetFunctionConfigurationVariable(o *webhookrelay.SetFunctionConfigRequest) (*webhookrelay.Variable, error) {
+	c.calls = append(c.calls, "set config "+o.ID+" "+o.Key)
+	return &webhookrelay.Variable{Key: o.Key, Value: o.Value}, nil
+}
+
+func (c *fakeClient) DeleteFunctionConfigurationVariable(o *webhookrelay.FunctionConfigurationVariableDeleteOptions) error {
+	c.calls = append(c.calls, "delete config "+o.ID+" "+o.Key)
+	return nil
+}
+
+func (c *fakeClient) ListBuckets(*webhookrelay.BucketListOptions) ([]*webhookrelay.Bucket, error) {
+	return c.buckets, nil
+}
+
+func (c *fakeClient) CreateBucket(o *webhookrelay.BucketCreateOptions) (*webhookrelay.Bucket, error) {
+	c.calls = append(c.calls, "create bucket "+o.Name)
+	b := &webhookrelay.Bucket{ID: c.id(), Name: o.Name, Description: o.Description, Stream: o.Stream}
+	if o.Auth != nil {
+		b.Auth = *o.Auth
+	}
+	if o.DefaultInput == nil || !o.DefaultInput.Skip {
+		b.Inputs = []*webhookrelay.Input{{ID: c.id(), Name: "Default public endpoint", BucketID: b.ID}}
+	}
+	c.buckets = append(c.buckets, b)
+	return b, nil
+}
+
+func (c *fakeClient) UpdateBucket(o *webhookrelay.Bucket) (*webhookrelay.Bucket, error) {
+	c.calls = append(c.calls, "update bucket "+o.Name)
+	return o, nil
+}
+
+func (c *fakeClient) DeleteBucket(o *webhookrelay.BucketDeleteOptions) error {
+	c.calls = append(c.calls, "delete bucket "+o.Ref)
+	return nil
+}
+
+func (c *fakeClient) CreateInput(o *webhookrelay.Input) (*webhookrelay.Input, error) {
+	c.calls = append(c.calls, fmt.Sprintf("create input %s (response from %s)", o.Name, o.ResponseFromOutput))
+	o.ID = c.id()
+	return o, nil
+}
+
+func (c *fakeClient) UpdateInput(o *webhookrelay.Input) (*webhookrelay.Input, error) {
+	c.calls = append(c.calls, "update input "+o.ID)
+	return o, nil
+}
+
+func (c *fakeClient) DeleteInput(o *webhookrelay.InputDeleteOptions) error {
+	c.calls = append(c.calls, "delete input "+o.Input)
+	return nil
+}
+
+func (c *fakeClient) CreateOutput(o *webhookrelay.Output) (*webhookrelay.Output, error) {
+	c.calls = append(c.calls, fmt.Sprintf("create output %s (function %s)", o.Name, o.FunctionID))
+	o.ID = c.id()
+	b := c.bucket(o.BucketID)
+	b.Outputs = append(b.Outputs, o)
+	return o, nil
+}
+
+func (c *fakeClient) UpdateOutput(o *webhookrelay.Output) (*webhookrelay.Output, error) {
+	c.calls = append(c.calls, "update output "+o.ID+" "+o.Destination)
+	return o, nil
+}
+
+func (c *fakeClient) DeleteOutput(o *webhookrelay.OutputDeleteOptions) error {
+	c.calls = append(c.calls, "delete output "+o.Output)
+	return nil
+}
+
+func (c *fakeClient) ListTunnels(*webhookrelay.TunnelListOptions) ([]*webhookrelay.Tunnel, error) {
+	return c.tunnels, nil
+}
+
+func (c *fakeClient) CreateTunnel(o *webhookrelay.Tunnel) (*webhookrelay.Tunnel, error) {
+	c.calls = append(c.calls, "create tunnel "+o.Name)
+	return o, nil
+}
+
+func (c *fakeClient) UpdateTunnel(o *webhookrelay.Tunnel) (*webhookrelay.Tunnel, error) {
+	c.calls = append(c.calls, "update tunnel "+o.Name)
+	return o, nil
+}
+
+func (c *fakeClient) DeleteTunnel(o *webhookrelay.TunnelDeleteOptions) error {
+	c.calls = append(c.calls, "delete tunnel "+o.ID)
+	return nil
+}
+
+func (c *fakeClient) ListAccessTokens(*webhookrelay.AccessTokenListOptions) ([]*webhookrelay.AccessToken, error) {
+	return c.tokens, nil
+}
+
+const testConfig = `
+domains:
+  - hooks.example.com
+functions:
+  - name: filter
+    driver: lua
+    source: "-- noop"
+    config:
+      token: secret
+buckets:
+  - name: github
+    inputs:
+      - name: public
+        custom_domain: hooks.example.com
+        response_from_output: jenkins
+    outputs:
+      - name: jenkins
+        destination: http://jenkins:8080/github-webhook/
+        internal: true
+        function: filter
+`
+
+func TestApply_Create(t *testing.T) {
+	cfg, err := Load(strings.NewReader(testConfig))
+	require.NoError(t, err)
+
+	client := &fakeClient{}
+
+	plan, err := Apply(client, cfg, &Options{DryRun: true})
+	require.NoError(t, err)
+	assert.Empty(t, client.calls, "dry run must not change anything")
+	assert.Equal(t, `+ domain hooks.example.com
++ function filter
++ function config filter
+    token: (unset) -> (sensitive)
++ bucket github
++ output github/jenkins
++ input github/public
+Plan: 6 to create, 0 to update, 0 to delete.`, plan.String())
+
+	_, err = Apply(client, cfg, &Options{Prune: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"reserve domain hooks.example.com",
+		"create function filter",
+		"set config id-2 token",
+		"create bucket github",
+		"create output jenkins (function id-2)",
+		"create input public (response from id-4)",
+	}, client.calls)
+}
+
+func TestApply_UpdateAndPrune(t *testing.T) {
+	cfg, err := Load(strings.NewReader(testConfig))
+	require.NoError(t, err)
+
+	client := &fakeClient{
+		domains: []*webhookrelay.Domain{{ID: "d-1", Domain: "hooks.example.com"}, {ID: "d-2", Domain: "old.example.com"}},
+		funcs:   []*webhookrelay.Function{{Id: "f-1", Name: "filter", Driver: "lua", Payload: []byte("-- noop")}},
+		buckets: []*webhookrelay.Bucket{{
+			ID:   "b-1",
+			Name: "github",
+			Outputs: []*webhookrelay.Output{
+				{ID: "o-1", Name: "jenkins", Destination: "http://localhost:8080", FunctionID: "f-1", Internal: true},
+				{ID: "o-2", Name: "legacy", Destination: "http://localhost:9090", Internal: true},
+			},
+			Inputs: []*webhookrelay.Input{
+				{ID: "i-1", Name: "public", CustomDomain: "hooks.example.com", ResponseFromOutput: "o-1"},
+			},
+		}},
+	}
+
+	plan, err := Diff(client, cfg, &Options{Prune: true})
+	require.NoError(t, err)
+	assert.Equal(t, `~ function config filter
+    token: (unset) -> (sensitive)
+~ output github/jenkins
+    destination: "http://localhost:8080" -> "http://jenkins:8080/github-webhook/"
+- output github/legacy
+- domain old.example.com
+Plan: 0 to create, 2 to update, 2 to delete.`, plan.String())
+
+	_, err = Apply(client, cfg, &Options{Prune: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"set config f-1 token",
+		"update output o-1 http://jenkins:8080/github-webhook/",
+		"delete output o-2",
+		"delete domain d-2",
+	}, client.calls)
+}
+
+func TestApply_CreateBucketFallback(t *testing.T) {
+	// the fake client ignores ephemeral on create, like an older server
+	cfg := &Config{Buckets: []Bucket{{Name: "private", Ephemeral: true, Auth: &Auth{Type: "token", Token: "t0ken"}}}}
+	client := &fakeClient{}
+
+	_, err := Apply(client, cfg, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"create bucket private", "update bucket private"}, client.calls)
+
+	// auth is applied on create
+	client = &fakeClient{}
+	cfg.Buckets[0].Ephemeral = false
+	_, err = Apply(client, cfg, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"create bucket private"}, client.calls)
+}
+
+func TestApply_FunctionDriverOnly(t *testing.T) {
+	cfg := &Config{Functions: []Function{{Name: "filter", Driver: "js"}}}
+	client := &fakeClient{
+		funcs: []*webhookrelay.Function{{Id: "f-1", Name: "filter", Driver: "lua", Payload: []byte("-- noop")}},
+	}
+
+	_, err := Apply(client, cfg, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"update function f-1"}, client.calls)
+	assert.Equal(t, "js", client.funcs[0].Driver)
+	assert.Equal(t, "-- noop", string(client.funcs[0].Payload), "code is left unchanged")
+
+	// the code can't be resent when the API didn't return it
+	client.funcs[0].Payload = nil
+	_, err = Diff(client, &Config{Functions: []Function{{Name: "filter", Driver: "lua"}}}, nil)
+	assert.EqualError(t, err, "function 'filter': source must be set to update it, the current code is not available")
+}
+
+func TestDiff_OutputDestination(t *testing.T) {
+	client := &fakeClient{
+		buckets: []*webhookrelay.Bucket{{
+			ID:      "b-1",
+			Name:    "github",
+			Outputs: []*webhookrelay.Output{{ID: "o-1", Name: "legacy", Destination: "http://legacy:9090"}},
+		}},
+	}
+
+	cfg := &Config{Buckets: []Bucket{{Name: "github", Outputs: []Output{
+		{Name: "legacy", Destination: "http://legacy:9090", Timeout: 10},
+		{Name: "jenkins", Destination: "http://jenkins:8080"},
+	}}}}
+	_, err := Diff(client, cfg, nil)
+	assert.EqualError(t, err, "output 'github/jenkins': destination 'http://jenkins:8080' is not reachable from Webhook Relay servers, use an internal output")
+
+	// unchanged destinations are not checked
+	cfg.Buckets[0].Outputs = cfg.Buckets[0].Outputs[:1]
+	plan, err := Diff(client, cfg, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1, len(plan.Changes))
+}
+
+func TestDiff_PrunedFunctionReference(t *testing.T) {
+	client := &fakeClient{
+		funcs: []*webhookrelay.Function{{Id: "f-1", Name: "filter", Driver: "lua", Payload: []byte("-- noop")}},
+	}
+	cfg := &Config{Buckets: []Bucket{{Name: "github", Outputs: []Output{
+		{Name: "ci", Destination: "https://ci.example.com", Function: "filter"},
+	}}}}
+
+	_, err := Diff(client, cfg, nil)
+	require.NoError(t, err, "functions that are kept can be referenced")
+
+	_, err = Diff(client, cfg, &Options{Prune: true})
+	assert.EqualError(t, err, "output 'github/ci': function 'filter' is not in the config and would be deleted by prune")
+}
+
+func TestDiff_RedactedOnCreate(t *testing.T) {
+	client := &fakeClient{}
+
+	_, err := Diff(client, &Config{Buckets: []Bucket{{Name: "stripe", Auth: &Auth{Type: "basic", Username: "stripe", Password: Redacted}}}}, nil)
+	assert.EqualError(t, err, "bucket 'stripe': auth secret is redacted, set it or use a ${ENV_VAR} reference to create it")
+
+	_, err = Diff(client, &Config{Tunnels: []Tunnel{{Name: "dev", Destination: "http://localhost:3000", Auth: &Auth{Type: "token", Token: Redacted}}}}, nil)
+	assert.EqualError(t, err, "tunnel 'dev': auth secret is redacted, set it or use a ${ENV_VAR} reference to create it")
+
+	_, err = Diff(client, &Config{Functions: []Function{{Name: "filter", Driver: "lua", Source: "-- noop", Config: map[string]string{"token": Redacted}}}}, nil)
+	assert.EqualError(t, err, "function 'filter': config 'token' is redacted but not set, there is no value to keep")
+}
+
+func TestValidate(t *testing.T) {
+	_, err := Load(strings.NewReader("buckets:\n  - name: a\n    unknown: true\n"))
+	assert.Error(t, err, "unknown fields must be rejected")
+
+	cfg := &Config{Buckets: []Bucket{{
+		Name:   "a",
+		Inputs: []Input{{Name: "in", ResponseFromOutput: "missing"}},
+	}}}
+	assert.EqualError(t, cfg.Validate(), "bucket 'a': input 'in': response_from_output refers to unknown output 'missing'")
+}
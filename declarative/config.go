@@ -0,0 +1,270 @@
+// Package declarative manages Webhook Relay configuration as code. A desired
+// state document (usually YAML kept in git) is compared against the account,
+// the differences are presented as a plan and then applied in dependency
+// order.
+package declarative
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/webhookrelay/webhookrelay-go"
+)
+
+// Config is a desired state document
+type Config struct {
+	Domains   []string   `json:"domains,omitempty" yaml:"domains,omitempty"`
+	Functions []Function `json:"functions,omitempty" yaml:"functions,omitempty"`
+	Buckets   []Bucket   `json:"buckets,omitempty" yaml:"buckets,omitempty"`
+	Tunnels   []Tunnel   `json:"tunnels,omitempty" yaml:"tunnels,omitempty"`
+	// Tokens are written by Export and ignored by Apply
+	Tokens []AccessToken `json:"tokens,omitempty" yaml:"tokens,omitempty"`
+}
+
+// Function is a serverless function with its configuration variables
+type Function struct {
+	Name   string `json:"name" yaml:"name"`
+	Driver string `json:"driver,omitempty" yaml:"driver,omitempty"`
+	// Source is the function code, it is required to create the function.
+	// When empty, the code of an existing function is left unchanged.
+	Source string `json:"source,omitempty" yaml:"source,omitempty"`
+	// File is a path to the function code, relative to the config file
+	File   string            `json:"file,omitempty" yaml:"file,omitempty"`
+	Config map[string]string `json:"config,omitempty" yaml:"config,omitempty"`
+}
+
+// Auth is authentication for buckets and tunnels. Password and Token can be
+// ${ENV_VAR} references or Redacted to keep the current value.
+type Auth struct {
+	Type     string `json:"type" yaml:"type"` // none, basic or token
+	Username string `json:"username,omitempty" yaml:"username,omitempty"`
+	Password string `json:"password,omitempty" yaml:"password,omitempty"`
+	Token    string `json:"token,omitempty" yaml:"token,omitempty"`
+}
+
+// Bucket groups inputs and outputs
+type Bucket struct {
+	Name          string   `json:"name" yaml:"name"`
+	Description   string   `json:"description,omitempty" yaml:"description,omitempty"`
+	Stream        bool     `json:"stream,omitempty" yaml:"stream,omitempty"`
+	Ephemeral     bool     `json:"ephemeral,omitempty" yaml:"ephemeral,omitempty"`
+	Suspended     bool     `json:"suspended,omitempty" yaml:"suspended,omitempty"`
+	LargeWebhooks bool     `json:"large_webhooks,omitempty" yaml:"large_webhooks,omitempty"`
+	Auth          *Auth    `json:"auth,omitempty" yaml:"auth,omitempty"`
+	Inputs        []Input  `json:"inputs,omitempty" yaml:"inputs,omitempty"`
+	Outputs       []Output `json:"outputs,omitempty" yaml:"outputs,omitempty"`
+}
+
+// Input is a public endpoint of a bucket. Function and ResponseFromOutput
+// refer to objects by name.
+type Input struct {
+	Name        string              `json:"name" yaml:"name"`
+	Description string              `json:"description,omitempty" yaml:"description,omitempty"`
+	Function    string              `json:"function,omitempty" yaml:"function,omitempty"`
+	Headers     map[string][]string `json:"headers,omitempty" yaml:"headers,omitempty"`
+	StatusCode  int                 `json:"status_code,omitempty" yaml:"status_code,omitempty"`
+	Body        string              `json:"body,omitempty" yaml:"body,omitempty"`
+	// ResponseFromOutput is an output name in the same bucket or "anyOutput"
+	ResponseFromOutput string `json:"response_from_output,omitempty" yaml:"response_from_output,omitempty"`
+	// CustomDomain and PathPrefix are assigned by the server when left empty
+	CustomDomain string `json:"custom_domain,omitempty" yaml:"custom_domain,omitempty"`
+	PathPrefix   string `json:"path_prefix,omitempty" yaml:"path_prefix,omitempty"`
+}
+
+// Output is a webhook forwarding destination
+type Output struct {
+	Name        string              `json:"name" yaml:"name"`
+	Description string              `json:"description,omitempty" yaml:"description,omitempty"`
+	Destination string              `json:"destination" yaml:"destination"`
+	Function    string              `json:"function,omitempty" yaml:"function,omitempty"`
+	Headers     map[string][]string `json:"headers,omitempty" yaml:"headers,omitempty"`
+	Disabled    bool                `json:"disabled,omitempty" yaml:"disabled,omitempty"`
+	LockPath    bool                `json:"lock_path,omitempty" yaml:"lock_path,omitempty"`
+	Internal    bool                `json:"internal,omitempty" yaml:"internal,omitempty"`
+	Timeout     int                 `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+}
+
+// Tunnel is a bidirectional tunnel. Region, Host, Protocol and Crypto are
+// picked by the server when left empty.
+type Tunnel struct {
+	Name              string        `json:"name" yaml:"name"`
+	Description       string        `json:"description,omitempty" yaml:"description,omitempty"`
+	Group             string        `json:"group,omitempty" yaml:"group,omitempty"`
+	Region            string        `json:"region,omitempty" yaml:"region,omitempty"`
+	Destination       string        `json:"destination,omitempty" yaml:"destination,omitempty"`
+	Host              string        `json:"host,omitempty" yaml:"host,omitempty"`
+	Protocol          string        `json:"protocol,omitempty" yaml:"protocol,omitempty"`
+	Crypto            string        `json:"crypto,omitempty" yaml:"crypto,omitempty"`
+	Mode              string        `json:"mode,omitempty" yaml:"mode,omitempty"` // active (default) or inactive
+	Auth              *Auth         `json:"auth,omitempty" yaml:"auth,omitempty"`
+	RewriteHostHeader string        `json:"rewrite_host_header,omitempty" yaml:"rewrite_host_header,omitempty"`
+	Ingress           []IngressRule `json:"ingress,omitempty" yaml:"ingress,omitempty"`
+}
+
+// IngressRule routes tunnel traffic by path
+type IngressRule struct {
+	Name      string   `json:"name,omitempty" yaml:"name,omitempty"`
+	Path      string   `json:"path,omitempty" yaml:"path,omitempty"`
+	Endpoints []string `json:"endpoints" yaml:"endpoints"`
+}
+
+// Load parses a YAML or JSON document
+func Load(r io.Reader) (*Config, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg Config
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&cfg); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+	return &cfg, nil
+}
+
+// LoadFile reads the config file and loads function sources referenced by
+// File, relative to the config file directory
+func LoadFile(path string) (*Config, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	cfg, err := Load(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	dir := filepath.Dir(path)
+	for idx := range cfg.Functions {
+		fn := &cfg.Functions[idx]
+		if fn.File == "" {
+			continue
+		}
+		source, err := ioutil.ReadFile(filepath.Join(dir, fn.File))
+		if err != nil {
+			return nil, fmt.Errorf("function '%s': %w", fn.Name, err)
+		}
+		fn.Source = string(source)
+	}
+	return cfg, nil
+}
+
+// Validate checks the document for missing names, duplicates and references
+// to objects that are not defined in the same bucket
+func (c *Config) Validate() error {
+	domains := make(map[string]bool)
+	for _, d := range c.Domains {
+		if d == "" {
+			return fmt.Errorf("domain must not be empty")
+		}
+		if domains[d] {
+			return fmt.Errorf("duplicate domain '%s'", d)
+		}
+		domains[d] = true
+	}
+
+	functions := make(map[string]bool)
+	for _, f := range c.Functions {
+		if f.Name == "" {
+			return fmt.Errorf("function name must not be empty")
+		}
+		if functions[f.Name] {
+			return fmt.Errorf("duplicate function '%s'", f.Name)
+		}
+		functions[f.Name] = true
+	}
+
+	buckets := make(map[string]bool)
+	for _, b := range c.Buckets {
+		if b.Name == "" {
+			return fmt.Errorf("bucket name must not be empty")
+		}
+		if buckets[b.Name] {
+			return fmt.Errorf("duplicate bucket '%s'", b.Name)
+		}
+		buckets[b.Name] = true
+
+		if err := validateAuth(b.Auth); err != nil {
+			return fmt.Errorf("bucket '%s': %w", b.Name, err)
+		}
+
+		outputs := make(map[string]bool)
+		for _, o := range b.Outputs {
+			if o.Name == "" {
+				return fmt.Errorf("bucket '%s': output name must not be empty", b.Name)
+			}
+			if outputs[o.Name] {
+				return fmt.Errorf("bucket '%s': duplicate output '%s'", b.Name, o.Name)
+			}
+			if o.Destination == "" {
+				return fmt.Errorf("bucket '%s': output '%s': destination must be set", b.Name, o.Name)
+			}
+			outputs[o.Name] = true
+		}
+
+		inputs := make(map[string]bool)
+		for _, i := range b.Inputs {
+			if i.Name == "" {
+				return fmt.Errorf("bucket '%s': input name must not be empty", b.Name)
+			}
+			if inputs[i.Name] {
+				return fmt.Errorf("bucket '%s': duplicate input '%s'", b.Name, i.Name)
+			}
+			inputs[i.Name] = true
+
+			if i.ResponseFromOutput != "" && i.ResponseFromOutput != webhookrelay.AnyResponseFromOutput && !outputs[i.ResponseFromOutput] {
+				return fmt.Errorf("bucket '%s': input '%s': response_from_output refers to unknown output '%s'", b.Name, i.Name, i.ResponseFromOutput)
+			}
+		}
+	}
+
+	tunnels := make(map[string]bool)
+	for _, t := range c.Tunnels {
+		if t.Name == "" {
+			return fmt.Errorf("tunnel name must not be empty")
+		}
+		if tunnels[t.Name] {
+			return fmt.Errorf("duplicate tunnel '%s'", t.Name)
+		}
+		tunnels[t.Name] = true
+
+		if t.Mode != "" && t.Mode != "active" && t.Mode != "inactive" {
+			return fmt.Errorf("tunnel '%s': unknown mode '%s'", t.Name, t.Mode)
+		}
+		if err := validateAuth(t.Auth); err != nil {
+			return fmt.Errorf("tunnel '%s': %w", t.Name, err)
+		}
+	}
+
+	return nil
+}
+
+func validateAuth(auth *Auth) error {
+	if auth == nil {
+		return nil
+	}
+	_, err := parseAuthType(auth.Type)
+	return err
+}
+
+func parseAuthType(s string) (webhookrelay.AuthType, error) {
+	switch s {
+	case "", "none":
+		return webhookrelay.AuthTypeNone, nil
+	case "basic":
+		return webhookrelay.AuthTypeBasic, nil
+	case "token":
+		return webhookrelay.AuthTypeToken, nil
+	}
+	return webhookrelay.AuthTypeNone, fmt.Errorf("unknown auth type '%s'", s)
+}
@@ -0,0 +1,261 @@
+package declarative
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/webhookrelay/webhookrelay-go"
+)
+
+// AccessToken is access token metadata. Tokens are exported for reference
+// and drift detection only, Apply doesn't manage them as their secrets can't
+// be recovered.
+type AccessToken struct {
+	Description string   `json:"description" yaml:"description"`
+	APIAccess   string   `json:"api_access,omitempty" yaml:"api_access,omitempty"`
+	Active      bool     `json:"active" yaml:"active"`
+	Buckets     []string `json:"buckets,omitempty" yaml:"buckets,omitempty"`
+	Tunnels     []string `json:"tunnels,omitempty" yaml:"tunnels,omitempty"`
+	Functions   []string `json:"functions,omitempty" yaml:"functions,omitempty"`
+	// Access per resource kind, see webhookrelay.AccessPermission
+	BucketAccess   string `json:"bucket_access,omitempty" yaml:"bucket_access,omitempty"`
+	TunnelAccess   string `json:"tunnel_access,omitempty" yaml:"tunnel_access,omitempty"`
+	FunctionAccess string `json:"function_access,omitempty" yaml:"function_access,omitempty"`
+}
+
+// ExportClient is the part of the Webhook Relay API used by Export,
+// *webhookrelay.API implements it.
+type ExportClient interface {
+	ListDomainReservations(options *webhookrelay.DomainListOptions) ([]*webhookrelay.Domain, error)
+	ListFunctions(options *webhookrelay.FunctionListOptions) ([]*webhookrelay.Function, error)
+	ListFunctionConfigurationVariables(options *webhookrelay.FunctionConfigurationVariablesListOptions) ([]*webhookrelay.Variable, error)
+	ListBuckets(options *webhookrelay.BucketListOptions) ([]*webhookrelay.Bucket, error)
+	ListTunnels(options *webhookrelay.TunnelListOptions) ([]*webhookrelay.Tunnel, error)
+	ListAccessTokens(options *webhookrelay.AccessTokenListOptions) ([]*webhookrelay.AccessToken, error)
+}
+
+// ExportOptions control Export
+type ExportOptions struct {
+	// Secrets sets how bucket and tunnel credentials and function config
+	// values are written, defaults to SecretsRedact
+	Secrets SecretMode
+}
+
+// Export snapshots the account as a config. Objects are sorted by name and
+// references between them use names instead of IDs so the result can be
+// kept in git and applied to another account.
+func Export(client ExportClient, opts *ExportOptions) (*Config, error) {
+	if opts == nil {
+		opts = &ExportOptions{}
+	}
+	mode := opts.Secrets
+	if mode == "" {
+		mode = SecretsRedact
+	}
+
+	cfg := &Config{}
+
+	domains, err := client.ListDomainReservations(&webhookrelay.DomainListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list domains: %w", err)
+	}
+	for _, d := range domains {
+		cfg.Domains = append(cfg.Domains, d.Domain)
+	}
+	sort.Strings(cfg.Domains)
+
+	functions, err := client.ListFunctions(&webhookrelay.FunctionListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list functions: %w", err)
+	}
+	functionNames := make(map[string]string)
+	for _, f := range functions {
+		functionNames[f.Id] = f.Name
+
+		variables, err := client.ListFunctionConfigurationVariables(&webhookrelay.FunctionConfigurationVariablesListOptions{ID: f.Id})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list function '%s' config: %w", f.Name, err)
+		}
+		fn := Function{
+			Name:   f.Name,
+			Driver: f.Driver,
+			Source: string(f.Payload),
+		}
+		for _, v := range variables {
+			if fn.Config == nil {
+				fn.Config = make(map[string]string)
+			}
+			fn.Config[v.Key] = exportSecret(mode, v.Value, "function", f.Name, v.Key)
+		}
+		cfg.Functions = append(cfg.Functions, fn)
+	}
+	sort.Slice(cfg.Functions, func(i, j int) bool { return cfg.Functions[i].Name < cfg.Functions[j].Name })
+
+	buckets, err := client.ListBuckets(&webhookrelay.BucketListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list buckets: %w", err)
+	}
+	bucketNames := make(map[string]string)
+	for _, b := range buckets {
+		bucketNames[b.ID] = b.Name
+		cfg.Buckets = append(cfg.Buckets, exportBucket(b, functionNames, mode))
+	}
+	sort.Slice(cfg.Buckets, func(i, j int) bool { return cfg.Buckets[i].Name < cfg.Buckets[j].Name })
+
+	tunnels, err := client.ListTunnels(&webhookrelay.TunnelListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tunnels: %w", err)
+	}
+	tunnelNames := make(map[string]string)
+	for _, t := range tunnels {
+		tunnelNames[t.ID] = t.Name
+		cfg.Tunnels = append(cfg.Tunnels, exportTunnel(t, mode))
+	}
+	sort.Slice(cfg.Tunnels, func(i, j int) bool { return cfg.Tunnels[i].Name < cfg.Tunnels[j].Name })
+
+	tokens, err := client.ListAccessTokens(&webhookrelay.AccessTokenListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list access tokens: %w", err)
+	}
+	for _, t := range tokens {
+		cfg.Tokens = append(cfg.Tokens, AccessToken{
+			Description:    t.Description,
+			APIAccess:      string(t.APIAccess),
+			Active:         t.Active,
+			Buckets:        namesOf(t.Scopes.Buckets, bucketNames),
+			Tunnels:        namesOf(t.Scopes.Tunnels, tunnelNames),
+			Functions:      namesOf(t.Scopes.Functions, functionNames),
+			BucketAccess:   string(t.Scopes.BucketAccess),
+			TunnelAccess:   string(t.Scopes.TunnelAccess),
+			FunctionAccess: string(t.Scopes.FunctionAccess),
+		})
+	}
+	sort.SliceStable(cfg.Tokens, func(i, j int) bool { return cfg.Tokens[i].Description < cfg.Tokens[j].Description })
+
+	return cfg, nil
+}
+
+func exportBucket(b *webhookrelay.Bucket, functionNames map[string]string, mode SecretMode) Bucket {
+	bucket := Bucket{
+		Name:          b.Name,
+		Description:   b.Description,
+		Stream:        b.Stream,
+		Ephemeral:     b.Ephemeral,
+		Suspended:     b.Suspended,
+		LargeWebhooks: b.LargeWebhooks,
+		Auth:          exportAuth(authFromBucket(b.Auth), mode, "bucket", b.Name),
+	}
+
+	for _, o := range b.Outputs {
+		bucket.Outputs = append(bucket.Outputs, Output{
+			Name:        o.Name,
+			Description: o.Description,
+			Destination: o.Destination,
+			Function:    nameOf(o.FunctionID, functionNames),
+			Headers:     o.Headers,
+			Disabled:    o.Disabled,
+			LockPath:    o.LockPath,
+			Internal:    o.Internal,
+			Timeout:     o.Timeout,
+		})
+	}
+	sort.Slice(bucket.Outputs, func(i, j int) bool { return bucket.Outputs[i].Name < bucket.Outputs[j].Name })
+
+	for _, i := range b.Inputs {
+		bucket.Inputs = append(bucket.Inputs, Input{
+			Name:               i.Name,
+			Description:        i.Description,
+			Function:           nameOf(i.FunctionID, functionNames),
+			Headers:            i.Headers,
+			StatusCode:         i.StatusCode,
+			Body:               i.Body,
+			ResponseFromOutput: outputName(b, i.ResponseFromOutput),
+			CustomDomain:       i.CustomDomain,
+			PathPrefix:         i.PathPrefix,
+		})
+	}
+	sort.Slice(bucket.Inputs, func(i, j int) bool { return bucket.Inputs[i].Name < bucket.Inputs[j].Name })
+
+	return bucket
+}
+
+func exportTunnel(t *webhookrelay.Tunnel, mode SecretMode) Tunnel {
+	tunnel := Tunnel{
+		Name:              t.Name,
+		Description:       t.Description,
+		Group:             t.Group,
+		Region:            t.Region,
+		Destination:       t.Destination,
+		Host:              t.Host,
+		Protocol:          t.Protocol,
+		Crypto:            t.Crypto,
+		Mode:              t.Mode.String(),
+		Auth:              exportAuth(authFromTunnel(t.Auth), mode, "tunnel", t.Name),
+		RewriteHostHeader: t.Features.RewriteHostHeader,
+	}
+	for _, r := range t.IngressRules.Rules {
+		rule := IngressRule{Name: r.Name, Path: r.Path}
+		for _, e := range r.Endpoints {
+			rule.Endpoints = append(rule.Endpoints, e.Address)
+		}
+		tunnel.Ingress = append(tunnel.Ingress, rule)
+	}
+	return tunnel
+}
+
+func exportAuth(auth Auth, mode SecretMode, kind, name string) *Auth {
+	if auth.Type == "none" {
+		return nil
+	}
+	auth.Password = exportSecret(mode, auth.Password, kind, name, "password")
+	auth.Token = exportSecret(mode, auth.Token, kind, name, "token")
+	return &auth
+}
+
+// nameOf returns the name for the ID, unknown IDs are kept
+func nameOf(id string, names map[string]string) string {
+	if name, ok := names[id]; ok {
+		return name
+	}
+	return id
+}
+
+func namesOf(ids []string, names map[string]string) []string {
+	var result []string
+	for _, id := range ids {
+		result = append(result, nameOf(id, names))
+	}
+	sort.Strings(result)
+	return result
+}
+
+// Format is a config document format
+type Format string
+
+// Supported formats
+const (
+	FormatYAML Format = "yaml"
+	FormatJSON Format = "json"
+)
+
+// Encode writes the config as YAML or JSON
+func (c *Config) Encode(w io.Writer, format Format) error {
+	switch format {
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(c)
+	case FormatYAML, "":
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(c); err != nil {
+			return err
+		}
+		return enc.Close()
+	}
+	return fmt.Errorf("unknown format '%s'", format)
+}
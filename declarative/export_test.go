@@ -0,0 +1,91 @@
+package declarative
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/webhookrelay/webhookrelay-go"
+)
+
+func TestExport(t *testing.T) {
+	client := &fakeClient{
+		funcs: []*webhookrelay.Function{{Id: "f-1", Name: "filter", Driver: "lua", Payload: []byte("-- noop")}},
+		buckets: []*webhookrelay.Bucket{
+			{
+				ID:   "b-2",
+				Name: "stripe",
+				Auth: webhookrelay.BucketAuth{Type: webhookrelay.AuthTypeBasic, Username: "stripe", Password: "hunter2"},
+			},
+			{
+				ID:   "b-1",
+				Name: "github",
+				Outputs: []*webhookrelay.Output{
+					{ID: "o-2", Name: "slack", Destination: "https://hooks.slack.com/x"},
+					{ID: "o-1", Name: "jenkins", Destination: "http://jenkins:8080", FunctionID: "f-1", Internal: true},
+				},
+				Inputs: []*webhookrelay.Input{
+					{ID: "i-1", Name: "public", ResponseFromOutput: "o-1"},
+				},
+			},
+		},
+		tokens: []*webhookrelay.AccessToken{
+			{ID: "t-1", Description: "ci", Active: true, Scopes: webhookrelay.AccessTokenScopes{Buckets: []string{"b-1"}}},
+			{ID: "t-2", Description: "deploy", Active: true, Scopes: webhookrelay.AccessTokenScopes{
+				Functions:      []string{"f-1"},
+				FunctionAccess: webhookrelay.AccessPermissionConfig,
+				BucketAccess:   webhookrelay.AccessPermissionNone,
+			}},
+		},
+	}
+
+	cfg, err := Export(client, &ExportOptions{Secrets: SecretsReference})
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, cfg.Encode(&buf, FormatYAML))
+	assert.Equal(t, `functions:
+  - name: filter
+    driver: lua
+    source: -- noop
+buckets:
+  - name: github
+    inputs:
+      - name: public
+        response_from_output: jenkins
+    outputs:
+      - name: jenkins
+        destination: http://jenkins:8080
+        function: filter
+        internal: true
+      - name: slack
+        destination: https://hooks.slack.com/x
+  - name: stripe
+    auth:
+      type: basic
+      username: stripe
+      password: ${RELAY_BUCKET_STRIPE_PASSWORD}
+tokens:
+  - description: ci
+    active: true
+    buckets:
+      - github
+  - description: deploy
+    active: true
+    functions:
+      - filter
+    bucket_access: none
+    function_access: config
+`, buf.String())
+
+	// exported config applied back to the same account is a no-op
+	redacted, err := Export(client, nil)
+	require.NoError(t, err)
+	assert.Equal(t, Redacted, redacted.Buckets[1].Auth.Password)
+
+	plan, err := Diff(client, redacted, &Options{Prune: true})
+	require.NoError(t, err)
+	assert.True(t, plan.Empty(), plan.String())
+}
@@ -0,0 +1,200 @@
+package declarative
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Action is a planned operation
+type Action string
+
+// Available actions
+const (
+	ActionCreate Action = "create"
+	ActionUpdate Action = "update"
+	ActionDelete Action = "delete"
+)
+
+func (a Action) symbol() string {
+	switch a {
+	case ActionCreate:
+		return "+"
+	case ActionDelete:
+		return "-"
+	default:
+		return "~"
+	}
+}
+
+// Kind is the type of object a change applies to
+type Kind string
+
+// Object kinds managed by the config
+const (
+	KindDomain         Kind = "domain"
+	KindFunction       Kind = "function"
+	KindFunctionConfig Kind = "function config"
+	KindBucket         Kind = "bucket"
+	KindInput          Kind = "input"
+	KindOutput         Kind = "output"
+	KindTunnel         Kind = "tunnel"
+)
+
+// phases define the order in which changes are applied. Objects are
+// created before anything that refers to them and deleted after.
+const (
+	phaseDomains = iota
+	phaseFunctions
+	phaseFunctionConfig
+	phaseBuckets
+	phaseOutputs
+	phaseInputs
+	phaseTunnels
+	phaseDeleteInputs
+	phaseDeleteOutputs
+	phaseDeleteBuckets
+	phaseDeleteTunnels
+	phaseDeleteFunctions
+	phaseDeleteDomains
+)
+
+// FieldChange describes a single changed field of an updated object
+type FieldChange struct {
+	Field string
+	Old   string
+	New   string
+}
+
+// Change is a single planned operation
+type Change struct {
+	Action Action
+	Kind   Kind
+	// Name of the object, inputs and outputs are prefixed with the bucket
+	// name ("bucket/output"), function config with the function name
+	Name   string
+	Fields []FieldChange
+
+	phase int
+	apply func(st *applyState) error
+}
+
+func (c *Change) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s %s %s", c.Action.symbol(), c.Kind, c.Name)
+	for _, f := range c.Fields {
+		fmt.Fprintf(&sb, "\n    %s: %s -> %s", f.Field, f.Old, f.New)
+	}
+	return sb.String()
+}
+
+// Plan is an ordered list of changes
+type Plan struct {
+	Changes []*Change
+}
+
+// Empty returns true when the account already matches the config
+func (p *Plan) Empty() bool {
+	return len(p.Changes) == 0
+}
+
+// Count returns number of changes with the action
+func (p *Plan) Count(action Action) int {
+	n := 0
+	for _, c := range p.Changes {
+		if c.Action == action {
+			n++
+		}
+	}
+	return n
+}
+
+// String returns human readable plan
+func (p *Plan) String() string {
+	if p.Empty() {
+		return "No changes, the account matches the configuration."
+	}
+	var sb strings.Builder
+	for _, c := range p.Changes {
+		sb.WriteString(c.String())
+		sb.WriteString("\n")
+	}
+	fmt.Fprintf(&sb, "Plan: %d to create, %d to update, %d to delete.",
+		p.Count(ActionCreate), p.Count(ActionUpdate), p.Count(ActionDelete))
+	return sb.String()
+}
+
+func (p *Plan) add(c *Change) {
+	p.Changes = append(p.Changes, c)
+}
+
+// sort orders changes by phase keeping the order within a phase
+func (p *Plan) sort() {
+	sort.SliceStable(p.Changes, func(i, j int) bool {
+		return p.Changes[i].phase < p.Changes[j].phase
+	})
+}
+
+// diff collects field changes
+type diff struct {
+	fields []FieldChange
+}
+
+func (d *diff) empty() bool {
+	return len(d.fields) == 0
+}
+
+func (d *diff) str(field, old, new string) {
+	if old != new {
+		d.fields = append(d.fields, FieldChange{Field: field, Old: strconv.Quote(old), New: strconv.Quote(new)})
+	}
+}
+
+// optional compares only when the desired value is set, these fields are
+// filled in by the server otherwise
+func (d *diff) optional(field, old, new string) {
+	if new != "" {
+		d.str(field, old, new)
+	}
+}
+
+// secret compares secrets without printing them
+func (d *diff) secret(field, old, new string) {
+	if old != new {
+		d.fields = append(d.fields, FieldChange{Field: field, Old: "(sensitive)", New: "(sensitive)"})
+	}
+}
+
+func (d *diff) boolean(field string, old, new bool) {
+	if old != new {
+		d.fields = append(d.fields, FieldChange{Field: field, Old: strconv.FormatBool(old), New: strconv.FormatBool(new)})
+	}
+}
+
+func (d *diff) integer(field string, old, new int) {
+	if old != new {
+		d.fields = append(d.fields, FieldChange{Field: field, Old: strconv.Itoa(old), New: strconv.Itoa(new)})
+	}
+}
+
+func (d *diff) headers(field string, old, new map[string][]string) {
+	o, n := formatHeaders(old), formatHeaders(new)
+	if o != n {
+		d.fields = append(d.fields, FieldChange{Field: field, Old: o, New: n})
+	}
+}
+
+func formatHeaders(headers map[string][]string) string {
+	keys := make([]string, 0, len(headers))
+	for k := range headers {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, k+"="+strings.Join(headers[k], ","))
+	}
+	return "{" + strings.Join(parts, "; ") + "}"
+}
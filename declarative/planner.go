@@ -0,0 +1,646 @@
+package declarative
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/webhookrelay/webhookrelay-go"
+)
+
+// Options control planning and applying
+type Options struct {
+	// Prune deletes objects that exist in the account but are not in the
+	// config. Without it the config only adds and updates objects.
+	Prune bool
+	// DryRun only builds the plan, nothing is changed
+	DryRun bool
+}
+
+// Diff compares the config with the account and returns the plan that
+// would make the account match the config
+func Diff(client Client, cfg *Config, opts *Options) (*Plan, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	plan, _, err := buildPlan(client, cfg, opts)
+	return plan, err
+}
+
+func buildPlan(client Client, cfg *Config, opts *Options) (*Plan, *remoteState, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, nil, err
+	}
+	cfg, err := cfg.expandSecrets()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	remote, err := fetchState(client, cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	p := &planner{
+		cfg:    cfg,
+		remote: remote,
+		opts:   opts,
+		plan:   &Plan{},
+	}
+
+	for _, step := range []func() error{p.domains, p.functions, p.buckets, p.tunnels} {
+		if err := step(); err != nil {
+			return nil, nil, err
+		}
+	}
+	p.plan.sort()
+
+	return p.plan, remote, nil
+}
+
+type planner struct {
+	cfg    *Config
+	remote *remoteState
+	opts   *Options
+	plan   *Plan
+}
+
+func (p *planner) domains() error {
+	desired := make(map[string]bool)
+	for _, name := range p.cfg.Domains {
+		name := name
+		desired[name] = true
+		if _, ok := p.remote.domains[name]; ok {
+			continue
+		}
+		p.plan.add(&Change{
+			Action: ActionCreate, Kind: KindDomain, Name: name, phase: phaseDomains,
+			apply: func(st *applyState) error {
+				_, err := st.client.ReserveDomain(&webhookrelay.Domain{Domain: name})
+				return err
+			},
+		})
+	}
+
+	if !p.opts.Prune {
+		return nil
+	}
+	for _, name := range sortedKeys(p.remote.domains) {
+		if desired[name] {
+			continue
+		}
+		domain := p.remote.domains[name]
+		p.plan.add(&Change{
+			Action: ActionDelete, Kind: KindDomain, Name: name, phase: phaseDeleteDomains,
+			apply: func(st *applyState) error {
+				return st.client.DeleteDomainReservation(&webhookrelay.DomainDeleteOptions{Ref: domain.ID})
+			},
+		})
+	}
+	return nil
+}
+
+func (p *planner) functions() error {
+	desired := make(map[string]bool)
+	for idx := range p.cfg.Functions {
+		fn := p.cfg.Functions[idx]
+		desired[fn.Name] = true
+
+		remote, ok := p.remote.functions[fn.Name]
+		if !ok {
+			if fn.Source == "" {
+				return fmt.Errorf("function '%s': source must be set to create it", fn.Name)
+			}
+			p.plan.add(&Change{
+				Action: ActionCreate, Kind: KindFunction, Name: fn.Name, phase: phaseFunctions,
+				apply: func(st *applyState) error {
+					created, err := st.client.CreateFunction(&webhookrelay.CreateFunctionRequest{
+						Name:    fn.Name,
+						Driver:  fn.Driver,
+						Payload: strings.NewReader(fn.Source),
+					})
+					if err != nil {
+						return err
+					}
+					st.functions[fn.Name] = created.Id
+					return nil
+				},
+			})
+		} else {
+			d := &diff{}
+			d.optional("driver", remote.Driver, fn.Driver)
+			// the API doesn't always return the payload, only compare when it does
+			if fn.Source != "" && len(remote.Payload) > 0 && string(remote.Payload) != fn.Source {
+				d.fields = append(d.fields, FieldChange{Field: "source", Old: fmt.Sprintf("(%d bytes)", len(remote.Payload)), New: fmt.Sprintf("(%d bytes)", len(fn.Source))})
+			}
+			if !d.empty() {
+				driver := fn.Driver
+				if driver == "" {
+					driver = remote.Driver
+				}
+				// updates replace the code, resend the current one when the
+				// source isn't set
+				source := fn.Source
+				if source == "" {
+					if len(remote.Payload) == 0 {
+						return fmt.Errorf("function '%s': source must be set to update it, the current code is not available", fn.Name)
+					}
+					source = string(remote.Payload)
+				}
+				id := remote.Id
+				p.plan.add(&Change{
+					Action: ActionUpdate, Kind: KindFunction, Name: fn.Name, Fields: d.fields, phase: phaseFunctions,
+					apply: func(st *applyState) error {
+						_, err := st.client.UpdateFunction(&webhookrelay.UpdateFunctionRequest{
+							ID:      id,
+							Name:    fn.Name,
+							Driver:  driver,
+							Payload: strings.NewReader(source),
+						})
+						return err
+					},
+				})
+			}
+		}
+
+		if err := p.functionConfig(fn, !ok); err != nil {
+			return err
+		}
+	}
+
+	if !p.opts.Prune {
+		return nil
+	}
+	for _, name := range sortedKeys(p.remote.functions) {
+		if desired[name] {
+			continue
+		}
+		id := p.remote.functions[name].Id
+		p.plan.add(&Change{
+			Action: ActionDelete, Kind: KindFunction, Name: name, phase: phaseDeleteFunctions,
+			apply: func(st *applyState) error {
+				return st.client.DeleteFunction(&webhookrelay.FunctionDeleteOptions{ID: id})
+			},
+		})
+	}
+	return nil
+}
+
+// functionConfig plans variable changes, values are treated as secrets
+func (p *planner) functionConfig(fn Function, created bool) error {
+	current := p.remote.functionConfig[fn.Name]
+
+	d := &diff{}
+	var set, unset []string
+	for _, key := range sortedKeys(fn.Config) {
+		old, ok := current[key]
+		if fn.Config[key] == Redacted {
+			if !ok {
+				return fmt.Errorf("function '%s': config '%s' is redacted but not set, there is no value to keep", fn.Name, key)
+			}
+			continue
+		}
+		if ok && old == fn.Config[key] {
+			continue
+		}
+		set = append(set, key)
+		if ok {
+			d.fields = append(d.fields, FieldChange{Field: key, Old: "(sensitive)", New: "(sensitive)"})
+		} else {
+			d.fields = append(d.fields, FieldChange{Field: key, Old: "(unset)", New: "(sensitive)"})
+		}
+	}
+	if p.opts.Prune {
+		for _, key := range sortedKeys(current) {
+			if _, ok := fn.Config[key]; ok {
+				continue
+			}
+			unset = append(unset, key)
+			d.fields = append(d.fields, FieldChange{Field: key, Old: "(sensitive)", New: "(unset)"})
+		}
+	}
+	if d.empty() {
+		return nil
+	}
+
+	action := ActionUpdate
+	if created {
+		action = ActionCreate
+	}
+	p.plan.add(&Change{
+		Action: action, Kind: KindFunctionConfig, Name: fn.Name, Fields: d.fields, phase: phaseFunctionConfig,
+		apply: func(st *applyState) error {
+			id, err := st.functionID(fn.Name)
+			if err != nil {
+				return err
+			}
+			for _, key := range set {
+				_, err := st.client.SetFunctionConfigurationVariable(&webhookrelay.SetFunctionConfigRequest{ID: id, Key: key, Value: fn.Config[key]})
+				if err != nil {
+					return fmt.Errorf("failed to set '%s': %w", key, err)
+				}
+			}
+			for _, key := range unset {
+				err := st.client.DeleteFunctionConfigurationVariable(&webhookrelay.FunctionConfigurationVariableDeleteOptions{ID: id, Key: key})
+				if err != nil {
+					return fmt.Errorf("failed to delete '%s': %w", key, err)
+				}
+			}
+			return nil
+		},
+	})
+	return nil
+}
+
+// checkFunction verifies that a referenced function will exist
+func (p *planner) checkFunction(name string) error {
+	if name == "" {
+		return nil
+	}
+	for _, f := range p.cfg.Functions {
+		if f.Name == name {
+			return nil
+		}
+	}
+	if _, ok := p.remote.functions[name]; ok {
+		if p.opts.Prune {
+			return fmt.Errorf("function '%s' is not in the config and would be deleted by prune", name)
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown function '%s'", name)
+}
+
+func (p *planner) buckets() error {
+	desired := make(map[string]bool)
+	for idx := range p.cfg.Buckets {
+		b := p.cfg.Buckets[idx]
+		desired[b.Name] = true
+
+		remote, ok := p.remote.buckets[b.Name]
+		if !ok {
+			if err := checkRedacted(b.Auth); err != nil {
+				return fmt.Errorf("bucket '%s': %w", b.Name, err)
+			}
+			p.plan.add(&Change{
+				Action: ActionCreate, Kind: KindBucket, Name: b.Name, phase: phaseBuckets,
+				apply: func(st *applyState) error {
+					return st.createBucket(b, p.opts.Prune)
+				},
+			})
+			remote = &webhookrelay.Bucket{Name: b.Name}
+		} else {
+			d := &diff{}
+			d.str("description", remote.Description, b.Description)
+			d.boolean("stream", remote.Stream, b.Stream)
+			d.boolean("ephemeral", remote.Ephemeral, b.Ephemeral)
+			d.boolean("suspended", remote.Suspended, b.Suspended)
+			d.boolean("large_webhooks", remote.LargeWebhooks, b.LargeWebhooks)
+			d.auth(authFromBucket(remote.Auth), b.Auth)
+
+			if !d.empty() {
+				current := *remote
+				p.plan.add(&Change{
+					Action: ActionUpdate, Kind: KindBucket, Name: b.Name, Fields: d.fields, phase: phaseBuckets,
+					apply: func(st *applyState) error {
+						update := current
+						update.Inputs, update.Outputs = nil, nil
+						applyBucket(&update, b)
+						_, err := st.client.UpdateBucket(&update)
+						return err
+					},
+				})
+			}
+		}
+
+		if err := p.outputs(b, remote); err != nil {
+			return err
+		}
+		if err := p.inputs(b, remote); err != nil {
+			return err
+		}
+	}
+
+	if !p.opts.Prune {
+		return nil
+	}
+	for _, name := range sortedKeys(p.remote.buckets) {
+		if desired[name] {
+			continue
+		}
+		id := p.remote.buckets[name].ID
+		p.plan.add(&Change{
+			Action: ActionDelete, Kind: KindBucket, Name: name, phase: phaseDeleteBuckets,
+			apply: func(st *applyState) error {
+				return st.client.DeleteBucket(&webhookrelay.BucketDeleteOptions{Ref: id, Force: true})
+			},
+		})
+	}
+	return nil
+}
+
+func (p *planner) outputs(b Bucket, remote *webhookrelay.Bucket) error {
+	desired := make(map[string]bool)
+	for idx := range b.Outputs {
+		o := b.Outputs[idx]
+		desired[o.Name] = true
+		name := b.Name + "/" + o.Name
+
+		if err := p.checkFunction(o.Function); err != nil {
+			return fmt.Errorf("output '%s': %w", name, err)
+		}
+
+		current, err := findOutput(remote, o.Name)
+		if err != nil {
+			return err
+		}
+		// existing destinations are only checked when they change, the
+		// check is newer than some outputs
+		if current == nil || current.Destination != o.Destination || current.Internal != o.Internal {
+			check := webhookrelay.Output{Destination: o.Destination, Internal: o.Internal}
+			if err := check.ValidateDestination(); err != nil {
+				return fmt.Errorf("output '%s': %w", name, err)
+			}
+		}
+		if current == nil {
+			p.plan.add(&Change{
+				Action: ActionCreate, Kind: KindOutput, Name: name, phase: phaseOutputs,
+				apply: func(st *applyState) error {
+					output := &webhookrelay.Output{}
+					if err := st.applyOutput(output, b.Name, o); err != nil {
+						return err
+					}
+					created, err := st.client.CreateOutput(output)
+					if err != nil {
+						return err
+					}
+					st.outputs[b.Name][o.Name] = created.ID
+					return nil
+				},
+			})
+			continue
+		}
+
+		d := &diff{}
+		d.str("description", current.Description, o.Description)
+		d.str("destination", current.Destination, o.Destination)
+		d.str("function", p.remote.functionNames[current.FunctionID], o.Function)
+		d.headers("headers", current.Headers, o.Headers)
+		d.boolean("disabled", current.Disabled, o.Disabled)
+		d.boolean("lock_path", current.LockPath, o.LockPath)
+		d.boolean("internal", current.Internal, o.Internal)
+		d.integer("timeout", current.Timeout, o.Timeout)
+		if d.empty() {
+			continue
+		}
+
+		base := *current
+		p.plan.add(&Change{
+			Action: ActionUpdate, Kind: KindOutput, Name: name, Fields: d.fields, phase: phaseOutputs,
+			apply: func(st *applyState) error {
+				output := base
+				if err := st.applyOutput(&output, b.Name, o); err != nil {
+					return err
+				}
+				_, err := st.client.UpdateOutput(&output)
+				return err
+			},
+		})
+	}
+
+	if !p.opts.Prune {
+		return nil
+	}
+	for _, current := range remote.Outputs {
+		if desired[current.Name] {
+			continue
+		}
+		bucketID, outputID := remote.ID, current.ID
+		p.plan.add(&Change{
+			Action: ActionDelete, Kind: KindOutput, Name: b.Name + "/" + current.Name, phase: phaseDeleteOutputs,
+			apply: func(st *applyState) error {
+				return st.client.DeleteOutput(&webhookrelay.OutputDeleteOptions{Bucket: bucketID, Output: outputID})
+			},
+		})
+	}
+	return nil
+}
+
+func (p *planner) inputs(b Bucket, remote *webhookrelay.Bucket) error {
+	desired := make(map[string]bool)
+	for idx := range b.Inputs {
+		in := b.Inputs[idx]
+		desired[in.Name] = true
+		name := b.Name + "/" + in.Name
+
+		if err := p.checkFunction(in.Function); err != nil {
+			return fmt.Errorf("input '%s': %w", name, err)
+		}
+
+		current, err := findInput(remote, in.Name)
+		if err != nil {
+			return err
+		}
+		if current == nil {
+			p.plan.add(&Change{
+				Action: ActionCreate, Kind: KindInput, Name: name, phase: phaseInputs,
+				apply: func(st *applyState) error {
+					return st.createInput(b.Name, in)
+				},
+			})
+			continue
+		}
+
+		d := &diff{}
+		d.str("description", current.Description, in.Description)
+		d.str("function", p.remote.functionNames[current.FunctionID], in.Function)
+		d.headers("headers", current.Headers, in.Headers)
+		d.integer("status_code", current.StatusCode, in.StatusCode)
+		d.str("body", current.Body, in.Body)
+		d.str("response_from_output", outputName(remote, current.ResponseFromOutput), in.ResponseFromOutput)
+		d.optional("custom_domain", current.CustomDomain, in.CustomDomain)
+		d.optional("path_prefix", current.PathPrefix, in.PathPrefix)
+		if d.empty() {
+			continue
+		}
+
+		base := *current
+		p.plan.add(&Change{
+			Action: ActionUpdate, Kind: KindInput, Name: name, Fields: d.fields, phase: phaseInputs,
+			apply: func(st *applyState) error {
+				input := base
+				if err := st.applyInput(&input, b.Name, in); err != nil {
+					return err
+				}
+				_, err := st.client.UpdateInput(&input)
+				return err
+			},
+		})
+	}
+
+	if !p.opts.Prune {
+		return nil
+	}
+	for _, current := range remote.Inputs {
+		if desired[current.Name] {
+			continue
+		}
+		bucketID, inputID := remote.ID, current.ID
+		p.plan.add(&Change{
+			Action: ActionDelete, Kind: KindInput, Name: b.Name + "/" + current.Name, phase: phaseDeleteInputs,
+			apply: func(st *applyState) error {
+				return st.client.DeleteInput(&webhookrelay.InputDeleteOptions{Bucket: bucketID, Input: inputID})
+			},
+		})
+	}
+	return nil
+}
+
+func (p *planner) tunnels() error {
+	desired := make(map[string]bool)
+	for idx := range p.cfg.Tunnels {
+		t := p.cfg.Tunnels[idx]
+		desired[t.Name] = true
+
+		remote, ok := p.remote.tunnels[t.Name]
+		if !ok {
+			if err := checkRedacted(t.Auth); err != nil {
+				return fmt.Errorf("tunnel '%s': %w", t.Name, err)
+			}
+			p.plan.add(&Change{
+				Action: ActionCreate, Kind: KindTunnel, Name: t.Name, phase: phaseTunnels,
+				apply: func(st *applyState) error {
+					tunnel := &webhookrelay.Tunnel{}
+					applyTunnel(tunnel, t)
+					_, err := st.client.CreateTunnel(tunnel)
+					return err
+				},
+			})
+			continue
+		}
+
+		d := &diff{}
+		d.str("description", remote.Description, t.Description)
+		d.str("group", remote.Group, t.Group)
+		d.optional("region", remote.Region, t.Region)
+		d.str("destination", remote.Destination, t.Destination)
+		d.optional("host", remote.Host, t.Host)
+		d.optional("protocol", remote.Protocol, t.Protocol)
+		d.optional("crypto", remote.Crypto, t.Crypto)
+		d.str("mode", remote.Mode.String(), tunnelMode(t.Mode).String())
+		d.auth(authFromTunnel(remote.Auth), t.Auth)
+		d.str("rewrite_host_header", remote.Features.RewriteHostHeader, t.RewriteHostHeader)
+		d.str("ingress", formatIngress(remote.IngressRules.Rules), formatIngress(ingressRules(t.Ingress)))
+		if d.empty() {
+			continue
+		}
+
+		base := *remote
+		p.plan.add(&Change{
+			Action: ActionUpdate, Kind: KindTunnel, Name: t.Name, Fields: d.fields, phase: phaseTunnels,
+			apply: func(st *applyState) error {
+				tunnel := base
+				applyTunnel(&tunnel, t)
+				_, err := st.client.UpdateTunnel(&tunnel)
+				return err
+			},
+		})
+	}
+
+	if !p.opts.Prune {
+		return nil
+	}
+	for _, name := range sortedKeys(p.remote.tunnels) {
+		if desired[name] {
+			continue
+		}
+		id := p.remote.tunnels[name].ID
+		p.plan.add(&Change{
+			Action: ActionDelete, Kind: KindTunnel, Name: name, phase: phaseDeleteTunnels,
+			apply: func(st *applyState) error {
+				return st.client.DeleteTunnel(&webhookrelay.TunnelDeleteOptions{ID: id})
+			},
+		})
+	}
+	return nil
+}
+
+// auth compares authentication. Secrets are only compared when the API
+// returns them and they are not redacted in the config.
+func (d *diff) auth(old Auth, new *Auth) {
+	desired := Auth{Type: "none"}
+	if new != nil {
+		desired = *new
+	}
+	if desired.Type == "" {
+		desired.Type = "none"
+	}
+	d.str("auth.type", old.Type, desired.Type)
+	d.str("auth.username", old.Username, desired.Username)
+	if old.Password != "" && desired.Password != Redacted {
+		d.secret("auth.password", old.Password, desired.Password)
+	}
+	if old.Token != "" && desired.Token != Redacted {
+		d.secret("auth.token", old.Token, desired.Token)
+	}
+}
+
+// checkRedacted rejects redacted secrets of objects that don't exist yet,
+// there is no current value to keep
+func checkRedacted(auth *Auth) error {
+	if auth != nil && (auth.Password == Redacted || auth.Token == Redacted) {
+		return fmt.Errorf("auth secret is redacted, set it or use a ${ENV_VAR} reference to create it")
+	}
+	return nil
+}
+
+func authFromBucket(a webhookrelay.BucketAuth) Auth {
+	return Auth{Type: a.Type.String(), Username: a.Username, Password: a.Password, Token: a.Token}
+}
+
+func authFromTunnel(a webhookrelay.TunnelAuth) Auth {
+	return Auth{Type: a.Type.String(), Username: a.Username, Password: a.Password, Token: a.Token}
+}
+
+func tunnelMode(mode string) webhookrelay.TunnelMode {
+	if mode == "" {
+		return webhookrelay.TunnelModeActive
+	}
+	return webhookrelay.ParseTunnelMode(mode)
+}
+
+func ingressRules(rules []IngressRule) []*webhookrelay.IngressRule {
+	var result []*webhookrelay.IngressRule
+	for _, r := range rules {
+		rule := &webhookrelay.IngressRule{Name: r.Name, Path: r.Path}
+		for _, address := range r.Endpoints {
+			rule.Endpoints = append(rule.Endpoints, &webhookrelay.Endpoint{Address: address})
+		}
+		result = append(result, rule)
+	}
+	return result
+}
+
+func formatIngress(rules []*webhookrelay.IngressRule) string {
+	parts := make([]string, 0, len(rules))
+	for _, r := range rules {
+		addresses := make([]string, 0, len(r.Endpoints))
+		for _, e := range r.Endpoints {
+			addresses = append(addresses, e.Address)
+		}
+		parts = append(parts, fmt.Sprintf("%s %s -> %s", r.Name, r.Path, strings.Join(addresses, ",")))
+	}
+	return "[" + strings.Join(parts, "; ") + "]"
+}
+
+// sortedKeys returns sorted keys of a map with string keys
+func sortedKeys(m interface{}) []string {
+	v := reflect.ValueOf(m)
+	keys := make([]string, 0, v.Len())
+	for _, k := range v.MapKeys() {
+		keys = append(keys, k.String())
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,126 @@
+package declarative
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Redacted replaces secrets in exported configs. When applying, redacted
+// values are left unchanged in the account.
+const Redacted = "REDACTED"
+
+// SecretMode controls how secrets are written by Export
+type SecretMode string
+
+// Available secret modes
+const (
+	// SecretsRedact replaces secrets with Redacted (default)
+	SecretsRedact SecretMode = "redact"
+	// SecretsReference replaces secrets with ${ENV_VAR} references that are
+	// expanded from the environment when the config is applied
+	SecretsReference SecretMode = "reference"
+	// SecretsInclude writes secrets as they are returned by the API
+	SecretsInclude SecretMode = "include"
+)
+
+var (
+	secretRefPattern = regexp.MustCompile(`^\$\{([A-Za-z_][A-Za-z0-9_]*)\}$`)
+	envUnsafePattern = regexp.MustCompile(`[^A-Z0-9_]+`)
+)
+
+// expandSecret resolves ${ENV_VAR} references, other values are returned as is
+func expandSecret(value string) (string, error) {
+	m := secretRefPattern.FindStringSubmatch(value)
+	if m == nil {
+		return value, nil
+	}
+	v, ok := os.LookupEnv(m[1])
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", m[1])
+	}
+	return v, nil
+}
+
+// secretRef builds an environment variable reference from name parts,
+// e.g. ["bucket", "github", "password"] -> ${RELAY_BUCKET_GITHUB_PASSWORD}
+func secretRef(parts ...string) string {
+	name := strings.ToUpper("relay_" + strings.Join(parts, "_"))
+	name = envUnsafePattern.ReplaceAllString(name, "_")
+	return "${" + name + "}"
+}
+
+// exportSecret writes a secret according to the mode
+func exportSecret(mode SecretMode, value string, parts ...string) string {
+	if value == "" {
+		return ""
+	}
+	switch mode {
+	case SecretsInclude:
+		return value
+	case SecretsReference:
+		return secretRef(parts...)
+	default:
+		return Redacted
+	}
+}
+
+// expandSecrets returns a copy of the config with secret references resolved
+func (c *Config) expandSecrets() (*Config, error) {
+	out := *c
+
+	out.Functions = make([]Function, len(c.Functions))
+	for idx, f := range c.Functions {
+		if f.Config != nil {
+			vars := make(map[string]string, len(f.Config))
+			for k, v := range f.Config {
+				expanded, err := expandSecret(v)
+				if err != nil {
+					return nil, fmt.Errorf("function '%s' config '%s': %w", f.Name, k, err)
+				}
+				vars[k] = expanded
+			}
+			f.Config = vars
+		}
+		out.Functions[idx] = f
+	}
+
+	out.Buckets = make([]Bucket, len(c.Buckets))
+	for idx, b := range c.Buckets {
+		auth, err := expandAuth(b.Auth)
+		if err != nil {
+			return nil, fmt.Errorf("bucket '%s': %w", b.Name, err)
+		}
+		b.Auth = auth
+		out.Buckets[idx] = b
+	}
+
+	out.Tunnels = make([]Tunnel, len(c.Tunnels))
+	for idx, t := range c.Tunnels {
+		auth, err := expandAuth(t.Auth)
+		if err != nil {
+			return nil, fmt.Errorf("tunnel '%s': %w", t.Name, err)
+		}
+		t.Auth = auth
+		out.Tunnels[idx] = t
+	}
+
+	return &out, nil
+}
+
+func expandAuth(auth *Auth) (*Auth, error) {
+	if auth == nil {
+		return nil, nil
+	}
+	expanded := *auth
+
+	var err error
+	if expanded.Password, err = expandSecret(auth.Password); err != nil {
+		return nil, fmt.Errorf("auth password: %w", err)
+	}
+	if expanded.Token, err = expandSecret(auth.Token); err != nil {
+		return nil, fmt.Errorf("auth token: %w", err)
+	}
+	return &expanded, nil
+}
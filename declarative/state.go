@@ -0,0 +1,166 @@
+package declarative
+
+import (
+	"fmt"
+
+	"github.com/webhookrelay/webhookrelay-go"
+)
+
+// Client is the part of the Webhook Relay API used to plan and apply
+// configuration, *webhookrelay.API implements it.
+type Client interface {
+	ListDomainReservations(options *webhookrelay.DomainListOptions) ([]*webhookrelay.Domain, error)
+	ReserveDomain(options *webhookrelay.Domain) (*webhookrelay.Domain, error)
+	DeleteDomainReservation(options *webhookrelay.DomainDeleteOptions) error
+
+	ListFunctions(options *webhookrelay.FunctionListOptions) ([]*webhookrelay.Function, error)
+	CreateFunction(opts *webhookrelay.CreateFunctionRequest) (*webhookrelay.Function, error)
+	UpdateFunction(options *webhookrelay.UpdateFunctionRequest) (*webhookrelay.Function, error)
+	DeleteFunction(options *webhookrelay.FunctionDeleteOptions) error
+	ListFunctionConfigurationVariables(options *webhookrelay.FunctionConfigurationVariablesListOptions) ([]*webhookrelay.Variable, error)
+	SetFunctionConfigurationVariable(options *webhookrelay.SetFunctionConfigRequest) (*webhookrelay.Variable, error)
+	DeleteFunctionConfigurationVariable(options *webhookrelay.FunctionConfigurationVariableDeleteOptions) error
+
+	ListBuckets(options *webhookrelay.BucketListOptions) ([]*webhookrelay.Bucket, error)
+	CreateBucket(options *webhookrelay.BucketCreateOptions) (*webhookrelay.Bucket, error)
+	UpdateBucket(options *webhookrelay.Bucket) (*webhookrelay.Bucket, error)
+	DeleteBucket(options *webhookrelay.BucketDeleteOptions) error
+
+	CreateInput(options *webhookrelay.Input) (*webhookrelay.Input, error)
+	UpdateInput(options *webhookrelay.Input) (*webhookrelay.Input, error)
+	DeleteInput(options *webhookrelay.InputDeleteOptions) error
+
+	CreateOutput(options *webhookrelay.Output) (*webhookrelay.Output, error)
+	UpdateOutput(options *webhookrelay.Output) (*webhookrelay.Output, error)
+	DeleteOutput(options *webhookrelay.OutputDeleteOptions) error
+
+	ListTunnels(options *webhookrelay.TunnelListOptions) ([]*webhookrelay.Tunnel, error)
+	CreateTunnel(options *webhookrelay.Tunnel) (*webhookrelay.Tunnel, error)
+	UpdateTunnel(options *webhookrelay.Tunnel) (*webhookrelay.Tunnel, error)
+	DeleteTunnel(options *webhookrelay.TunnelDeleteOptions) error
+}
+
+// remoteState is a snapshot of the account, objects are indexed by name
+type remoteState struct {
+	domains        map[string]*webhookrelay.Domain
+	functions      map[string]*webhookrelay.Function
+	functionNames  map[string]string            // function ID -> name
+	functionConfig map[string]map[string]string // function name -> variables
+	buckets        map[string]*webhookrelay.Bucket
+	tunnels        map[string]*webhookrelay.Tunnel
+}
+
+// fetchState lists everything the config can manage. Function configuration
+// is only fetched for functions present in the config, the rest are either
+// left alone or deleted as a whole.
+func fetchState(client Client, cfg *Config) (*remoteState, error) {
+	st := &remoteState{
+		domains:        make(map[string]*webhookrelay.Domain),
+		functions:      make(map[string]*webhookrelay.Function),
+		functionNames:  make(map[string]string),
+		functionConfig: make(map[string]map[string]string),
+		buckets:        make(map[string]*webhookrelay.Bucket),
+		tunnels:        make(map[string]*webhookrelay.Tunnel),
+	}
+
+	domains, err := client.ListDomainReservations(&webhookrelay.DomainListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list domains: %w", err)
+	}
+	for _, d := range domains {
+		st.domains[d.Domain] = d
+	}
+
+	functions, err := client.ListFunctions(&webhookrelay.FunctionListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list functions: %w", err)
+	}
+	for _, f := range functions {
+		if _, ok := st.functions[f.Name]; ok {
+			return nil, fmt.Errorf("account has several functions named '%s'", f.Name)
+		}
+		st.functions[f.Name] = f
+		st.functionNames[f.Id] = f.Name
+	}
+
+	for _, f := range cfg.Functions {
+		remote, ok := st.functions[f.Name]
+		if !ok {
+			continue
+		}
+		variables, err := client.ListFunctionConfigurationVariables(&webhookrelay.FunctionConfigurationVariablesListOptions{ID: remote.Id})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list function '%s' config: %w", f.Name, err)
+		}
+		vars := make(map[string]string, len(variables))
+		for _, v := range variables {
+			vars[v.Key] = v.Value
+		}
+		st.functionConfig[f.Name] = vars
+	}
+
+	buckets, err := client.ListBuckets(&webhookrelay.BucketListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list buckets: %w", err)
+	}
+	for _, b := range buckets {
+		if _, ok := st.buckets[b.Name]; ok {
+			return nil, fmt.Errorf("account has several buckets named '%s'", b.Name)
+		}
+		st.buckets[b.Name] = b
+	}
+
+	tunnels, err := client.ListTunnels(&webhookrelay.TunnelListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tunnels: %w", err)
+	}
+	for _, t := range tunnels {
+		if _, ok := st.tunnels[t.Name]; ok {
+			return nil, fmt.Errorf("account has several tunnels named '%s'", t.Name)
+		}
+		st.tunnels[t.Name] = t
+	}
+
+	return st, nil
+}
+
+// outputName maps an output ID to its name within the bucket
+func outputName(bucket *webhookrelay.Bucket, id string) string {
+	if id == "" || id == webhookrelay.AnyResponseFromOutput {
+		return id
+	}
+	for _, o := range bucket.Outputs {
+		if o.ID == id {
+			return o.Name
+		}
+	}
+	return id
+}
+
+func findInput(bucket *webhookrelay.Bucket, name string) (*webhookrelay.Input, error) {
+	var found *webhookrelay.Input
+	for _, i := range bucket.Inputs {
+		if i.Name != name {
+			continue
+		}
+		if found != nil {
+			return nil, fmt.Errorf("bucket '%s' has several inputs named '%s'", bucket.Name, name)
+		}
+		found = i
+	}
+	return found, nil
+}
+
+func findOutput(bucket *webhookrelay.Bucket, name string) (*webhookrelay.Output, error) {
+	var found *webhookrelay.Output
+	for _, o := range bucket.Outputs {
+		if o.Name != name {
+			continue
+		}
+		if found != nil {
+			return nil, fmt.Errorf("bucket '%s' has several outputs named '%s'", bucket.Name, name)
+		}
+		found = o
+	}
+	return found, nil
+}
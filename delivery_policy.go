@@ -0,0 +1,122 @@
+package webhookrelay
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+)
+
+// BackoffStrategy sets how the delay between retries grows
+type BackoffStrategy string
+
+// Available backoff strategies
+const (
+	BackoffFixed       BackoffStrategy = "fixed"
+	BackoffExponential BackoffStrategy = "exponential"
+)
+
+// WebhookRetryPolicy controls redelivery of webhooks that failed to reach
+// an output. Output policies override the bucket policy.
+type WebhookRetryPolicy struct {
+	// MaxAttempts including the first delivery, 1 disables retries
+	MaxAttempts int             `json:"max_attempts"`
+	Backoff     BackoffStrategy `json:"backoff,omitempty"` // defaults to exponential
+	// InitialDelay and MaxDelay are in seconds
+	InitialDelay int `json:"initial_delay,omitempty"`
+	MaxDelay     int `json:"max_delay,omitempty"`
+	// RetryOnStatus lists response codes that count as failures, defaults
+	// to 429 and 5xx. Connection errors and timeouts always do.
+	RetryOnStatus []int `json:"retry_on_status,omitempty"`
+}
+
+// Validate checks the policy
+func (p *WebhookRetryPolicy) Validate() error {
+	if p.MaxAttempts < 1 {
+		return fmt.Errorf("retry policy: max attempts must be at least 1")
+	}
+	switch p.Backoff {
+	case "", BackoffFixed, BackoffExponential:
+	default:
+		return fmt.Errorf("retry policy: unknown backoff '%s'", p.Backoff)
+	}
+	if p.InitialDelay < 0 || p.MaxDelay < 0 {
+		return fmt.Errorf("retry policy: delays must not be negative")
+	}
+	if p.MaxDelay > 0 && p.MaxDelay < p.InitialDelay {
+		return fmt.Errorf("retry policy: max delay is shorter than initial delay")
+	}
+	for _, code := range p.RetryOnStatus {
+		if code < 100 || code > 599 {
+			return fmt.Errorf("retry policy: invalid status code %d", code)
+		}
+	}
+	return nil
+}
+
+// IsFailure reports whether a response with the status code is retried
+func (p *WebhookRetryPolicy) IsFailure(statusCode int) bool {
+	if len(p.RetryOnStatus) == 0 {
+		return statusCode == http.StatusTooManyRequests || statusCode >= 500
+	}
+	for _, code := range p.RetryOnStatus {
+		if code == statusCode {
+			return true
+		}
+	}
+	return false
+}
+
+// Delay returns the wait before the retry attempt (the first retry is
+// attempt 1)
+func (p *WebhookRetryPolicy) Delay(attempt int) time.Duration {
+	if attempt < 1 {
+		return 0
+	}
+	delay := float64(time.Duration(p.InitialDelay) * time.Second)
+	if p.Backoff != BackoffFixed {
+		delay *= math.Pow(2, float64(attempt-1))
+	}
+	// compared as float64, a large attempt would overflow the duration
+	max := time.Duration(math.MaxInt64)
+	if p.MaxDelay > 0 {
+		max = time.Duration(p.MaxDelay) * time.Second
+	}
+	if delay >= float64(max) {
+		return max
+	}
+	return time.Duration(delay)
+}
+
+// DeadLetterPolicy keeps webhooks that exhausted their retries
+type DeadLetterPolicy struct {
+	Enabled bool `json:"enabled"`
+	// Destination optionally receives dead-lettered webhooks, they are
+	// kept in the logs with RequestStatusDeadLetter either way
+	Destination string `json:"destination,omitempty"`
+}
+
+// Validate checks the policy
+func (p *DeadLetterPolicy) Validate() error {
+	if p.Destination == "" {
+		return nil
+	}
+	o := &Output{Destination: p.Destination}
+	if err := o.ValidateDestination(); err != nil {
+		return fmt.Errorf("dead letter: %w", err)
+	}
+	return nil
+}
+
+// validateDeliveryPolicies checks optional retry and dead letter policies
+func validateDeliveryPolicies(retry *WebhookRetryPolicy, deadLetter *DeadLetterPolicy) error {
+	if retry != nil {
+		if err := retry.Validate(); err != nil {
+			return err
+		}
+	}
+	if deadLetter != nil {
+		return deadLetter.Validate()
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package webhookrelay
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhookRetryPolicy(t *testing.T) {
+	p := &WebhookRetryPolicy{MaxAttempts: 5, InitialDelay: 10, MaxDelay: 60}
+	assert.NoError(t, p.Validate())
+	assert.Equal(t, time.Duration(0), p.Delay(0))
+	assert.Equal(t, 10*time.Second, p.Delay(1))
+	assert.Equal(t, 20*time.Second, p.Delay(2))
+	assert.Equal(t, 40*time.Second, p.Delay(3))
+	assert.Equal(t, 60*time.Second, p.Delay(4))
+	assert.Equal(t, 60*time.Second, (&WebhookRetryPolicy{InitialDelay: 1, MaxDelay: 60}).Delay(35))
+	assert.Equal(t, 60*time.Second, p.Delay(100))
+	assert.Equal(t, time.Duration(math.MaxInt64), (&WebhookRetryPolicy{InitialDelay: 1}).Delay(100))
+
+	assert.True(t, p.IsFailure(503))
+	assert.True(t, p.IsFailure(429))
+	assert.False(t, p.IsFailure(404))
+
+	p = &WebhookRetryPolicy{MaxAttempts: 3, Backoff: BackoffFixed, InitialDelay: 5, RetryOnStatus: []int{404, 502}}
+	assert.Equal(t, 5*time.Second, p.Delay(3))
+	assert.True(t, p.IsFailure(404))
+	assert.False(t, p.IsFailure(500))
+
+	assert.EqualError(t, (&WebhookRetryPolicy{}).Validate(), "retry policy: max attempts must be at least 1")
+	assert.EqualError(t, (&WebhookRetryPolicy{MaxAttempts: 2, Backoff: "linear"}).Validate(), "retry policy: unknown backoff 'linear'")
+	assert.EqualError(t, (&WebhookRetryPolicy{MaxAttempts: 2, InitialDelay: 30, MaxDelay: 10}).Validate(), "retry policy: max delay is shorter than initial delay")
+	assert.EqualError(t, (&WebhookRetryPolicy{MaxAttempts: 2, RetryOnStatus: []int{42}}).Validate(), "retry policy: invalid status code 42")
+
+	assert.NoError(t, (&DeadLetterPolicy{Enabled: true}).Validate())
+	assert.EqualError(t, (&DeadLetterPolicy{Enabled: true, Destination: "http://localhost"}).Validate(),
+		"dead letter: destination 'http://localhost' is not reachable from Webhook Relay servers, use an internal output")
+
+	output := &Output{Destination: "https://example.com", RetryPolicy: &WebhookRetryPolicy{}}
+	assert.Error(t, output.validate())
+}
@@ -2,7 +2,6 @@ package webhookrelay
 
 import (
 	"encoding/json"
-	"fmt"
 	"net/http"
 	"time"
 
@@ -90,6 +89,7 @@ func (api *API) ReserveDomain(options *Domain) (*Domain, error) {
 	if err != nil {
 		return nil, err
 	}
+	api.invalidateRefs(ResourceDomain, "")
 
 	var domainReservation Domain
 	if err := json.Unmarshal(resp, &domainReservation); err != nil {
@@ -102,31 +102,17 @@ func (api *API) ReserveDomain(options *Domain) (*Domain, error) {
 // once no Input or Tunnel is using it.
 func (api *API) DeleteDomainReservation(options *DomainDeleteOptions) error {
 
-	if !IsUUID(options.Ref) {
-		var err error
-		options.Ref, err = api.domainIDFromName(options.Ref)
-		if err != nil {
-			return err
-		}
+	domainID, err := api.resolveRef(ResourceDomain, "", options.Ref)
+	if err != nil {
+		return err
 	}
+	options.Ref = domainID
 
-	_, err := api.makeRequest(http.MethodDelete, "/domains/"+options.Ref, nil)
+	_, err = api.makeRequest(http.MethodDelete, "/domains/"+options.Ref, nil)
 	if err != nil {
 		return err
 	}
+	api.invalidateRefs(ResourceDomain, "")
 
 	return nil
 }
-
-func (api *API) domainIDFromName(domainName string) (id string, err error) {
-	domains, err := api.ListDomainReservations(&DomainListOptions{})
-	if err != nil {
-		return
-	}
-	for _, b := range domains {
-		if b.Domain == domainName {
-			return b.ID, nil
-		}
-	}
-	return "", fmt.Errorf("no such domain '%s'", domainName)
-}
@@ -0,0 +1,113 @@
+package webhookrelay
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// InputURL returns the public endpoint of the input. Inputs with a custom
+// domain are served on that domain under PathPrefix, others under the
+// webhooks path of the configured API server (BaseURL, or the URL set with
+// WithWebhooksURL). Region DomainSuffix is not used: regions are the
+// servers tunnels connect to, buckets and inputs have no region and are
+// served by the API server, so a regional deployment is configured with
+// WithAPIEndpointURL or WithWebhooksURL.
+func (api *API) InputURL(input *Input) (*url.URL, error) {
+	if input.CustomDomain != "" {
+		path := input.PathPrefix
+		if !strings.HasPrefix(path, "/") {
+			path = "/" + path
+		}
+		return &url.URL{Scheme: api.endpointScheme(), Host: input.CustomDomain, Path: path}, nil
+	}
+	if input.ID == "" {
+		return nil, fmt.Errorf("input has neither ID nor custom domain")
+	}
+
+	base, err := url.Parse(api.webhooksURL())
+	if err != nil {
+		return nil, fmt.Errorf("invalid webhooks URL: %w", err)
+	}
+	base.Path = strings.TrimSuffix(base.Path, "/") + "/" + url.PathEscape(input.ID)
+	return base, nil
+}
+
+// TunnelURL returns the public URL of the tunnel. Hosts without a domain are
+// completed with the DomainSuffix of the tunnel region, regions are fetched
+// once with ListRegions unless provided with WithRegions. TCP tunnels return
+// a tcp:// URL.
+func (api *API) TunnelURL(tunnel *Tunnel) (*url.URL, error) {
+	host := tunnel.Host
+	if tunnel.Protocol == "tcp" && tunnel.Addr != "" {
+		host = tunnel.Addr
+	}
+	if host == "" {
+		return nil, fmt.Errorf("tunnel '%s' has no host", tunnel.Name)
+	}
+
+	hostname, port := host, ""
+	if h, p, err := net.SplitHostPort(host); err == nil {
+		hostname, port = h, p
+	}
+	if !strings.Contains(hostname, ".") && tunnel.Region != "" {
+		region, err := api.region(tunnel.Region)
+		if err != nil {
+			return nil, err
+		}
+		if region.DomainSuffix != "" {
+			hostname = hostname + "." + strings.TrimPrefix(region.DomainSuffix, ".")
+		}
+	}
+	host = hostname
+	if port != "" {
+		host = net.JoinHostPort(hostname, port)
+	}
+
+	switch {
+	case tunnel.Protocol == "tcp":
+		return &url.URL{Scheme: "tcp", Host: host}, nil
+	case tunnel.Crypto == CryptoOff:
+		return &url.URL{Scheme: "http", Host: host}, nil
+	default:
+		return &url.URL{Scheme: "https", Host: host}, nil
+	}
+}
+
+// webhooksURL is where inputs without a custom domain receive webhooks
+func (api *API) webhooksURL() string {
+	if api.WebhooksURL != "" {
+		return api.WebhooksURL
+	}
+	return strings.TrimSuffix(api.BaseURL, "/") + "/webhooks"
+}
+
+// endpointScheme follows the webhooks URL so plain HTTP self-hosted setups
+// get http:// endpoints
+func (api *API) endpointScheme() string {
+	if strings.HasPrefix(api.webhooksURL(), "http://") {
+		return "http"
+	}
+	return "https"
+}
+
+// region finds a region by ID or name
+func (api *API) region(ref string) (*Region, error) {
+	api.regionsMu.Lock()
+	defer api.regionsMu.Unlock()
+
+	if api.regions == nil {
+		regions, err := api.ListRegions(&RegionListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list regions: %w", err)
+		}
+		api.regions = regions
+	}
+	for _, r := range api.regions {
+		if r.ID == ref || r.Name == ref {
+			return r, nil
+		}
+	}
+	return nil, &NotFoundError{Resource: "region", Ref: ref}
+}
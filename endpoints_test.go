@@ -0,0 +1,80 @@
+package webhookrelay
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInputURL(t *testing.T) {
+	client, err := New("test-key", "test-secret")
+	require.NoError(t, err)
+
+	u, err := client.InputURL(&Input{ID: "abc"})
+	require.NoError(t, err)
+	assert.Equal(t, "https://my.webhookrelay.com/v1/webhooks/abc", u.String())
+
+	u, err = client.InputURL(&Input{ID: "abc", CustomDomain: "x1.hooks.webhookrelay.com", PathPrefix: "/github"})
+	require.NoError(t, err)
+	assert.Equal(t, "https://x1.hooks.webhookrelay.com/github", u.String())
+
+	u, err = client.InputURL(&Input{ID: "abc", CustomDomain: "x1.hooks.webhookrelay.com"})
+	require.NoError(t, err)
+	assert.Equal(t, "https://x1.hooks.webhookrelay.com/", u.String())
+
+	selfHosted, err := New("test-key", "test-secret", WithAPIEndpointURL("http://relay.internal:8080/v1/"))
+	require.NoError(t, err)
+	u, err = selfHosted.InputURL(&Input{ID: "abc"})
+	require.NoError(t, err)
+	assert.Equal(t, "http://relay.internal:8080/v1/webhooks/abc", u.String())
+	u, err = selfHosted.InputURL(&Input{CustomDomain: "hooks.internal", PathPrefix: "ci"})
+	require.NoError(t, err)
+	assert.Equal(t, "http://hooks.internal/ci", u.String())
+
+	regional, err := New("test-key", "test-secret", WithWebhooksURL("https://au.webhookrelay.com/v1/webhooks"))
+	require.NoError(t, err)
+	u, err = regional.InputURL(&Input{ID: "abc"})
+	require.NoError(t, err)
+	assert.Equal(t, "https://au.webhookrelay.com/v1/webhooks/abc", u.String())
+
+	_, err = client.InputURL(&Input{})
+	assert.Error(t, err)
+}
+
+func TestTunnelURL(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		assert.Equal(t, "/regions", r.URL.Path)
+		_, _ = w.Write([]byte(`[{"id": "r-1", "name": "au", "domain_suffix": "au.webrelay.io"}]`))
+	}))
+	defer server.Close()
+
+	client, err := New("test-key", "test-secret", WithAPIEndpointURL(server.URL))
+	require.NoError(t, err)
+
+	u, err := client.TunnelURL(&Tunnel{Host: "abc.webrelay.io", Crypto: CryptoFlexible})
+	require.NoError(t, err)
+	assert.Equal(t, "https://abc.webrelay.io", u.String())
+
+	u, err = client.TunnelURL(&Tunnel{Host: "abc", Region: "au", Crypto: CryptoOff})
+	require.NoError(t, err)
+	assert.Equal(t, "http://abc.au.webrelay.io", u.String())
+
+	u, err = client.TunnelURL(&Tunnel{Host: "abc", Region: "r-1", Protocol: "tcp", Addr: "abc:5000"})
+	require.NoError(t, err)
+	assert.Equal(t, "tcp://abc.au.webrelay.io:5000", u.String())
+	assert.Equal(t, 1, requests, "regions are fetched once")
+
+	_, err = client.TunnelURL(&Tunnel{Host: "abc", Region: "us"})
+	assert.True(t, IsNotFound(err))
+
+	offline, err := New("test-key", "test-secret", WithRegions([]*Region{{Name: "eu", DomainSuffix: ".eu.example.com"}}))
+	require.NoError(t, err)
+	u, err = offline.TunnelURL(&Tunnel{Host: "abc", Region: "eu"})
+	require.NoError(t, err)
+	assert.Equal(t, "https://abc.eu.example.com", u.String())
+}
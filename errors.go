@@ -1,10 +1,22 @@
 package webhookrelay
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
 
 // Errors
 var (
 	ErrEmptyCredentials = errors.New("invalid credentials: key & secret must not be empty")
+	// ErrPreconditionFailed is returned when an object was modified since it
+	// was read, either detected by the client or by the server (HTTP 412)
+	ErrPreconditionFailed = errors.New("precondition failed")
+	// ErrAuthNotApplied is returned by CreateBucket when the server created
+	// the bucket without the requested auth. The bucket is deleted instead
+	// of updated, so that it never accepts unauthenticated webhooks.
+	ErrAuthNotApplied = errors.New("server did not apply the bucket auth")
 )
 
 // Error messages
@@ -12,3 +24,81 @@ var (
 	errMakeRequestError = "error from makeRequest"
 	errUnmarshalError   = "error while unmarshalling the JSON response"
 )
+
+// NotFoundError is returned when a resource reference (ID or name) cannot be
+// resolved to an existing object, or when the API responds with 404 for it.
+type NotFoundError struct {
+	Resource string // resource kind, e.g. "function"
+	Ref      string // reference that was looked up
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("no such %s '%s'", e.Resource, e.Ref)
+}
+
+// Is allows matching the resource specific sentinel errors such as
+// ErrNoSuchInput with errors.Is
+func (e *NotFoundError) Is(target error) bool {
+	switch target {
+	case ErrNoSuchInput:
+		return e.Resource == string(ResourceInput)
+	case ErrNoSuchOutput:
+		return e.Resource == string(ResourceOutput)
+	}
+	return false
+}
+
+// uriResources maps API path segments to resource kinds
+var uriResources = map[string]string{
+	"buckets":   string(ResourceBucket),
+	"inputs":    string(ResourceInput),
+	"outputs":   string(ResourceOutput),
+	"tunnels":   string(ResourceTunnel),
+	"functions": string(ResourceFunction),
+	"domains":   string(ResourceDomain),
+	"tokens":    "access token",
+}
+
+// notFoundFromURI returns a *NotFoundError for the last object referenced
+// in an API path such as /buckets/{id}/outputs/{id}, nil if the path has
+// no object reference
+func notFoundFromURI(uri string) *NotFoundError {
+	if idx := strings.IndexByte(uri, '?'); idx >= 0 {
+		uri = uri[:idx]
+	}
+	segments := strings.Split(strings.Trim(uri, "/"), "/")
+	var nf *NotFoundError
+	for i := 0; i+1 < len(segments); i += 2 {
+		resource, ok := uriResources[segments[i]]
+		if !ok || segments[i+1] == "" {
+			break
+		}
+		nf = &NotFoundError{Resource: resource, Ref: segments[i+1]}
+	}
+	return nf
+}
+
+// IsNotFound returns true if err (or any error it wraps) is a *NotFoundError.
+func IsNotFound(err error) bool {
+	var nf *NotFoundError
+	return errors.As(err, &nf)
+}
+
+// ConflictError is returned by patch operations when the object was updated
+// after the version the patch is based on
+type ConflictError struct {
+	Resource  string
+	Ref       string
+	Expected  time.Time // UpdatedAt the patch is based on
+	UpdatedAt time.Time // current UpdatedAt
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("%s '%s' was modified at %s, expected %s", e.Resource, e.Ref,
+		e.UpdatedAt.UTC().Format(time.RFC3339), e.Expected.UTC().Format(time.RFC3339))
+}
+
+// Is allows matching with errors.Is(err, ErrPreconditionFailed)
+func (e *ConflictError) Is(target error) bool {
+	return target == ErrPreconditionFailed
+}
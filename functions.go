@@ -79,7 +79,12 @@ func (api *API) ListFunctions(options *FunctionListOptions) ([]*Function, error)
 // InvokeFunction invokes function and gets a response
 func (api *API) InvokeFunction(options *InvokeOpts) (*ExecuteResponse, error) {
 
-	resp, err := api.makeRequest("POST", "/functions/"+options.ID+"/invoke", options.InvokeFunctionRequest)
+	id, err := api.ensureFunctionID(options.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := api.makeRequest("POST", "/functions/"+id+"/invoke", options.InvokeFunctionRequest)
 	if err != nil {
 		return nil, err
 	}
@@ -130,6 +135,8 @@ func (api *API) CreateFunction(opts *CreateFunctionRequest) (*Function, error) {
 		return nil, err
 	}
 
+	api.invalidateRefs(ResourceFunction, "")
+
 	var f reactor_v1.Function
 	if err := json.Unmarshal(resp, &f); err != nil {
 		return nil, err
@@ -140,18 +147,20 @@ func (api *API) CreateFunction(opts *CreateFunctionRequest) (*Function, error) {
 // UpdateFunction - update function
 func (api *API) UpdateFunction(options *UpdateFunctionRequest) (*Function, error) {
 
-	if options.ID != "" {
-		// ok
-	} else if options.Name != "" {
-		fID, err := api.ensureFunctionID(options.ID)
-		if err != nil {
-			return nil, err
-		}
-		options.ID = fID
-	} else {
+	ref := options.ID
+	if ref == "" {
+		ref = options.Name
+	}
+	if ref == "" {
 		return nil, fmt.Errorf("either name or ID has to be set")
 	}
 
+	fID, err := api.ensureFunctionID(ref)
+	if err != nil {
+		return nil, err
+	}
+	options.ID = fID
+
 	functionBody, err := ioutil.ReadAll(options.Payload)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to read function body")
@@ -167,6 +176,8 @@ func (api *API) UpdateFunction(options *UpdateFunctionRequest) (*Function, error
 	if err != nil {
 		return nil, err
 	}
+	api.invalidateRefs(ResourceFunction, "")
+
 	var function reactor_v1.Function
 	if err := json.Unmarshal(resp, &function); err != nil {
 		return nil, err
@@ -195,31 +206,12 @@ func (api *API) DeleteFunction(options *FunctionDeleteOptions) error {
 	if err != nil {
 		return err
 	}
+	api.invalidateRefs(ResourceFunction, "")
 
 	return nil
 }
 
 // ensureFunctionID - takes name/id and always returns ID (when it not fails)
 func (api *API) ensureFunctionID(ref string) (string, error) {
-	if !IsUUID(ref) {
-		id, err := api.functionIDFromRef(ref)
-		if err != nil {
-			return "", err
-		}
-		return id, nil
-	}
-	return ref, nil
-}
-
-func (api *API) functionIDFromRef(ref string) (id string, err error) {
-	functions, err := api.ListFunctions(&FunctionListOptions{})
-	if err != nil {
-		return
-	}
-	for _, f := range functions {
-		if f.Id == ref || f.Name == ref {
-			return f.Id, nil
-		}
-	}
-	return "", fmt.Errorf("no such function '%s'", ref)
+	return api.resolveRef(ResourceFunction, "", ref)
 }
@@ -30,7 +30,12 @@ type FunctionConfigurationVariablesListOptions struct {
 
 // ListFunctionConfigurationVariables lists function configuration variables
 func (api *API) ListFunctionConfigurationVariables(options *FunctionConfigurationVariablesListOptions) ([]*Variable, error) {
-	resp, err := api.makeRequest(http.MethodGet, "/functions/"+options.ID+"/config", nil)
+	id, err := api.ensureFunctionID(options.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := api.makeRequest(http.MethodGet, "/functions/"+id+"/config", nil)
 	if err != nil {
 		return nil, errors.Wrap(err, errMakeRequestError)
 	}
@@ -48,7 +53,12 @@ func (api *API) ListFunctionConfigurationVariables(options *FunctionConfiguratio
 // to retrieve those variables during runtime.
 func (api *API) SetFunctionConfigurationVariable(options *SetFunctionConfigRequest) (*Variable, error) {
 
-	resp, err := api.makeRequest("PUT", "/functions/"+options.ID+"/config", options)
+	id, err := api.ensureFunctionID(options.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := api.makeRequest("PUT", "/functions/"+id+"/config", options)
 	if err != nil {
 		return nil, err
 	}
@@ -82,7 +92,7 @@ func (api *API) DeleteFunctionConfigurationVariable(options *FunctionConfigurati
 	}
 	options.ID = id
 
-	path := url.PathEscape("/functions/" + options.ID + "/config/" + options.Key)
+	path := "/functions/" + options.ID + "/config/" + url.PathEscape(options.Key)
 
 	_, err = api.makeRequest("DELETE", path, nil)
 	if err != nil {
@@ -0,0 +1,64 @@
+package webhookrelay
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFunctionRefResolution(t *testing.T) {
+	const functionID = "5c3a7c4b-0b8e-4c8a-9f0f-2a1b3c4d5e6f"
+
+	listCalls := 0
+	var paths []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodGet && r.URL.Path == "/functions" {
+			listCalls++
+			_ = json.NewEncoder(w).Encode([]map[string]interface{}{
+				{"id": functionID, "name": "rewrite-payload"},
+			})
+			return
+		}
+		paths = append(paths, r.Method+" "+r.URL.EscapedPath())
+		switch r.URL.Path {
+		case "/functions/" + functionID + "/config":
+			if r.Method == http.MethodGet {
+				_, _ = w.Write([]byte(`{"variables": []}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"key": "token", "value": "xyz"}`))
+		default:
+			_, _ = w.Write([]byte(`{}`))
+		}
+	}))
+	defer server.Close()
+
+	client, err := New("test-key", "test-secret", WithAPIEndpointURL(server.URL))
+	assert.NoError(t, err)
+
+	_, err = client.SetFunctionConfigurationVariable(&SetFunctionConfigRequest{ID: "rewrite-payload", Key: "token", Value: "xyz"})
+	assert.NoError(t, err)
+
+	_, err = client.ListFunctionConfigurationVariables(&FunctionConfigurationVariablesListOptions{ID: "rewrite-payload"})
+	assert.NoError(t, err)
+
+	err = client.DeleteFunctionConfigurationVariable(&FunctionConfigurationVariableDeleteOptions{ID: "rewrite-payload", Key: "a/b"})
+	assert.NoError(t, err)
+
+	// name lookups are cached between calls
+	assert.Equal(t, 1, listCalls)
+	assert.Equal(t, []string{
+		"PUT /functions/" + functionID + "/config",
+		"GET /functions/" + functionID + "/config",
+		"DELETE /functions/" + functionID + "/config/a%2Fb",
+	}, paths)
+
+	_, err = client.InvokeFunction(&InvokeOpts{ID: "missing"})
+	assert.True(t, IsNotFound(err), "expected not found error, got: %v", err)
+	assert.Equal(t, 2, listCalls)
+}
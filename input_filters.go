@@ -0,0 +1,364 @@
+package webhookrelay
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Keys of webhook sender ranges in published IP range files, pass them to
+// LoadIPRanges
+const (
+	// GitHubIPRangesKey - "hooks" list of https://api.github.com/meta
+	GitHubIPRangesKey = "hooks"
+	// StripeIPRangesKey - "WEBHOOKS" list of https://stripe.com/files/ips/ips_webhooks.json
+	StripeIPRangesKey = "WEBHOOKS"
+)
+
+// ValidateFilters checks the input access filters: allowed IPs and CIDR
+// ranges, HTTP methods, max body size and required header names.
+// Overlapping ranges are valid, they are reported by FilterWarnings.
+func (i *Input) ValidateFilters() error {
+	if err := validateAllowedIPs(i.AllowedIPs); err != nil {
+		return err
+	}
+	if err := validateAllowedMethods(i.AllowedMethods); err != nil {
+		return err
+	}
+	if err := validateMaxBodySize(i.MaxBodySize); err != nil {
+		return err
+	}
+	return validateRequiredHeaders(i.RequiredHeaders)
+}
+
+// validateFilters checks only the filters changed by the patch
+func (p *InputPatch) validateFilters() error {
+	if p.AllowedIPs != nil {
+		if err := validateAllowedIPs(p.AllowedIPs); err != nil {
+			return err
+		}
+	}
+	if p.AllowedMethods != nil {
+		if err := validateAllowedMethods(p.AllowedMethods); err != nil {
+			return err
+		}
+	}
+	if p.MaxBodySize != nil {
+		if err := validateMaxBodySize(*p.MaxBodySize); err != nil {
+			return err
+		}
+	}
+	if p.RequiredHeaders != nil {
+		return validateRequiredHeaders(p.RequiredHeaders)
+	}
+	return nil
+}
+
+func validateAllowedIPs(ips []string) error {
+	_, err := ParseCIDRs(ips)
+	return err
+}
+
+func validateAllowedMethods(methods []string) error {
+	for _, m := range methods {
+		if !isToken(m) || strings.ToUpper(m) != m {
+			return fmt.Errorf("allowed methods: invalid method '%s'", m)
+		}
+	}
+	return nil
+}
+
+func validateMaxBodySize(size int64) error {
+	if size < 0 {
+		return fmt.Errorf("max body size must not be negative")
+	}
+	return nil
+}
+
+func validateRequiredHeaders(headers []string) error {
+	for _, h := range headers {
+		if !isToken(h) {
+			return fmt.Errorf("required headers: invalid header name '%s'", h)
+		}
+	}
+	return nil
+}
+
+// FilterWarnings reports filters that are valid but likely unintended, such
+// as allowed IP ranges covered by other ranges. CompactCIDRs removes them.
+func (i *Input) FilterWarnings() []string {
+	nets, err := ParseCIDRs(i.AllowedIPs)
+	if err != nil {
+		return nil
+	}
+	var warnings []string
+	for _, overlap := range OverlappingCIDRs(nets) {
+		warnings = append(warnings, fmt.Sprintf("allowed IPs: %s overlaps with %s", overlap[0], overlap[1]))
+	}
+	return warnings
+}
+
+// AllowsRequest checks a request against the input filters the same way
+// the server does. ip is the source address, bodySize the length of the
+// request body.
+func (i *Input) AllowsRequest(ip string, method string, header http.Header, bodySize int64) error {
+	if len(i.AllowedIPs) > 0 && !i.AllowsIP(ip) {
+		return fmt.Errorf("source IP '%s' is not allowed", ip)
+	}
+	if len(i.AllowedMethods) > 0 {
+		allowed := false
+		for _, m := range i.AllowedMethods {
+			if m == method {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return fmt.Errorf("method %s is not allowed", method)
+		}
+	}
+	if i.MaxBodySize > 0 && bodySize > i.MaxBodySize {
+		return fmt.Errorf("body size %d exceeds %d bytes", bodySize, i.MaxBodySize)
+	}
+	for _, h := range i.RequiredHeaders {
+		if header.Get(h) == "" {
+			return fmt.Errorf("required header '%s' is missing", h)
+		}
+	}
+	return nil
+}
+
+// AllowsIP reports whether the address is in the allowlist, an empty
+// allowlist allows everything
+func (i *Input) AllowsIP(ip string) bool {
+	if len(i.AllowedIPs) == 0 {
+		return true
+	}
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return false
+	}
+	nets, err := ParseCIDRs(i.AllowedIPs)
+	if err != nil {
+		return false
+	}
+	for _, n := range nets {
+		if n.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowIPRanges adds ranges to the allowlist, ranges already covered by the
+// allowlist are skipped and existing entries covered by the new ranges are
+// removed
+func (i *Input) AllowIPRanges(ranges []string) error {
+	if _, err := ParseCIDRs(ranges); err != nil {
+		return err
+	}
+	compacted, err := CompactCIDRs(append(append([]string{}, i.AllowedIPs...), ranges...))
+	if err != nil {
+		return err
+	}
+	i.AllowedIPs = compacted
+	return nil
+}
+
+// ParseCIDRs parses CIDR ranges, plain IP addresses are treated as single
+// address ranges
+func ParseCIDRs(ranges []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(ranges))
+	for _, r := range ranges {
+		n, err := parseCIDR(r)
+		if err != nil {
+			return nil, err
+		}
+		nets = append(nets, n)
+	}
+	return nets, nil
+}
+
+func parseCIDR(r string) (*net.IPNet, error) {
+	r = strings.TrimSpace(r)
+	if !strings.Contains(r, "/") {
+		ip := net.ParseIP(r)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid IP address '%s'", r)
+		}
+		if v4 := ip.To4(); v4 != nil {
+			return &net.IPNet{IP: v4, Mask: net.CIDRMask(32, 32)}, nil
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}, nil
+	}
+	ip, n, err := net.ParseCIDR(r)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CIDR range '%s'", r)
+	}
+	if !ip.Equal(n.IP) {
+		return nil, fmt.Errorf("CIDR range '%s' has host bits set, use '%s'", r, n)
+	}
+	return n, nil
+}
+
+// OverlappingCIDRs returns pairs of ranges that overlap. CIDR ranges either
+// contain each other or are disjoint, the first range of a pair is the
+// larger one.
+func OverlappingCIDRs(nets []*net.IPNet) [][2]string {
+	var overlaps [][2]string
+	for a := 0; a < len(nets); a++ {
+		for b := a + 1; b < len(nets); b++ {
+			switch {
+			case cidrContains(nets[a], nets[b]):
+				overlaps = append(overlaps, [2]string{nets[a].String(), nets[b].String()})
+			case cidrContains(nets[b], nets[a]):
+				overlaps = append(overlaps, [2]string{nets[b].String(), nets[a].String()})
+			}
+		}
+	}
+	return overlaps
+}
+
+// CompactCIDRs removes duplicate ranges and ranges contained in others,
+// the result is sorted
+func CompactCIDRs(ranges []string) ([]string, error) {
+	nets, err := ParseCIDRs(ranges)
+	if err != nil {
+		return nil, err
+	}
+
+	var kept []*net.IPNet
+	for idx, n := range nets {
+		covered := false
+		for other, o := range nets {
+			if idx == other || !cidrContains(o, n) {
+				continue
+			}
+			// of two identical ranges keep the first
+			if !cidrContains(n, o) || other < idx {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			kept = append(kept, n)
+		}
+	}
+
+	sort.Slice(kept, func(a, b int) bool {
+		if c := bytes.Compare(kept[a].IP.To16(), kept[b].IP.To16()); c != 0 {
+			return c < 0
+		}
+		onesA, _ := kept[a].Mask.Size()
+		onesB, _ := kept[b].Mask.Size()
+		return onesA < onesB
+	})
+
+	result := make([]string, len(kept))
+	for idx, n := range kept {
+		result[idx] = singleIP(n)
+	}
+	return result, nil
+}
+
+// cidrContains reports whether outer contains the whole inner range
+func cidrContains(outer, inner *net.IPNet) bool {
+	outerOnes, outerBits := outer.Mask.Size()
+	innerOnes, innerBits := inner.Mask.Size()
+	return outerBits == innerBits && outerOnes <= innerOnes && outer.Contains(inner.IP)
+}
+
+// singleIP formats single address ranges as plain IPs
+func singleIP(n *net.IPNet) string {
+	if ones, bits := n.Mask.Size(); ones == bits {
+		return n.IP.String()
+	}
+	return n.String()
+}
+
+// LoadIPRanges reads published IP ranges from a local file. JSON files
+// (GitHub meta, Stripe ips_webhooks.json) need the key of the list, e.g.
+// GitHubIPRangesKey, plain text files have one range per line and key is
+// ignored. Ranges are validated.
+func LoadIPRanges(path, key string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var ranges []string
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		var lists map[string]json.RawMessage
+		if err := json.Unmarshal(trimmed, &lists); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		if key == "" {
+			return nil, fmt.Errorf("%s is a JSON file, key of the IP list is required", path)
+		}
+		list, ok := lists[key]
+		if !ok {
+			return nil, fmt.Errorf("%s has no '%s' list", path, key)
+		}
+		if err := json.Unmarshal(list, &ranges); err != nil {
+			return nil, fmt.Errorf("failed to parse '%s' list of %s: %w", key, path, err)
+		}
+	} else {
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			ranges = append(ranges, line)
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := ParseCIDRs(ranges); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return ranges, nil
+}
+
+// AllowInputIPRanges adds ranges, e.g. from LoadIPRanges, to the input
+// allowlist
+func (api *API) AllowInputIPRanges(bucketRef, inputRef string, ranges []string) (*Input, error) {
+	bucket, err := api.GetBucket(bucketRef)
+	if err != nil {
+		return nil, err
+	}
+	inputID, err := api.ensureInputID(bucket.ID, inputRef)
+	if err != nil {
+		return nil, err
+	}
+	input := findInputByID(bucket, inputID)
+	if input == nil {
+		return nil, &NotFoundError{Resource: string(ResourceInput), Ref: inputRef}
+	}
+
+	if err := input.AllowIPRanges(ranges); err != nil {
+		return nil, err
+	}
+	return api.PatchInput(bucket.ID, input.ID, &InputPatch{AllowedIPs: input.AllowedIPs}, &PatchOptions{UpdatedAt: input.UpdatedAt})
+}
+
+// isToken reports whether s is a valid HTTP token (method or header name)
+func isToken(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c > 127 || c <= ' ' || strings.ContainsRune("()<>@,;:\\\"/[]?={}", c) {
+			return false
+		}
+	}
+	return true
+}
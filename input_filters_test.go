@@ -0,0 +1,248 @@
+package webhookrelay
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInputValidateFilters(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   Input
+		wantErr string
+	}{
+		{
+			name: "valid",
+			input: Input{
+				AllowedIPs:      []string{"192.30.252.0/22", "2a0a:a440::/29", "10.1.2.3"},
+				AllowedMethods:  []string{"POST", "PUT"},
+				MaxBodySize:     1 << 20,
+				RequiredHeaders: []string{"X-Hub-Signature-256"},
+			},
+		},
+		{
+			name:    "invalid CIDR",
+			input:   Input{AllowedIPs: []string{"192.30.252.0/33"}},
+			wantErr: "invalid CIDR range '192.30.252.0/33'",
+		},
+		{
+			name:    "host bits",
+			input:   Input{AllowedIPs: []string{"192.30.252.1/22"}},
+			wantErr: "CIDR range '192.30.252.1/22' has host bits set, use '192.30.252.0/22'",
+		},
+		{
+			name:    "invalid IP",
+			input:   Input{AllowedIPs: []string{"github.com"}},
+			wantErr: "invalid IP address 'github.com'",
+		},
+		{
+			name:  "overlap",
+			input: Input{AllowedIPs: []string{"192.30.253.7", "192.30.252.0/22"}},
+		},
+		{
+			name:    "lowercase method",
+			input:   Input{AllowedMethods: []string{"post"}},
+			wantErr: "allowed methods: invalid method 'post'",
+		},
+		{
+			name:    "negative body size",
+			input:   Input{MaxBodySize: -1},
+			wantErr: "max body size must not be negative",
+		},
+		{
+			name:    "header name",
+			input:   Input{RequiredHeaders: []string{"X Signature"}},
+			wantErr: "required headers: invalid header name 'X Signature'",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.input.ValidateFilters()
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestInputFilterWarnings(t *testing.T) {
+	input := &Input{AllowedIPs: []string{"192.30.253.7", "192.30.252.0/22", "10.0.0.1"}}
+	assert.Equal(t, []string{"allowed IPs: 192.30.252.0/22 overlaps with 192.30.253.7/32"}, input.FilterWarnings())
+
+	compacted, err := CompactCIDRs(input.AllowedIPs)
+	require.NoError(t, err)
+	input.AllowedIPs = compacted
+	assert.Empty(t, input.FilterWarnings())
+}
+
+func TestInputAllowsRequest(t *testing.T) {
+	input := &Input{
+		AllowedIPs:      []string{"192.30.252.0/22", "2a0a:a440::/29"},
+		AllowedMethods:  []string{"POST"},
+		MaxBodySize:     100,
+		RequiredHeaders: []string{"X-Hub-Signature-256"},
+	}
+	header := http.Header{"X-Hub-Signature-256": []string{"sha256=abc"}}
+
+	assert.NoError(t, input.AllowsRequest("192.30.255.254", "POST", header, 10))
+	assert.NoError(t, input.AllowsRequest("2a0a:a440::1", "POST", header, 10))
+	assert.EqualError(t, input.AllowsRequest("192.30.256.1", "POST", header, 10), "source IP '192.30.256.1' is not allowed")
+	assert.EqualError(t, input.AllowsRequest("10.0.0.1", "POST", header, 10), "source IP '10.0.0.1' is not allowed")
+	assert.EqualError(t, input.AllowsRequest("192.30.252.1", "GET", header, 10), "method GET is not allowed")
+	assert.EqualError(t, input.AllowsRequest("192.30.252.1", "POST", header, 101), "body size 101 exceeds 100 bytes")
+	assert.EqualError(t, input.AllowsRequest("192.30.252.1", "POST", http.Header{}, 10), "required header 'X-Hub-Signature-256' is missing")
+
+	assert.NoError(t, (&Input{}).AllowsRequest("10.0.0.1", "DELETE", http.Header{}, 1<<30))
+}
+
+func TestCompactCIDRs(t *testing.T) {
+	compacted, err := CompactCIDRs([]string{"10.0.1.0/24", "192.30.252.0/22", "10.0.0.0/16", "192.30.252.0/22", "10.0.0.5", "::1"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"::1", "10.0.0.0/16", "192.30.252.0/22"}, compacted)
+
+	input := &Input{AllowedIPs: []string{"1.2.3.4", "185.199.108.0/24"}}
+	require.NoError(t, input.AllowIPRanges([]string{"185.199.108.0/22"}))
+	assert.Equal(t, []string{"1.2.3.4", "185.199.108.0/22"}, input.AllowedIPs)
+	assert.NoError(t, input.ValidateFilters())
+}
+
+func TestLoadIPRanges(t *testing.T) {
+	dir := t.TempDir()
+
+	github := filepath.Join(dir, "meta.json")
+	require.NoError(t, os.WriteFile(github, []byte(`{
+		"verifiable_password_authentication": false,
+		"hooks": ["192.30.252.0/22", "185.199.108.0/22", "2a0a:a440::/29"],
+		"web": ["192.30.252.0/22"]
+	}`), 0600))
+	ranges, err := LoadIPRanges(github, GitHubIPRangesKey)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"192.30.252.0/22", "185.199.108.0/22", "2a0a:a440::/29"}, ranges)
+
+	_, err = LoadIPRanges(github, StripeIPRangesKey)
+	assert.EqualError(t, err, github+" has no 'WEBHOOKS' list")
+	_, err = LoadIPRanges(github, "")
+	assert.EqualError(t, err, github+" is a JSON file, key of the IP list is required")
+
+	stripe := filepath.Join(dir, "ips_webhooks.txt")
+	require.NoError(t, os.WriteFile(stripe, []byte("3.18.12.63\n3.130.192.231\n\n# comment\n13.235.14.237\n"), 0600))
+	ranges, err = LoadIPRanges(stripe, "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"3.18.12.63", "3.130.192.231", "13.235.14.237"}, ranges)
+
+	bad := filepath.Join(dir, "bad.txt")
+	require.NoError(t, os.WriteFile(bad, []byte("3.18.12.63\nnot-an-ip\n"), 0600))
+	_, err = LoadIPRanges(bad, "")
+	assert.EqualError(t, err, bad+": invalid IP address 'not-an-ip'")
+}
+
+const (
+	filterBucket = "00000000-0000-0000-0000-0000000000f1"
+	filterInput  = "00000000-0000-0000-0000-0000000000f2"
+)
+
+func TestAllowInputIPRanges(t *testing.T) {
+	var updated Input
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Method {
+		case http.MethodGet:
+			_, _ = w.Write([]byte(`{"id":"` + filterBucket + `","name":"stripe","updated_at":100,
+				"inputs":[{"id":"` + filterInput + `","name":"in","updated_at":100,"allowed_ips":["3.18.12.63"]}]}`))
+		case http.MethodPut:
+			assert.Equal(t, "/buckets/"+filterBucket+"/inputs/"+filterInput, r.URL.Path)
+			if err := json.NewDecoder(r.Body).Decode(&updated); err != nil {
+				t.Error(err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(&updated)
+		}
+	}))
+	defer server.Close()
+
+	client, err := New("test-key", "test-secret", WithAPIEndpointURL(server.URL))
+	require.NoError(t, err)
+
+	input, err := client.AllowInputIPRanges(filterBucket, filterInput, []string{"3.18.12.63", "13.235.14.237"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"3.18.12.63", "13.235.14.237"}, input.AllowedIPs)
+	assert.Equal(t, input.AllowedIPs, updated.AllowedIPs)
+
+	_, err = client.AllowInputIPRanges(filterBucket, filterInput, []string{"3.18.12.630"})
+	assert.EqualError(t, err, "invalid IP address '3.18.12.630'")
+}
+
+func TestPatchInput_Filters(t *testing.T) {
+	var puts int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Method {
+		case http.MethodGet:
+			// stored before the client validated methods
+			_, _ = w.Write([]byte(`{"id":"` + filterBucket + `","name":"stripe",
+				"inputs":[{"id":"` + filterInput + `","name":"in","allowed_methods":["post"]}]}`))
+		case http.MethodPut:
+			puts++
+			_, _ = w.Write([]byte(`{}`))
+		}
+	}))
+	defer server.Close()
+
+	client, err := New("test-key", "test-secret", WithAPIEndpointURL(server.URL))
+	require.NoError(t, err)
+
+	_, err = client.PatchInput(filterBucket, filterInput, &InputPatch{AllowedIPs: []string{"3.18.12.0/24", "3.18.12.63"}}, nil)
+	require.NoError(t, err, "unchanged filters and overlaps are not errors")
+	assert.Equal(t, 1, puts)
+
+	_, err = client.PatchInput(filterBucket, filterInput, &InputPatch{AllowedMethods: []string{"get"}}, nil)
+	assert.EqualError(t, err, "allowed methods: invalid method 'get'")
+	assert.Equal(t, 1, puts)
+}
+
+func TestPatchInput_ClearFilters(t *testing.T) {
+	var put map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Method {
+		case http.MethodGet:
+			_, _ = w.Write([]byte(`{"id":"` + filterBucket + `","name":"stripe",
+				"inputs":[{"id":"` + filterInput + `","name":"in","allowed_ips":["3.18.12.63"],
+				"allowed_methods":["POST"],"max_body_size":1024,"required_headers":["Stripe-Signature"]}]}`))
+		case http.MethodPut:
+			put = nil
+			if err := json.NewDecoder(r.Body).Decode(&put); err != nil {
+				t.Error(err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			_, _ = w.Write([]byte(`{}`))
+		}
+	}))
+	defer server.Close()
+
+	client, err := New("test-key", "test-secret", WithAPIEndpointURL(server.URL))
+	require.NoError(t, err)
+
+	_, err = client.PatchInput(filterBucket, filterInput, &InputPatch{
+		AllowedIPs:      []string{},
+		AllowedMethods:  []string{},
+		MaxBodySize:     Int64(0),
+		RequiredHeaders: []string{},
+	}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{}, put["allowed_ips"])
+	assert.Equal(t, []interface{}{}, put["allowed_methods"])
+	assert.Equal(t, float64(0), put["max_body_size"])
+	assert.Equal(t, []interface{}{}, put["required_headers"])
+}
@@ -0,0 +1,248 @@
+package webhookrelay
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Content types set by the response builder
+const (
+	ContentTypeJSON = "application/json"
+	ContentTypeXML  = "text/xml; charset=utf-8"
+	ContentTypeText = "text/plain; charset=utf-8"
+)
+
+// InputResponse is what an input returns to the webhook sender, either a
+// static response or the response of an output
+type InputResponse struct {
+	StatusCode         int
+	Headers            map[string][]string
+	Body               string
+	ResponseFromOutput string
+}
+
+// Validate checks the response. When bucket is set, ResponseFromOutput must
+// refer to one of its outputs.
+func (r *InputResponse) Validate(bucket *Bucket) error {
+	if r.ResponseFromOutput != "" {
+		if r.Body != "" || r.StatusCode != 0 || len(r.Headers) > 0 {
+			return fmt.Errorf("static status code, headers and body can't be combined with a response from output")
+		}
+		if r.ResponseFromOutput == AnyResponseFromOutput || bucket == nil {
+			return nil
+		}
+		if findOutputByID(bucket, r.ResponseFromOutput) == nil {
+			return fmt.Errorf("output '%s' does not belong to bucket '%s'", r.ResponseFromOutput, bucket.Name)
+		}
+		return nil
+	}
+
+	if r.StatusCode != 0 && (r.StatusCode < 100 || r.StatusCode > 599) {
+		return fmt.Errorf("invalid status code %d", r.StatusCode)
+	}
+	if r.Body != "" && (r.StatusCode == http.StatusNoContent || r.StatusCode == http.StatusNotModified) {
+		return fmt.Errorf("status code %d must not have a body", r.StatusCode)
+	}
+	contentType := http.Header(r.Headers).Get("Content-Type")
+	if r.Body != "" && strings.HasPrefix(contentType, ContentTypeJSON) && !json.Valid([]byte(r.Body)) {
+		return fmt.Errorf("body is not valid JSON")
+	}
+	return nil
+}
+
+// ApplyTo sets the response on the input
+func (r *InputResponse) ApplyTo(input *Input) {
+	input.StatusCode = r.StatusCode
+	input.Headers = r.Headers
+	input.Body = r.Body
+	input.ResponseFromOutput = r.ResponseFromOutput
+}
+
+// InputResponseBuilder builds input responses, errors are reported by Build
+type InputResponseBuilder struct {
+	resp InputResponse
+	err  error
+}
+
+// NewInputResponse starts a static 200 OK response
+func NewInputResponse() *InputResponseBuilder {
+	return &InputResponseBuilder{resp: InputResponse{StatusCode: http.StatusOK}}
+}
+
+// Status sets the status code
+func (b *InputResponseBuilder) Status(code int) *InputResponseBuilder {
+	b.resp.StatusCode = code
+	return b
+}
+
+// Header adds a response header
+func (b *InputResponseBuilder) Header(key, value string) *InputResponseBuilder {
+	if b.resp.Headers == nil {
+		b.resp.Headers = make(map[string][]string)
+	}
+	http.Header(b.resp.Headers).Add(key, value)
+	return b
+}
+
+// ContentType sets the Content-Type header, it's detected from the body
+// when not set
+func (b *InputResponseBuilder) ContentType(contentType string) *InputResponseBuilder {
+	if b.resp.Headers == nil {
+		b.resp.Headers = make(map[string][]string)
+	}
+	http.Header(b.resp.Headers).Set("Content-Type", contentType)
+	return b
+}
+
+// Text sets a plain text body
+func (b *InputResponseBuilder) Text(body string) *InputResponseBuilder {
+	b.resp.Body = body
+	return b.ContentType(ContentTypeText)
+}
+
+// JSON sets the body to v encoded as JSON
+func (b *InputResponseBuilder) JSON(v interface{}) *InputResponseBuilder {
+	body, err := json.Marshal(v)
+	if err != nil {
+		b.err = fmt.Errorf("failed to encode JSON body: %w", err)
+		return b
+	}
+	b.resp.Body = string(body)
+	return b.ContentType(ContentTypeJSON)
+}
+
+// XML sets an XML body
+func (b *InputResponseBuilder) XML(body string) *InputResponseBuilder {
+	b.resp.Body = body
+	return b.ContentType(ContentTypeXML)
+}
+
+// Body sets the body as is
+func (b *InputResponseBuilder) Body(body string) *InputResponseBuilder {
+	b.resp.Body = body
+	return b
+}
+
+// FromOutput returns the response of the output instead of a static one
+func (b *InputResponseBuilder) FromOutput(outputID string) *InputResponseBuilder {
+	b.resp.ResponseFromOutput = outputID
+	b.resp.StatusCode = 0
+	return b
+}
+
+// FromAnyOutput returns the first response of any output
+func (b *InputResponseBuilder) FromAnyOutput() *InputResponseBuilder {
+	return b.FromOutput(AnyResponseFromOutput)
+}
+
+// Build validates the response without checking output references, use
+// BuildFor to check them as well
+func (b *InputResponseBuilder) Build() (*InputResponse, error) {
+	return b.BuildFor(nil)
+}
+
+// BuildFor validates the response for an input of the bucket
+func (b *InputResponseBuilder) BuildFor(bucket *Bucket) (*InputResponse, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	resp := b.resp
+	if resp.Body != "" && http.Header(resp.Headers).Get("Content-Type") == "" {
+		resp.Headers = copyHeaders(resp.Headers)
+		http.Header(resp.Headers).Set("Content-Type", detectContentType(resp.Body))
+	}
+	if err := resp.Validate(bucket); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func detectContentType(body string) string {
+	trimmed := strings.TrimSpace(body)
+	switch {
+	case json.Valid([]byte(trimmed)) && (strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[")):
+		return ContentTypeJSON
+	case strings.HasPrefix(trimmed, "<?xml"):
+		return ContentTypeXML
+	}
+	return http.DetectContentType([]byte(body))
+}
+
+func copyHeaders(h map[string][]string) map[string][]string {
+	out := make(map[string][]string, len(h))
+	for k, v := range h {
+		out[k] = append([]string(nil), v...)
+	}
+	return out
+}
+
+// Provider presets
+
+// AcceptedResponse acknowledges the webhook with 202 Accepted and no body,
+// which works for most providers (GitHub, Stripe, Shopify)
+func AcceptedResponse() *InputResponseBuilder {
+	return NewInputResponse().Status(http.StatusAccepted)
+}
+
+// SlackAckResponse acknowledges Slack events and slash commands with an
+// empty 200 OK, Slack retries requests that aren't acknowledged in 3 seconds
+func SlackAckResponse() *InputResponseBuilder {
+	return NewInputResponse()
+}
+
+// SlackURLVerificationResponse responds with the output response. Slack's
+// url_verification challenge differs per request so it can't be answered
+// with a static body, the destination (or an input function) must echo the
+// challenge.
+func SlackURLVerificationResponse(outputID string) *InputResponseBuilder {
+	return NewInputResponse().FromOutput(outputID)
+}
+
+// TwilioEmptyResponse is an empty TwiML document, Twilio takes no action
+func TwilioEmptyResponse() *InputResponseBuilder {
+	return NewInputResponse().XML(xml.Header + "<Response></Response>")
+}
+
+// TwilioMessageResponse replies to an incoming SMS with the message
+func TwilioMessageResponse(message string) *InputResponseBuilder {
+	var escaped strings.Builder
+	if err := xml.EscapeText(&escaped, []byte(message)); err != nil {
+		return &InputResponseBuilder{err: err}
+	}
+	return NewInputResponse().XML(xml.Header + "<Response><Message>" + escaped.String() + "</Message></Response>")
+}
+
+// SetInputResponse validates the response against the input bucket and
+// updates only the response fields of the input
+func (api *API) SetInputResponse(bucketRef, inputRef string, resp *InputResponse) (*Input, error) {
+	bucket, err := api.GetBucket(bucketRef)
+	if err != nil {
+		return nil, err
+	}
+	if resp.ResponseFromOutput != "" && resp.ResponseFromOutput != AnyResponseFromOutput {
+		outputID, err := api.ensureOutputID(bucket.ID, resp.ResponseFromOutput)
+		if err != nil {
+			return nil, err
+		}
+		copied := *resp
+		copied.ResponseFromOutput = outputID
+		resp = &copied
+	}
+	if err := resp.Validate(bucket); err != nil {
+		return nil, err
+	}
+
+	headers := resp.Headers
+	if headers == nil {
+		headers = map[string][]string{}
+	}
+	return api.PatchInput(bucket.ID, inputRef, &InputPatch{
+		StatusCode:         Int(resp.StatusCode),
+		Headers:            headers,
+		Body:               String(resp.Body),
+		ResponseFromOutput: String(resp.ResponseFromOutput),
+	}, nil)
+}
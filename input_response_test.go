@@ -0,0 +1,86 @@
+package webhookrelay
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInputResponseBuilder(t *testing.T) {
+	resp, err := NewInputResponse().JSON(map[string]bool{"ok": true}).Build()
+	require.NoError(t, err)
+	assert.Equal(t, &InputResponse{
+		StatusCode: 200,
+		Headers:    map[string][]string{"Content-Type": {ContentTypeJSON}},
+		Body:       `{"ok":true}`,
+	}, resp)
+
+	resp, err = NewInputResponse().Body(`{"ok": true}`).Build()
+	require.NoError(t, err)
+	assert.Equal(t, []string{ContentTypeJSON}, resp.Headers["Content-Type"], "content type is detected")
+
+	resp, err = TwilioMessageResponse("Thanks <3").Build()
+	require.NoError(t, err)
+	assert.Equal(t, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<Response><Message>Thanks &lt;3</Message></Response>", resp.Body)
+	assert.Equal(t, []string{ContentTypeXML}, resp.Headers["Content-Type"])
+
+	resp, err = SlackURLVerificationResponse(AnyResponseFromOutput).Build()
+	require.NoError(t, err)
+	assert.Equal(t, &InputResponse{ResponseFromOutput: AnyResponseFromOutput}, resp)
+
+	_, err = NewInputResponse().Status(http.StatusNoContent).Text("hi").Build()
+	assert.EqualError(t, err, "status code 204 must not have a body")
+
+	_, err = NewInputResponse().ContentType(ContentTypeJSON).Body("{").Build()
+	assert.EqualError(t, err, "body is not valid JSON")
+
+	_, err = NewInputResponse().FromAnyOutput().Text("hi").Build()
+	assert.Error(t, err, "static body can't be combined with response from output")
+
+	_, err = NewInputResponse().JSON(func() {}).Build()
+	assert.Error(t, err)
+
+	bucket := &Bucket{Name: "one", Outputs: []*Output{{ID: "o-1"}}}
+	_, err = NewInputResponse().FromOutput("o-2").BuildFor(bucket)
+	assert.EqualError(t, err, "output 'o-2' does not belong to bucket 'one'")
+	_, err = NewInputResponse().FromOutput("o-1").BuildFor(bucket)
+	assert.NoError(t, err)
+}
+
+func TestSetInputResponse(t *testing.T) {
+	var put map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodPut {
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&put))
+			_ = json.NewEncoder(w).Encode(put)
+			return
+		}
+		_, _ = w.Write([]byte(`{
+			"id": "` + maintBucket1 + `",
+			"name": "one",
+			"outputs": [{"id": "` + maintOutput + `", "name": "jenkins"}],
+			"inputs": [{"id": "00000000-0000-0000-0000-0000000000c1", "name": "public", "status_code": 200, "body": "ok", "description": "kept"}]
+		}`))
+	}))
+	defer server.Close()
+
+	client, err := New("test-key", "test-secret", WithAPIEndpointURL(server.URL))
+	require.NoError(t, err)
+
+	resp, err := NewInputResponse().FromOutput("jenkins").Build()
+	require.NoError(t, err)
+	input, err := client.SetInputResponse(maintBucket1, "public", resp)
+	require.NoError(t, err)
+	assert.Equal(t, maintOutput, input.ResponseFromOutput, "output name is resolved")
+	assert.Equal(t, 0, input.StatusCode)
+	assert.Equal(t, "", input.Body)
+	assert.Equal(t, "kept", put["description"])
+
+	_, err = client.SetInputResponse(maintBucket1, "public", &InputResponse{ResponseFromOutput: "missing"})
+	assert.True(t, IsNotFound(err))
+}
@@ -35,6 +35,18 @@ type Input struct {
 	CustomDomain       string `json:"custom_domain"`
 	PathPrefix         string `json:"path_prefix"`
 	Description        string `json:"description"`
+
+	// Access filters, requests that don't pass are rejected and logged
+	// with RequestStatusRejected. See ValidateFilters.
+
+	// AllowedIPs - IP addresses and CIDR ranges, empty allows all
+	AllowedIPs []string `json:"allowed_ips"`
+	// AllowedMethods - e.g. "POST", empty allows all
+	AllowedMethods []string `json:"allowed_methods"`
+	// MaxBodySize in bytes, 0 uses the bucket limit
+	MaxBodySize int64 `json:"max_body_size"`
+	// RequiredHeaders - names of headers that must be present
+	RequiredHeaders []string `json:"required_headers"`
 }
 
 func (i *Input) String() string {
@@ -43,6 +55,8 @@ func (i *Input) String() string {
 
 // EndpointURL returns default input URL. If CustomDomain is set (all new inputs from 2020 06 01 are getting them),
 // then it's this domain with path prefix, otherwise it's the URL based on the input ID
+//
+// Deprecated: EndpointURL assumes the hosted service, use API.InputURL.
 func (i *Input) EndpointURL() string {
 	if i.CustomDomain != "" {
 		return "https://" + i.CustomDomain + i.PathPrefix
@@ -134,6 +148,9 @@ func (api *API) allInputList(opts *BucketListOptions) ([]*Input, error) {
 
 // CreateInput creates an Input and returns the new object.
 func (api *API) CreateInput(options *Input) (*Input, error) {
+	if err := options.ValidateFilters(); err != nil {
+		return nil, err
+	}
 	bucketID, err := api.ensureBucketID(options.BucketID)
 	if err != nil {
 		return nil, err
@@ -143,6 +160,7 @@ func (api *API) CreateInput(options *Input) (*Input, error) {
 	if err != nil {
 		return nil, err
 	}
+	api.invalidateRefs(ResourceInput, bucketID)
 
 	var input Input
 	err = json.Unmarshal(resp, &input)
@@ -151,6 +169,9 @@ func (api *API) CreateInput(options *Input) (*Input, error) {
 
 // UpdateInput updates existing input
 func (api *API) UpdateInput(options *Input) (*Input, error) {
+	if err := options.ValidateFilters(); err != nil {
+		return nil, err
+	}
 	if options.BucketID == "" {
 		return nil, fmt.Errorf("bucket not specified")
 	}
@@ -164,7 +185,12 @@ func (api *API) UpdateInput(options *Input) (*Input, error) {
 		return nil, err
 	}
 
-	inputID, err := api.ensureInputID(options.ID)
+	ref := options.ID
+	if ref == "" {
+		ref = options.Name
+	}
+
+	inputID, err := api.ensureInputID(bucketID, ref)
 	if err != nil {
 		return nil, err
 	}
@@ -173,6 +199,7 @@ func (api *API) UpdateInput(options *Input) (*Input, error) {
 	if err != nil {
 		return nil, err
 	}
+	api.invalidateRefs(ResourceInput, bucketID)
 
 	var input Input
 	err = json.Unmarshal(resp, &input)
@@ -202,36 +229,31 @@ func (api *API) DeleteInput(options *InputDeleteOptions) error {
 		return err
 	}
 
-	inputID, err := api.ensureInputID(options.Input)
+	inputID, err := api.ensureInputID(bucketID, options.Input)
 	if err != nil {
 		return err
 	}
 
 	_, err = api.makeRequest("DELETE", "/buckets/"+bucketID+"/inputs/"+inputID, nil)
-	return err
+	if err != nil {
+		return err
+	}
+	api.invalidateRefs(ResourceInput, bucketID)
+	return nil
 }
 
-// ensureInputID - takes name/id and always returns ID (when it not fails)
-func (api *API) ensureInputID(ref string) (string, error) {
-	if !IsUUID(ref) {
-		id, err := api.inputIDFromName(ref)
-		if err != nil {
-			return "", err
-		}
-		return id, nil
-	}
-	return ref, nil
+// ensureInputID - takes name/id and always returns ID (when it not fails).
+// Names are only looked up within the bucket as input names are not unique
+// across the account.
+func (api *API) ensureInputID(bucketID, ref string) (string, error) {
+	return api.resolveRef(ResourceInput, bucketID, ref)
 }
 
-func (api *API) inputIDFromName(name string) (id string, err error) {
-	inputs, err := api.ListInputs(&InputListOptions{})
-	if err != nil {
-		return
-	}
-	for _, b := range inputs {
-		if b.Name == name {
-			return b.ID, nil
+func findInputByID(bucket *Bucket, id string) *Input {
+	for _, i := range bucket.Inputs {
+		if i.ID == id {
+			return i
 		}
 	}
-	return "", ErrNoSuchInput
+	return nil
 }
@@ -0,0 +1,128 @@
+package webhookrelay
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testBucketA = "0a1b2c3d-0000-4000-8000-00000000000a"
+	testBucketB = "0a1b2c3d-0000-4000-8000-00000000000b"
+	testInputA  = "1a1b2c3d-0000-4000-8000-00000000000a"
+	testInputB  = "1a1b2c3d-0000-4000-8000-00000000000b"
+	testInputB2 = "1a1b2c3d-0000-4000-8000-0000000000b2"
+	testInputB3 = "1a1b2c3d-0000-4000-8000-0000000000b3"
+)
+
+// newInputsTestServer serves two buckets that both have an input named
+// "default" and bucket-b additionally has two inputs named "dup"
+func newInputsTestServer(requests *[]string) *httptest.Server {
+	buckets := []map[string]interface{}{
+		{
+			"id":   testBucketA,
+			"name": "bucket-a",
+			"inputs": []map[string]interface{}{
+				{"id": testInputA, "name": "default", "bucket_id": testBucketA},
+			},
+		},
+		{
+			"id":   testBucketB,
+			"name": "bucket-b",
+			"inputs": []map[string]interface{}{
+				{"id": testInputB, "name": "default", "bucket_id": testBucketB},
+				{"id": testInputB2, "name": "dup", "bucket_id": testBucketB},
+				{"id": testInputB3, "name": "dup", "bucket_id": testBucketB},
+			},
+		},
+	}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests = append(*requests, r.Method+" "+r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/buckets":
+			_ = json.NewEncoder(w).Encode(buckets)
+		case r.Method == http.MethodGet:
+			for _, b := range buckets {
+				if r.URL.Path == "/buckets/"+b["id"].(string) {
+					_ = json.NewEncoder(w).Encode(b)
+					return
+				}
+			}
+			w.WriteHeader(http.StatusNotFound)
+		case r.Method == http.MethodDelete:
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			_, _ = w.Write([]byte(`{}`))
+		}
+	}))
+}
+
+func TestDeleteInput_ScopedToBucket(t *testing.T) {
+	var requests []string
+	server := newInputsTestServer(&requests)
+	defer server.Close()
+
+	client, err := New("test-key", "test-secret", WithAPIEndpointURL(server.URL))
+	assert.NoError(t, err)
+
+	err = client.DeleteInput(&InputDeleteOptions{Bucket: "bucket-b", Input: "default"})
+	assert.NoError(t, err)
+
+	err = client.DeleteInput(&InputDeleteOptions{Bucket: "bucket-a", Input: "default"})
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"GET /buckets",
+		"DELETE /buckets/" + testBucketB + "/inputs/" + testInputB,
+		"DELETE /buckets/" + testBucketA + "/inputs/" + testInputA,
+	}, requests)
+}
+
+func TestUpdateInput_ByName(t *testing.T) {
+	var requests []string
+	server := newInputsTestServer(&requests)
+	defer server.Close()
+
+	client, err := New("test-key", "test-secret", WithAPIEndpointURL(server.URL))
+	assert.NoError(t, err)
+
+	_, err = client.UpdateInput(&Input{BucketID: testBucketB, Name: "default", Description: "updated"})
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"GET /buckets/" + testBucketB,
+		"PUT /buckets/" + testBucketB + "/inputs/" + testInputB,
+	}, requests)
+}
+
+func TestDeleteInput_Ambiguous(t *testing.T) {
+	var requests []string
+	server := newInputsTestServer(&requests)
+	defer server.Close()
+
+	client, err := New("test-key", "test-secret", WithAPIEndpointURL(server.URL))
+	assert.NoError(t, err)
+
+	err = client.DeleteInput(&InputDeleteOptions{Bucket: "bucket-b", Input: "dup"})
+	assert.True(t, errors.Is(err, ErrAmbiguousRef), "unexpected error: %v", err)
+
+	var ambiguous *AmbiguousRefError
+	if assert.True(t, errors.As(err, &ambiguous)) {
+		assert.Equal(t, ResourceInput, ambiguous.Resource)
+		assert.Equal(t, []string{testInputB2, testInputB3}, ambiguous.Candidates)
+	}
+
+	err = client.DeleteInput(&InputDeleteOptions{Bucket: "bucket-a", Input: "dup"})
+	assert.True(t, errors.Is(err, ErrNoSuchInput), "unexpected error: %v", err)
+
+	for _, r := range requests {
+		assert.NotContains(t, r, http.MethodDelete)
+	}
+}
@@ -0,0 +1,244 @@
+package webhookrelay
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// MaintenanceWindow lists buckets to suspend and outputs to disable until
+// the window ends
+type MaintenanceWindow struct {
+	Buckets []string            // bucket IDs or names
+	Outputs []MaintenanceOutput // outputs to disable
+	Until   time.Time
+}
+
+// MaintenanceOutput references an output within a bucket
+type MaintenanceOutput struct {
+	Bucket string // bucket ID or name
+	Output string // output ID or name
+}
+
+// MaintenanceState is the persisted state of a running window. Only objects
+// that were active when the window started are recorded so objects that were
+// already suspended or disabled stay that way when it ends.
+type MaintenanceState struct {
+	StartedAt time.Time                `json:"started_at"`
+	Until     time.Time                `json:"until"`
+	Buckets   []string                 `json:"buckets"` // bucket IDs
+	Outputs   []MaintenanceStateOutput `json:"outputs"`
+}
+
+// MaintenanceStateOutput is a disabled output
+type MaintenanceStateOutput struct {
+	BucketID string `json:"bucket_id"`
+	OutputID string `json:"output_id"`
+}
+
+// Maintenance suspends buckets and disables outputs for a window. The state
+// is kept in a local file so an interrupted window is ended by the next
+// process calling Recover or Run.
+type Maintenance struct {
+	api       *API
+	stateFile string
+}
+
+// NewMaintenance creates a maintenance helper that keeps its state in
+// stateFile
+func NewMaintenance(api *API, stateFile string) *Maintenance {
+	return &Maintenance{api: api, stateFile: stateFile}
+}
+
+// State returns the running window or nil
+func (m *Maintenance) State() (*MaintenanceState, error) {
+	data, err := ioutil.ReadFile(m.stateFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var st MaintenanceState
+	if err := json.Unmarshal(data, &st); err != nil {
+		return nil, fmt.Errorf("invalid maintenance state file %s: %w", m.stateFile, err)
+	}
+	return &st, nil
+}
+
+// Start suspends the buckets and disables the outputs. The state is saved
+// before anything is changed, so a crash during Start is recovered as well.
+func (m *Maintenance) Start(window *MaintenanceWindow) (*MaintenanceState, error) {
+	current, err := m.State()
+	if err != nil {
+		return nil, err
+	}
+	if current != nil {
+		return nil, fmt.Errorf("maintenance window until %s is already running", current.Until.Format(time.RFC3339))
+	}
+
+	st := &MaintenanceState{
+		StartedAt: time.Now(),
+		Until:     window.Until,
+	}
+
+	for _, ref := range window.Buckets {
+		bucket, err := m.api.GetBucket(ref)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get bucket '%s': %w", ref, err)
+		}
+		if !bucket.Suspended {
+			st.Buckets = append(st.Buckets, bucket.ID)
+		}
+	}
+	for _, ref := range window.Outputs {
+		bucket, err := m.api.GetBucket(ref.Bucket)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get bucket '%s': %w", ref.Bucket, err)
+		}
+		outputID, err := m.api.ensureOutputID(bucket.ID, ref.Output)
+		if err != nil {
+			return nil, err
+		}
+		output := findOutputByID(bucket, outputID)
+		if output == nil {
+			return nil, &NotFoundError{Resource: string(ResourceOutput), Ref: ref.Output}
+		}
+		if !output.Disabled {
+			st.Outputs = append(st.Outputs, MaintenanceStateOutput{BucketID: bucket.ID, OutputID: output.ID})
+		}
+	}
+
+	if err := m.save(st); err != nil {
+		return nil, err
+	}
+
+	for _, id := range st.Buckets {
+		if _, err := m.api.SuspendBucket(id); err != nil {
+			return st, fmt.Errorf("failed to suspend bucket %s: %w", id, err)
+		}
+	}
+	for _, o := range st.Outputs {
+		if err := m.setOutputDisabled(o, true); err != nil {
+			return st, fmt.Errorf("failed to disable output %s: %w", o.OutputID, err)
+		}
+	}
+	return st, nil
+}
+
+// End resumes buckets and enables outputs recorded by Start and removes the
+// state file. Objects that failed to resume are kept in the state so End
+// can be retried.
+func (m *Maintenance) End() error {
+	st, err := m.State()
+	if err != nil || st == nil {
+		return err
+	}
+
+	var failed []string
+	remaining := *st
+	remaining.Buckets, remaining.Outputs = nil, nil
+
+	for _, id := range st.Buckets {
+		if _, err := m.api.ResumeBucket(id); err != nil && !IsNotFound(err) {
+			failed = append(failed, fmt.Sprintf("bucket %s: %s", id, err))
+			remaining.Buckets = append(remaining.Buckets, id)
+		}
+	}
+	for _, o := range st.Outputs {
+		if err := m.setOutputDisabled(o, false); err != nil && !IsNotFound(err) {
+			failed = append(failed, fmt.Sprintf("output %s: %s", o.OutputID, err))
+			remaining.Outputs = append(remaining.Outputs, o)
+		}
+	}
+
+	if len(failed) > 0 {
+		if err := m.save(&remaining); err != nil {
+			return err
+		}
+		return fmt.Errorf("failed to end maintenance: %s", strings.Join(failed, "; "))
+	}
+	return os.Remove(m.stateFile)
+}
+
+// Recover ends a window that expired while no process was watching it and
+// returns the window that is still running, if any
+func (m *Maintenance) Recover() (*MaintenanceState, error) {
+	st, err := m.State()
+	if err != nil || st == nil {
+		return nil, err
+	}
+	if time.Now().Before(st.Until) {
+		return st, nil
+	}
+	return nil, m.End()
+}
+
+// Run starts the window, or picks up an interrupted one, and ends it when it
+// expires. If ctx is cancelled first the window keeps running and is ended
+// by the next Run or Recover.
+func (m *Maintenance) Run(ctx context.Context, window *MaintenanceWindow) error {
+	st, err := m.Recover()
+	if err != nil {
+		return err
+	}
+	if st == nil {
+		if st, err = m.Start(window); err != nil {
+			if st != nil {
+				// partially started, undo what was changed
+				if endErr := m.End(); endErr != nil {
+					return fmt.Errorf("%w (%s)", err, endErr)
+				}
+			}
+			return err
+		}
+	}
+
+	timer := time.NewTimer(time.Until(st.Until))
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return m.End()
+	}
+}
+
+func (m *Maintenance) setOutputDisabled(o MaintenanceStateOutput, disabled bool) error {
+	_, err := m.api.PatchOutput(o.BucketID, o.OutputID, &OutputPatch{Disabled: Bool(disabled)}, nil)
+	return err
+}
+
+// save writes the state atomically so a crash never leaves a partial file
+func (m *Maintenance) save(st *MaintenanceState) error {
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(m.stateFile), filepath.Base(m.stateFile)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), m.stateFile)
+}
@@ -0,0 +1,156 @@
+package webhookrelay
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	maintBucket1 = "00000000-0000-0000-0000-0000000000b1"
+	maintBucket2 = "00000000-0000-0000-0000-0000000000b2"
+	maintOutput  = "00000000-0000-0000-0000-0000000000a1"
+)
+
+// newMaintenanceTestServer serves two buckets, the second one is already
+// suspended
+func newMaintenanceTestServer(t *testing.T) (*httptest.Server, map[string]*Bucket) {
+	var mu sync.Mutex
+	buckets := map[string]*Bucket{
+		maintBucket1: {ID: maintBucket1, Name: "one", Outputs: []*Output{{ID: maintOutput, Name: "jenkins", BucketID: maintBucket1}}},
+		maintBucket2: {ID: maintBucket2, Name: "two", Suspended: true},
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+
+		parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+		bucket, ok := buckets[parts[1]]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		switch {
+		case r.Method == http.MethodGet && len(parts) == 2:
+			_ = json.NewEncoder(w).Encode(bucket)
+		case r.Method == http.MethodPut && len(parts) == 2:
+			var update Bucket
+			if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+				t.Error(err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			bucket.Suspended = update.Suspended
+			_ = json.NewEncoder(w).Encode(bucket)
+		case r.Method == http.MethodPut && len(parts) == 4:
+			var update Output
+			if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+				t.Error(err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			bucket.Outputs[0].Disabled = update.Disabled
+			_ = json.NewEncoder(w).Encode(bucket.Outputs[0])
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}))
+	return server, buckets
+}
+
+func TestMaintenance(t *testing.T) {
+	server, buckets := newMaintenanceTestServer(t)
+	defer server.Close()
+
+	client, err := New("test-key", "test-secret", WithAPIEndpointURL(server.URL))
+	require.NoError(t, err)
+
+	stateFile := filepath.Join(t.TempDir(), "maintenance.json")
+	m := NewMaintenance(client, stateFile)
+
+	st, err := m.Start(&MaintenanceWindow{
+		Buckets: []string{maintBucket1, maintBucket2},
+		Outputs: []MaintenanceOutput{{Bucket: maintBucket1, Output: maintOutput}},
+		Until:   time.Now().Add(-time.Second),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{maintBucket1}, st.Buckets, "already suspended buckets are not recorded")
+	assert.True(t, buckets[maintBucket1].Suspended)
+	assert.True(t, buckets[maintBucket1].Outputs[0].Disabled)
+
+	_, err = m.Start(&MaintenanceWindow{Until: time.Now().Add(time.Hour)})
+	assert.Error(t, err, "only one window can run at a time")
+
+	// process restarted after the window expired
+	active, err := NewMaintenance(client, stateFile).Recover()
+	require.NoError(t, err)
+	assert.Nil(t, active)
+	assert.False(t, buckets[maintBucket1].Suspended)
+	assert.False(t, buckets[maintBucket1].Outputs[0].Disabled)
+	assert.True(t, buckets[maintBucket2].Suspended, "bucket suspended before the window stays suspended")
+
+	_, err = os.Stat(stateFile)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestMaintenance_ObjectDeleted(t *testing.T) {
+	server, buckets := newMaintenanceTestServer(t)
+	defer server.Close()
+
+	client, err := New("test-key", "test-secret", WithAPIEndpointURL(server.URL))
+	require.NoError(t, err)
+
+	stateFile := filepath.Join(t.TempDir(), "maintenance.json")
+	m := NewMaintenance(client, stateFile)
+	_, err = m.Start(&MaintenanceWindow{
+		Buckets: []string{maintBucket1},
+		Outputs: []MaintenanceOutput{{Bucket: maintBucket1, Output: maintOutput}},
+		Until:   time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+
+	// bucket deleted while maintenance is active
+	delete(buckets, maintBucket1)
+
+	require.NoError(t, m.End())
+	_, err = os.Stat(stateFile)
+	assert.True(t, os.IsNotExist(err), "state is removed when the objects are gone")
+}
+
+func TestMaintenance_RunInterrupted(t *testing.T) {
+	server, buckets := newMaintenanceTestServer(t)
+	defer server.Close()
+
+	client, err := New("test-key", "test-secret", WithAPIEndpointURL(server.URL))
+	require.NoError(t, err)
+
+	stateFile := filepath.Join(t.TempDir(), "maintenance.json")
+	window := &MaintenanceWindow{Buckets: []string{maintBucket1}, Until: time.Now().Add(time.Hour)}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = NewMaintenance(client, stateFile).Run(ctx, window)
+	assert.Equal(t, context.Canceled, err)
+	assert.True(t, buckets[maintBucket1].Suspended, "window keeps running after the process stops")
+
+	active, err := NewMaintenance(client, stateFile).Recover()
+	require.NoError(t, err)
+	require.NotNil(t, active)
+	assert.Equal(t, []string{maintBucket1}, active.Buckets)
+
+	require.NoError(t, NewMaintenance(client, stateFile).End())
+	assert.False(t, buckets[maintBucket1].Suspended)
+}
@@ -25,6 +25,34 @@ func WithAPIEndpointURL(apiBaseURL string) Option {
 	}
 }
 
+// WithWebhooksURL sets the base URL of input endpoints for self-hosted or
+// regional setups where webhooks are not received by the API server.
+// Default: API endpoint URL + "/webhooks"
+func WithWebhooksURL(webhooksURL string) Option {
+	return func(api *API) error {
+		api.WebhooksURL = webhooksURL
+		return nil
+	}
+}
+
+// WithRegions provides region data used to build tunnel URLs instead of
+// fetching it with ListRegions
+func WithRegions(regions []*Region) Option {
+	return func(api *API) error {
+		api.regions = regions
+		return nil
+	}
+}
+
+// WithTunnelRegion sets the region of tunnels created without one. It only
+// affects CreateTunnel, URLs are built from the region of each tunnel.
+func WithTunnelRegion(region string) Option {
+	return func(api *API) error {
+		api.tunnelRegion = region
+		return nil
+	}
+}
+
 // WithHeaders allows you to set custom HTTP headers when making API calls (e.g. for
 // satisfying HTTP proxies, or for debugging).
 func WithHeaders(headers http.Header) Option {
@@ -48,6 +76,24 @@ func WithRetryPolicy(maxRetries int, minRetryDelaySecs int, maxRetryDelaySecs in
 	}
 }
 
+// WithRetryDelays is WithRetryPolicy with delays of any precision. Unlike
+// WithRetryPolicy it rejects negative values and a max delay shorter than
+// the min delay.
+func WithRetryDelays(maxRetries int, minRetryDelay, maxRetryDelay time.Duration) Option {
+	return func(api *API) error {
+		policy := RetryPolicy{
+			MaxRetries:    maxRetries,
+			MinRetryDelay: minRetryDelay,
+			MaxRetryDelay: maxRetryDelay,
+		}
+		if err := policy.validate(); err != nil {
+			return err
+		}
+		api.retryPolicy = policy
+		return nil
+	}
+}
+
 // WithUserAgent can be set if you want to send a software name and version for HTTP access logs.
 // It is recommended to set it in order to help future Customer Support diagnostics
 // and prevent collateral damage by sharing generic User-Agent string with abusive users.
@@ -68,6 +114,34 @@ func WithLogger(logger Logger) Option {
 	}
 }
 
+// WithResolver replaces the default caching Resolver used to turn object
+// names into IDs.
+func WithResolver(resolver Resolver) Option {
+	return func(api *API) error {
+		api.resolver = resolver
+		return nil
+	}
+}
+
+// WithResolverCacheTTL sets how long the default Resolver trusts cached
+// name to ID mappings. Zero disables caching.
+// Default: 30 seconds
+func WithResolverCacheTTL(ttl time.Duration) Option {
+	return func(api *API) error {
+		api.resolverTTL = ttl
+		return nil
+	}
+}
+
+// WithStrictIDs disables name lookups, every reference passed to the client
+// has to be an ID. Names are rejected with ErrNameLookupDisabled.
+func WithStrictIDs() Option {
+	return func(api *API) error {
+		api.strictIDs = true
+		return nil
+	}
+}
+
 // parseOptions parses the supplied options functions and returns a configured
 // *API instance.
 func (api *API) parseOptions(opts ...Option) error {
@@ -0,0 +1,237 @@
+package webhookrelay
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ValidateDestination checks the destination URL. Public outputs are called
+// by Webhook Relay servers, so they can't point to localhost, private
+// networks or hosts without a domain, those need Internal outputs that are
+// delivered by the relay agent.
+func (o *Output) ValidateDestination() error {
+	if o.Destination == "" {
+		return fmt.Errorf("destination must be set")
+	}
+	u, err := url.Parse(o.Destination)
+	if err != nil {
+		return fmt.Errorf("invalid destination: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("destination '%s' must use http or https", o.Destination)
+	}
+	if u.Hostname() == "" {
+		return fmt.Errorf("destination '%s' has no host", o.Destination)
+	}
+	if p := u.Port(); p != "" {
+		port, err := strconv.Atoi(p)
+		if err != nil || port < 1 || port > 65535 {
+			return fmt.Errorf("destination '%s' has invalid port '%s'", o.Destination, p)
+		}
+	}
+	if u.Fragment != "" {
+		return fmt.Errorf("destination '%s' must not have a fragment", o.Destination)
+	}
+	if !o.Internal && isLocalHost(u.Hostname()) {
+		return fmt.Errorf("destination '%s' is not reachable from Webhook Relay servers, use an internal output", o.Destination)
+	}
+	return nil
+}
+
+// isLocalHost reports hosts that only resolve inside a private network
+func isLocalHost(host string) bool {
+	if ip := net.ParseIP(host); ip != nil {
+		return ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsUnspecified()
+	}
+	host = strings.ToLower(host)
+	return !strings.Contains(host, ".") ||
+		host == "localhost" || strings.HasSuffix(host, ".localhost") ||
+		strings.HasSuffix(host, ".local") || strings.HasSuffix(host, ".internal")
+}
+
+// ForwardURL returns where a webhook received with the extra path (the part
+// of the input URL after the endpoint) is sent. With LockPath the
+// destination is used as is, otherwise the extra path is appended.
+func (o *Output) ForwardURL(extraPath string) (*url.URL, error) {
+	u, err := url.Parse(o.Destination)
+	if err != nil {
+		return nil, err
+	}
+	if o.LockPath || extraPath == "" || extraPath == "/" {
+		return u, nil
+	}
+	trailing := strings.HasSuffix(extraPath, "/")
+	u.Path = path.Join("/", u.Path, extraPath)
+	if trailing {
+		u.Path += "/"
+	}
+	return u, nil
+}
+
+// ProbeOptions configure ProbeDestination
+type ProbeOptions struct {
+	// Method defaults to POST
+	Method string
+	// Body defaults to a small JSON document
+	Body []byte
+	// Header is added to the output headers
+	Header http.Header
+	// ExtraPath is appended to the destination unless the output locks the path
+	ExtraPath string
+	// Timeout defaults to the output timeout or 10 seconds
+	Timeout time.Duration
+	// Client defaults to a client that doesn't follow redirects, as
+	// Webhook Relay doesn't follow them either
+	Client *http.Client
+}
+
+// ProbeResult is the outcome of a probe
+type ProbeResult struct {
+	URL        string
+	StatusCode int
+	Latency    time.Duration // time until response headers were received
+	TLS        *ProbeTLS     // nil for plain HTTP
+}
+
+// ProbeTLS describes the destination TLS connection
+type ProbeTLS struct {
+	Version     string
+	CipherSuite string
+	ServerName  string
+	Subject     string
+	Issuer      string
+	NotAfter    time.Time
+}
+
+// Success reports whether the destination accepted the probe
+func (r *ProbeResult) Success() bool {
+	return r.StatusCode >= 200 && r.StatusCode < 300
+}
+
+// ProbeDestination validates the destination and sends a synthetic webhook
+// to it from this machine, with the output headers and the
+// X-Webhookrelay-Probe header set. Internal destinations are only reachable
+// from the network where the relay agent runs.
+func ProbeDestination(ctx context.Context, output *Output, opts *ProbeOptions) (*ProbeResult, error) {
+	if err := output.ValidateDestination(); err != nil {
+		return nil, err
+	}
+	if opts == nil {
+		opts = &ProbeOptions{}
+	}
+
+	target, err := output.ForwardURL(opts.ExtraPath)
+	if err != nil {
+		return nil, err
+	}
+
+	method := opts.Method
+	if method == "" {
+		method = http.MethodPost
+	}
+	body := opts.Body
+	if body == nil {
+		body = []byte(`{"webhookrelay":"probe"}`)
+	}
+	timeout := opts.Timeout
+	if timeout == 0 && output.Timeout > 0 {
+		timeout = time.Duration(output.Timeout) * time.Second
+	}
+	if timeout == 0 {
+		timeout = 10 * time.Second
+	}
+	client := opts.Client
+	if client == nil {
+		client = &http.Client{
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, method, target.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for k, values := range output.Headers {
+		for _, v := range values {
+			req.Header.Add(k, v)
+		}
+	}
+	for k, values := range opts.Header {
+		for _, v := range values {
+			req.Header.Add(k, v)
+		}
+	}
+	if req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("X-Webhookrelay-Probe", "true")
+
+	started := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("probe of '%s' failed: %w", target, err)
+	}
+	defer resp.Body.Close()
+
+	return &ProbeResult{
+		URL:        target.String(),
+		StatusCode: resp.StatusCode,
+		Latency:    time.Since(started),
+		TLS:        probeTLS(resp.TLS),
+	}, nil
+}
+
+func probeTLS(state *tls.ConnectionState) *ProbeTLS {
+	if state == nil {
+		return nil
+	}
+	result := &ProbeTLS{
+		Version:     tlsVersionName(state.Version),
+		CipherSuite: tls.CipherSuiteName(state.CipherSuite),
+		ServerName:  state.ServerName,
+	}
+	if len(state.PeerCertificates) > 0 {
+		cert := state.PeerCertificates[0]
+		result.Subject = certName(cert.Subject.CommonName, cert)
+		result.Issuer = cert.Issuer.CommonName
+		result.NotAfter = cert.NotAfter
+	}
+	return result
+}
+
+func certName(commonName string, cert *x509.Certificate) string {
+	if commonName != "" {
+		return commonName
+	}
+	if len(cert.DNSNames) > 0 {
+		return cert.DNSNames[0]
+	}
+	return cert.Subject.String()
+}
+
+func tlsVersionName(version uint16) string {
+	switch version {
+	case tls.VersionTLS10:
+		return "TLS 1.0"
+	case tls.VersionTLS11:
+		return "TLS 1.1"
+	case tls.VersionTLS12:
+		return "TLS 1.2"
+	case tls.VersionTLS13:
+		return "TLS 1.3"
+	}
+	return fmt.Sprintf("0x%04x", version)
+}
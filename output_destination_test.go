@@ -0,0 +1,124 @@
+package webhookrelay
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutput_ValidateDestination(t *testing.T) {
+	tests := []struct {
+		output Output
+		err    string
+	}{
+		{output: Output{Destination: "https://ci.example.com/github-webhook/"}},
+		{output: Output{Destination: "http://localhost:8080", Internal: true}},
+		{output: Output{Destination: "http://jenkins:8080", Internal: true}},
+		{output: Output{Destination: "http://10.0.0.5", Internal: true}},
+		{output: Output{}, err: "destination must be set"},
+		{output: Output{Destination: "localhost:8080"}, err: "destination 'localhost:8080' must use http or https"},
+		{output: Output{Destination: "ftp://example.com"}, err: "destination 'ftp://example.com' must use http or https"},
+		{output: Output{Destination: "https://"}, err: "destination 'https://' has no host"},
+		{output: Output{Destination: "https://example.com:99999"}, err: "destination 'https://example.com:99999' has invalid port '99999'"},
+		{output: Output{Destination: "https://example.com/#hooks"}, err: "destination 'https://example.com/#hooks' must not have a fragment"},
+		{output: Output{Destination: "http://localhost:8080"}, err: "destination 'http://localhost:8080' is not reachable from Webhook Relay servers, use an internal output"},
+		{output: Output{Destination: "http://192.168.1.10/hooks"}, err: "destination 'http://192.168.1.10/hooks' is not reachable from Webhook Relay servers, use an internal output"},
+		{output: Output{Destination: "http://jenkins:8080"}, err: "destination 'http://jenkins:8080' is not reachable from Webhook Relay servers, use an internal output"},
+	}
+	for _, tt := range tests {
+		err := tt.output.ValidateDestination()
+		if tt.err == "" {
+			assert.NoError(t, err, tt.output.Destination)
+		} else {
+			assert.EqualError(t, err, tt.err)
+		}
+	}
+}
+
+func TestOutput_ForwardURL(t *testing.T) {
+	o := &Output{Destination: "http://localhost:8080/hooks?source=relay"}
+	u, err := o.ForwardURL("/github/push")
+	require.NoError(t, err)
+	assert.Equal(t, "http://localhost:8080/hooks/github/push?source=relay", u.String())
+
+	o.LockPath = true
+	u, err = o.ForwardURL("/github/push")
+	require.NoError(t, err)
+	assert.Equal(t, "http://localhost:8080/hooks?source=relay", u.String())
+}
+
+func TestProbeDestination(t *testing.T) {
+	var received http.Header
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header
+		assert.Equal(t, "/hooks/github", r.URL.Path)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	output := &Output{
+		Destination: server.URL + "/hooks",
+		Internal:    true,
+		Headers:     map[string][]string{"X-Token": {"secret"}},
+	}
+	result, err := ProbeDestination(context.Background(), output, &ProbeOptions{
+		ExtraPath: "/github",
+		Client:    server.Client(),
+	})
+	require.NoError(t, err)
+	assert.True(t, result.Success())
+	assert.Equal(t, http.StatusAccepted, result.StatusCode)
+	assert.True(t, result.Latency > 0)
+	require.NotNil(t, result.TLS)
+	assert.NotEmpty(t, result.TLS.Version)
+	assert.NotEmpty(t, result.TLS.CipherSuite)
+	assert.Equal(t, "secret", received.Get("X-Token"))
+	assert.Equal(t, "true", received.Get("X-Webhookrelay-Probe"))
+
+	// certificate is not trusted by the default client
+	_, err = ProbeDestination(context.Background(), output, nil)
+	assert.Error(t, err)
+
+	output.Internal = false
+	_, err = ProbeDestination(context.Background(), output, nil)
+	assert.Contains(t, err.Error(), "use an internal output", "destination is validated first")
+}
+
+const (
+	destBucket = "00000000-0000-0000-0000-0000000000d1"
+	destOutput = "00000000-0000-0000-0000-0000000000d2"
+)
+
+func TestUpdateOutput_Destination(t *testing.T) {
+	var puts int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Method {
+		case http.MethodGet:
+			_, _ = w.Write([]byte(`{"id": "` + destBucket + `", "name": "ci", "outputs": [{"id": "` + destOutput + `", "name": "jenkins", "destination": "http://jenkins:8080"}]}`))
+		case http.MethodPut:
+			puts++
+			_, _ = w.Write([]byte(`{"id": "` + destOutput + `", "name": "jenkins"}`))
+		}
+	}))
+	defer server.Close()
+
+	client, err := New("test-key", "test-secret", WithAPIEndpointURL(server.URL))
+	require.NoError(t, err)
+
+	// outputs created before the check can still be updated
+	_, err = client.UpdateOutput(&Output{ID: destOutput, BucketID: destBucket, Name: "jenkins", Destination: "http://jenkins:8080", Timeout: 10})
+	require.NoError(t, err)
+	assert.Equal(t, 1, puts)
+
+	_, err = client.UpdateOutput(&Output{ID: destOutput, BucketID: destBucket, Name: "jenkins", Destination: "http://jenkins:9090"})
+	assert.EqualError(t, err, "destination 'http://jenkins:9090' is not reachable from Webhook Relay servers, use an internal output")
+	assert.Equal(t, 1, puts)
+
+	_, err = client.CreateOutput(&Output{BucketID: destBucket, Name: "jenkins", Destination: "http://jenkins:8080"})
+	assert.Error(t, err, "new outputs are always checked")
+}
@@ -0,0 +1,402 @@
+package webhookrelay
+
+import (
+	"container/list"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// RuleField is the part of the webhook a rule looks at
+type RuleField string
+
+// Available rule fields
+const (
+	RuleFieldHeader RuleField = "header" // Key is the header name
+	RuleFieldPath   RuleField = "path"   // extra path after the input endpoint
+	RuleFieldMethod RuleField = "method"
+	RuleFieldBody   RuleField = "body" // Key is a JSONPath, e.g. $.pull_request.state
+)
+
+// RuleOperator compares the field with the rule value
+type RuleOperator string
+
+// Available rule operators
+const (
+	RuleEquals    RuleOperator = "equals"
+	RuleNotEquals RuleOperator = "not_equals"
+	RuleContains  RuleOperator = "contains"
+	RulePrefix    RuleOperator = "prefix"
+	RuleSuffix    RuleOperator = "suffix"
+	RuleRegex     RuleOperator = "regex"
+	RuleExists    RuleOperator = "exists"
+	RuleNotExists RuleOperator = "not_exists"
+)
+
+// Rule match modes, see OutputRules.Mode
+const (
+	RuleMatchAll = "all"
+	RuleMatchAny = "any"
+)
+
+// OutputRules decide which webhooks are forwarded to the output. Outputs
+// without rules receive every webhook.
+type OutputRules struct {
+	// Mode is RuleMatchAll (default) or RuleMatchAny
+	Mode  string       `json:"mode,omitempty"`
+	Rules []OutputRule `json:"rules"`
+}
+
+// OutputRule is a single condition. When the field has several values
+// (repeated headers, JSONPath wildcards) the rule matches if any of them
+// does, not_equals and not_exists match if none does.
+type OutputRule struct {
+	Field    RuleField    `json:"field"`
+	Key      string       `json:"key,omitempty"`
+	Operator RuleOperator `json:"operator"`
+	Value    string       `json:"value,omitempty"`
+}
+
+// WebhookRequest is a webhook that rules are evaluated against
+type WebhookRequest struct {
+	Method    string
+	ExtraPath string
+	Header    http.Header
+	Body      []byte
+}
+
+// WebhookRequestFromLog builds a request from a webhook log
+func WebhookRequestFromLog(l *Log) *WebhookRequest {
+	return &WebhookRequest{
+		Method:    l.Method,
+		ExtraPath: l.ExtraPath,
+		Header:    l.Headers.HTTPHeader(),
+		Body:      []byte(l.Body),
+	}
+}
+
+// Validate checks fields, operators, regular expressions and JSONPaths
+func (r *OutputRules) Validate() error {
+	if r.Mode != "" && r.Mode != RuleMatchAll && r.Mode != RuleMatchAny {
+		return fmt.Errorf("unknown rule match mode '%s'", r.Mode)
+	}
+	for idx, rule := range r.Rules {
+		if err := rule.Validate(); err != nil {
+			return fmt.Errorf("rule %d: %w", idx+1, err)
+		}
+	}
+	return nil
+}
+
+// Validate checks the rule
+func (r *OutputRule) Validate() error {
+	switch r.Field {
+	case RuleFieldHeader:
+		if r.Key == "" {
+			return fmt.Errorf("header rule requires a header name")
+		}
+	case RuleFieldBody:
+		if _, err := parseJSONPath(r.Key); err != nil {
+			return err
+		}
+	case RuleFieldPath, RuleFieldMethod:
+	default:
+		return fmt.Errorf("unknown field '%s'", r.Field)
+	}
+
+	switch r.Operator {
+	case RuleEquals, RuleNotEquals, RuleContains, RulePrefix, RuleSuffix, RuleExists, RuleNotExists:
+	case RuleRegex:
+		if _, err := compileRuleRegexp(r.Value); err != nil {
+			return fmt.Errorf("invalid regex: %w", err)
+		}
+	default:
+		return fmt.Errorf("unknown operator '%s'", r.Operator)
+	}
+	return nil
+}
+
+// Match evaluates the rules against the request
+func (r *OutputRules) Match(req *WebhookRequest) (bool, error) {
+	if r == nil || len(r.Rules) == 0 {
+		return true, nil
+	}
+	if err := r.Validate(); err != nil {
+		return false, err
+	}
+
+	var body interface{}
+	bodyParsed := false
+	matchAny := r.Mode == RuleMatchAny
+
+	for _, rule := range r.Rules {
+		var values []string
+		switch rule.Field {
+		case RuleFieldHeader:
+			values = req.Header.Values(rule.Key)
+		case RuleFieldPath:
+			values = []string{req.ExtraPath}
+		case RuleFieldMethod:
+			values = []string{req.Method}
+		case RuleFieldBody:
+			if !bodyParsed {
+				bodyParsed = true
+				if err := json.Unmarshal(req.Body, &body); err != nil {
+					body = nil // not JSON, body rules see no values
+				}
+			}
+			path, _ := parseJSONPath(rule.Key) // validated above
+			values = path.eval(body)
+		}
+
+		matched := rule.match(values)
+		if matchAny && matched {
+			return true, nil
+		}
+		if !matchAny && !matched {
+			return false, nil
+		}
+	}
+	return !matchAny, nil
+}
+
+func (r *OutputRule) match(values []string) bool {
+	switch r.Operator {
+	case RuleExists:
+		return len(values) > 0
+	case RuleNotExists:
+		return len(values) == 0
+	case RuleNotEquals:
+		for _, v := range values {
+			if r.compare(v) {
+				return false
+			}
+		}
+		return true
+	}
+	for _, v := range values {
+		if r.compare(v) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *OutputRule) compare(v string) bool {
+	switch r.Operator {
+	case RuleEquals, RuleNotEquals:
+		if r.Field == RuleFieldMethod || r.Field == RuleFieldHeader && strings.EqualFold(r.Key, "Content-Type") {
+			return strings.EqualFold(v, r.Value)
+		}
+		return v == r.Value
+	case RuleContains:
+		return strings.Contains(v, r.Value)
+	case RulePrefix:
+		return strings.HasPrefix(v, r.Value)
+	case RuleSuffix:
+		return strings.HasSuffix(v, r.Value)
+	case RuleRegex:
+		re, err := compileRuleRegexp(r.Value) // validated, normally cached
+		return err == nil && re.MatchString(v)
+	}
+	return false
+}
+
+// maxRuleRegexps bounds the compiled pattern cache, least recently used
+// patterns are evicted first
+const maxRuleRegexps = 256
+
+// regexpCache is a small LRU of compiled patterns
+type regexpCache struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List // front is the most recently used pattern
+	entries map[string]*list.Element
+}
+
+type regexpCacheEntry struct {
+	pattern string
+	re      *regexp.Regexp
+}
+
+func newRegexpCache(size int) *regexpCache {
+	return &regexpCache{size: size, order: list.New(), entries: make(map[string]*list.Element)}
+}
+
+func (c *regexpCache) get(pattern string) (*regexp.Regexp, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[pattern]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(el)
+	return el.Value.(*regexpCacheEntry).re, true
+}
+
+func (c *regexpCache) add(pattern string, re *regexp.Regexp) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[pattern]; ok {
+		c.order.MoveToFront(el)
+		return
+	}
+	c.entries[pattern] = c.order.PushFront(&regexpCacheEntry{pattern: pattern, re: re})
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*regexpCacheEntry).pattern)
+	}
+}
+
+// ruleRegexps caches compiled rule patterns, rules are matched against
+// every webhook
+var ruleRegexps = newRegexpCache(maxRuleRegexps)
+
+func compileRuleRegexp(pattern string) (*regexp.Regexp, error) {
+	if re, ok := ruleRegexps.get(pattern); ok {
+		return re, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	ruleRegexps.add(pattern, re)
+	return re, nil
+}
+
+// MatchingOutputs returns the enabled outputs of the bucket that would
+// receive the request
+func MatchingOutputs(bucket *Bucket, req *WebhookRequest) ([]*Output, error) {
+	var matched []*Output
+	for _, o := range bucket.Outputs {
+		if o.Disabled {
+			continue
+		}
+		ok, err := o.Rules.Match(req)
+		if err != nil {
+			return nil, fmt.Errorf("output '%s': %w", o.Name, err)
+		}
+		if ok {
+			matched = append(matched, o)
+		}
+	}
+	return matched, nil
+}
+
+// jsonPath is a parsed subset of JSONPath: $, .field, ['field'], [index]
+// and [*] or .* wildcards
+type jsonPath []jsonPathSegment
+
+type jsonPathSegment struct {
+	key      string
+	index    int
+	isIndex  bool
+	wildcard bool
+}
+
+func parseJSONPath(p string) (jsonPath, error) {
+	if !strings.HasPrefix(p, "$") {
+		return nil, fmt.Errorf("JSONPath '%s' must start with $", p)
+	}
+	var path jsonPath
+	rest := p[1:]
+	for rest != "" {
+		switch {
+		case strings.HasPrefix(rest, "[*]") || strings.HasPrefix(rest, ".*"):
+			path = append(path, jsonPathSegment{wildcard: true})
+			rest = rest[len(".*"):]
+			if strings.HasPrefix(rest, "]") {
+				rest = rest[1:]
+			}
+		case strings.HasPrefix(rest, "['") || strings.HasPrefix(rest, `["`):
+			quote := rest[1:2]
+			end := strings.Index(rest[2:], quote+"]")
+			if end < 0 {
+				return nil, fmt.Errorf("JSONPath '%s': unterminated key", p)
+			}
+			path = append(path, jsonPathSegment{key: rest[2 : 2+end]})
+			rest = rest[2+end+2:]
+		case strings.HasPrefix(rest, "["):
+			end := strings.Index(rest, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("JSONPath '%s': unterminated index", p)
+			}
+			idx, err := strconv.Atoi(rest[1:end])
+			if err != nil || idx < 0 {
+				return nil, fmt.Errorf("JSONPath '%s': invalid index '%s'", p, rest[1:end])
+			}
+			path = append(path, jsonPathSegment{index: idx, isIndex: true})
+			rest = rest[end+1:]
+		case strings.HasPrefix(rest, "."):
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("JSONPath '%s': empty key", p)
+			}
+			path = append(path, jsonPathSegment{key: rest[:end]})
+			rest = rest[end:]
+		default:
+			return nil, fmt.Errorf("JSONPath '%s': unexpected '%s'", p, rest)
+		}
+	}
+	return path, nil
+}
+
+// eval returns the string form of every value the path selects
+func (p jsonPath) eval(doc interface{}) []string {
+	nodes := []interface{}{doc}
+	if doc == nil {
+		return nil
+	}
+	for _, seg := range p {
+		var next []interface{}
+		for _, node := range nodes {
+			switch n := node.(type) {
+			case map[string]interface{}:
+				if seg.wildcard {
+					for _, v := range n {
+						next = append(next, v)
+					}
+				} else if v, ok := n[seg.key]; ok && !seg.isIndex {
+					next = append(next, v)
+				}
+			case []interface{}:
+				if seg.wildcard {
+					next = append(next, n...)
+				} else if seg.isIndex && seg.index < len(n) {
+					next = append(next, n[seg.index])
+				}
+			}
+		}
+		nodes = next
+	}
+
+	values := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		values = append(values, jsonString(node))
+	}
+	return values
+}
+
+func jsonString(v interface{}) string {
+	switch n := v.(type) {
+	case string:
+		return n
+	case float64:
+		return strconv.FormatFloat(n, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(n)
+	case nil:
+		return "null"
+	}
+	b, _ := json.Marshal(v)
+	return string(b)
+}
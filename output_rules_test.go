@@ -0,0 +1,177 @@
+package webhookrelay
+
+import (
+	"net/http"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const pullRequestBody = `{
+	"action": "closed",
+	"number": 42,
+	"pull_request": {"merged": true, "base": {"ref": "main"}},
+	"labels": [{"name": "bug"}, {"name": "deploy"}]
+}`
+
+func githubRequest(event string) *WebhookRequest {
+	return &WebhookRequest{
+		Method:    http.MethodPost,
+		ExtraPath: "/github",
+		Header:    http.Header{"X-Github-Event": {event}, "Content-Type": {"application/json"}},
+		Body:      []byte(pullRequestBody),
+	}
+}
+
+func TestOutputRules_Match(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules *OutputRules
+		match bool
+	}{
+		{name: "no rules", rules: nil, match: true},
+		{
+			name:  "header",
+			rules: &OutputRules{Rules: []OutputRule{{Field: RuleFieldHeader, Key: "X-GitHub-Event", Operator: RuleEquals, Value: "pull_request"}}},
+			match: true,
+		},
+		{
+			name: "all rules must match",
+			rules: &OutputRules{Rules: []OutputRule{
+				{Field: RuleFieldHeader, Key: "X-GitHub-Event", Operator: RuleEquals, Value: "pull_request"},
+				{Field: RuleFieldBody, Key: "$.pull_request.merged", Operator: RuleEquals, Value: "true"},
+				{Field: RuleFieldBody, Key: "$.pull_request.base.ref", Operator: RuleEquals, Value: "develop"},
+			}},
+			match: false,
+		},
+		{
+			name: "any rule matches",
+			rules: &OutputRules{Mode: RuleMatchAny, Rules: []OutputRule{
+				{Field: RuleFieldHeader, Key: "X-GitHub-Event", Operator: RuleEquals, Value: "push"},
+				{Field: RuleFieldMethod, Operator: RuleEquals, Value: "post"},
+			}},
+			match: true,
+		},
+		{
+			name:  "number",
+			rules: &OutputRules{Rules: []OutputRule{{Field: RuleFieldBody, Key: "$['number']", Operator: RuleEquals, Value: "42"}}},
+			match: true,
+		},
+		{
+			name:  "wildcard",
+			rules: &OutputRules{Rules: []OutputRule{{Field: RuleFieldBody, Key: "$.labels[*].name", Operator: RuleEquals, Value: "deploy"}}},
+			match: true,
+		},
+		{
+			name:  "index",
+			rules: &OutputRules{Rules: []OutputRule{{Field: RuleFieldBody, Key: "$.labels[0].name", Operator: RuleEquals, Value: "deploy"}}},
+			match: false,
+		},
+		{
+			name:  "not equals checks every value",
+			rules: &OutputRules{Rules: []OutputRule{{Field: RuleFieldBody, Key: "$.labels[*].name", Operator: RuleNotEquals, Value: "bug"}}},
+			match: false,
+		},
+		{
+			name:  "missing field",
+			rules: &OutputRules{Rules: []OutputRule{{Field: RuleFieldBody, Key: "$.release", Operator: RuleNotExists}}},
+			match: true,
+		},
+		{
+			name:  "path regex",
+			rules: &OutputRules{Rules: []OutputRule{{Field: RuleFieldPath, Operator: RuleRegex, Value: "^/(github|gitlab)$"}}},
+			match: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matched, err := tt.rules.Match(githubRequest("pull_request"))
+			require.NoError(t, err)
+			assert.Equal(t, tt.match, matched)
+		})
+	}
+}
+
+func TestOutputRules_Validate(t *testing.T) {
+	invalid := map[string]OutputRule{
+		"rule 1: header rule requires a header name":                          {Field: RuleFieldHeader, Operator: RuleExists},
+		"rule 1: unknown field 'query'":                                       {Field: "query", Operator: RuleExists},
+		"rule 1: unknown operator 'gt'":                                       {Field: RuleFieldMethod, Operator: "gt"},
+		"rule 1: JSONPath 'action' must start with $":                         {Field: RuleFieldBody, Key: "action", Operator: RuleExists},
+		"rule 1: JSONPath '$.labels[x]': invalid index 'x'":                   {Field: RuleFieldBody, Key: "$.labels[x]", Operator: RuleExists},
+		"rule 1: JSONPath '$['a': unterminated key":                           {Field: RuleFieldBody, Key: "$['a", Operator: RuleExists},
+		"rule 1: invalid regex: error parsing regexp: missing closing ): `(`": {Field: RuleFieldPath, Operator: RuleRegex, Value: "("},
+	}
+	for msg, rule := range invalid {
+		rules := &OutputRules{Rules: []OutputRule{rule}}
+		assert.EqualError(t, rules.Validate(), msg)
+		_, err := rules.Match(githubRequest("push"))
+		assert.Error(t, err)
+	}
+}
+
+func TestMatchingOutputs(t *testing.T) {
+	pullRequests := &OutputRules{Rules: []OutputRule{{Field: RuleFieldHeader, Key: "X-GitHub-Event", Operator: RuleEquals, Value: "pull_request"}}}
+	bucket := &Bucket{Outputs: []*Output{
+		{Name: "audit"},
+		{Name: "ci", Rules: pullRequests},
+		{Name: "deploy", Rules: &OutputRules{Rules: []OutputRule{{Field: RuleFieldHeader, Key: "X-GitHub-Event", Operator: RuleEquals, Value: "push"}}}},
+		{Name: "disabled", Disabled: true},
+	}}
+
+	outputs, err := MatchingOutputs(bucket, githubRequest("pull_request"))
+	require.NoError(t, err)
+	var names []string
+	for _, o := range outputs {
+		names = append(names, o.Name)
+	}
+	assert.Equal(t, []string{"audit", "ci"}, names)
+
+	req := WebhookRequestFromLog(&Log{
+		Method:  http.MethodPost,
+		Headers: Headers{"X-Github-Event": []interface{}{"push"}},
+		Body:    "{}",
+	})
+	outputs, err = MatchingOutputs(bucket, req)
+	require.NoError(t, err)
+	require.Len(t, outputs, 2)
+	assert.Equal(t, "deploy", outputs[1].Name)
+}
+
+func TestHeaders_HTTPHeader(t *testing.T) {
+	header := Headers{
+		"X-Github-Event": []string{"push"},
+		"Accept":         []interface{}{"text/plain", "application/json"},
+		"Content-Length": float64(2),
+		"User-Agent":     "GitHub-Hookshot",
+	}.HTTPHeader()
+	assert.Equal(t, http.Header{
+		"X-Github-Event": {"push"},
+		"Accept":         {"text/plain", "application/json"},
+		"Content-Length": {"2"},
+		"User-Agent":     {"GitHub-Hookshot"},
+	}, header)
+}
+
+func TestOutputRule_RegexCache(t *testing.T) {
+	rule := OutputRule{Field: RuleFieldPath, Operator: RuleRegex, Value: `^/cache/[0-9]+$`}
+	require.NoError(t, rule.Validate())
+	_, cached := ruleRegexps.get(rule.Value)
+	assert.True(t, cached, "compiled when validated")
+	assert.True(t, rule.match([]string{"/cache/42"}))
+	assert.False(t, rule.match([]string{"/cache/x"}))
+
+	cache := newRegexpCache(2)
+	cache.add("a", regexp.MustCompile("a"))
+	cache.add("b", regexp.MustCompile("b"))
+	_, _ = cache.get("a")
+	cache.add("c", regexp.MustCompile("c"))
+	_, ok := cache.get("b")
+	assert.False(t, ok, "least recently used pattern is evicted")
+	_, ok = cache.get("a")
+	assert.True(t, ok)
+	assert.Equal(t, 2, cache.order.Len())
+}
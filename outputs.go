@@ -32,6 +32,11 @@ type Output struct {
 	Internal    bool   `json:"internal"`
 	Timeout     int    `json:"timeout"` // Destination response timeout
 	Description string `json:"description"`
+	// Rules limit which webhooks are forwarded, nil forwards everything
+	Rules *OutputRules `json:"rules,omitempty"`
+	// RetryPolicy and DeadLetter override the bucket policies
+	RetryPolicy *WebhookRetryPolicy `json:"retry_policy,omitempty"`
+	DeadLetter  *DeadLetterPolicy   `json:"dead_letter,omitempty"`
 }
 
 // MarshalJSON helper to change time into unix
@@ -117,6 +122,10 @@ func (api *API) allOutputList(options *BucketListOptions) ([]*Output, error) {
 
 // CreateOutput creates an Output and returns the new object
 func (api *API) CreateOutput(options *Output) (*Output, error) {
+	if err := options.validate(); err != nil {
+		return nil, err
+	}
+
 	bucketID, err := api.ensureBucketID(options.BucketID)
 	if err != nil {
 		return nil, err
@@ -126,14 +135,20 @@ func (api *API) CreateOutput(options *Output) (*Output, error) {
 	if err != nil {
 		return nil, err
 	}
+	api.invalidateRefs(ResourceOutput, bucketID)
 
 	var result Output
 	err = json.Unmarshal(resp, &result)
 	return &result, nil
 }
 
-// UpdateOutput updates output
+// UpdateOutput updates output. The destination is only validated when it
+// or Internal changes, outputs with a destination that doesn't pass
+// ValidateDestination can still be updated otherwise.
 func (api *API) UpdateOutput(options *Output) (*Output, error) {
+	if err := options.validateSettings(); err != nil {
+		return nil, err
+	}
 
 	bucketID, err := api.ensureBucketID(options.BucketID)
 	if err != nil {
@@ -145,10 +160,15 @@ func (api *API) UpdateOutput(options *Output) (*Output, error) {
 		return nil, err
 	}
 
+	if err := api.validateChangedDestination(bucketID, outputID, options); err != nil {
+		return nil, err
+	}
+
 	resp, err := api.makeRequest("PUT", "/buckets/"+bucketID+"/outputs/"+outputID, options)
 	if err != nil {
 		return nil, err
 	}
+	api.invalidateRefs(ResourceOutput, bucketID)
 
 	var output Output
 	err = json.Unmarshal(resp, &output)
@@ -183,31 +203,59 @@ func (api *API) DeleteOutput(options *OutputDeleteOptions) error {
 	}
 
 	_, err = api.makeRequest("DELETE", "/buckets/"+bucketID+"/outputs/"+outputID, nil)
-	return err
+	if err != nil {
+		return err
+	}
+	api.invalidateRefs(ResourceOutput, bucketID)
+	return nil
 }
 
-func (api *API) ensureOutputID(bucket, ref string) (string, error) {
-	if !IsUUID(ref) {
-		id, err := api.outputIDFromName(bucket, ref)
-		if err != nil {
-			return "", err
+// ensureOutputID - takes name/id and always returns ID (when it not fails)
+func (api *API) ensureOutputID(bucketID, ref string) (string, error) {
+	return api.resolveRef(ResourceOutput, bucketID, ref)
+}
+
+func findOutputByID(bucket *Bucket, id string) *Output {
+	for _, o := range bucket.Outputs {
+		if o.ID == id {
+			return o
 		}
-		return id, nil
 	}
-	return ref, nil
+	return nil
 }
 
-func (api *API) outputIDFromName(bucket, name string) (id string, err error) {
-	outputs, err := api.ListOutputs(&OutputListOptions{
-		Bucket: bucket,
-	})
-	if err != nil {
-		return
+func (o *Output) validate() error {
+	if err := o.ValidateDestination(); err != nil {
+		return err
 	}
-	for _, b := range outputs {
-		if b.Name == name {
-			return b.ID, nil
+	return o.validateSettings()
+}
+
+// validateSettings checks everything but the destination
+func (o *Output) validateSettings() error {
+	if o.Rules != nil {
+		if err := o.Rules.Validate(); err != nil {
+			return err
 		}
 	}
-	return "", ErrNoSuchOutput
+	return validateDeliveryPolicies(o.RetryPolicy, o.DeadLetter)
+}
+
+// validateChangedDestination checks the destination of an update unless it
+// keeps the current destination and Internal setting. The current output is
+// only fetched when the check fails.
+func (api *API) validateChangedDestination(bucketID, outputID string, output *Output) error {
+	err := output.ValidateDestination()
+	if err == nil {
+		return nil
+	}
+	bucket, getErr := api.GetBucket(bucketID)
+	if getErr != nil {
+		return getErr
+	}
+	current := findOutputByID(bucket, outputID)
+	if current != nil && current.Destination == output.Destination && current.Internal == output.Internal {
+		return nil
+	}
+	return err
 }
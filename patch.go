@@ -0,0 +1,385 @@
+package webhookrelay
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// Patch types only change fields that are set. Nil pointers and nil header
+// maps keep the current value, use an empty map to remove all headers.
+// Patches are applied with read-modify-write, the write is rejected with
+// ErrPreconditionFailed if the object changed in between.
+
+// PatchOptions control optimistic concurrency of patch operations
+type PatchOptions struct {
+	// UpdatedAt is the UpdatedAt of the object the patch is based on. When
+	// set, the patch fails with a *ConflictError if the object was updated
+	// since.
+	UpdatedAt time.Time
+}
+
+// BucketPatch changes bucket settings
+type BucketPatch struct {
+	Name          *string
+	Description   *string
+	Stream        *bool
+	Ephemeral     *bool
+	Suspended     *bool
+	LargeWebhooks *bool
+	Auth          *BucketAuth
+	RetryPolicy   *WebhookRetryPolicy
+	DeadLetter    *DeadLetterPolicy
+	RateLimit     *BucketRateLimit
+	Quota         *BucketQuota
+}
+
+func (p *BucketPatch) apply(b *Bucket) {
+	patchString(&b.Name, p.Name)
+	patchString(&b.Description, p.Description)
+	patchBool(&b.Stream, p.Stream)
+	patchBool(&b.Ephemeral, p.Ephemeral)
+	patchBool(&b.Suspended, p.Suspended)
+	patchBool(&b.LargeWebhooks, p.LargeWebhooks)
+	if p.Auth != nil {
+		b.Auth = *p.Auth
+	}
+	if p.RetryPolicy != nil {
+		b.RetryPolicy = p.RetryPolicy
+	}
+	if p.DeadLetter != nil {
+		b.DeadLetter = p.DeadLetter
+	}
+	if p.RateLimit != nil {
+		b.RateLimit = p.RateLimit
+	}
+	if p.Quota != nil {
+		b.Quota = p.Quota
+	}
+}
+
+// InputPatch changes input settings
+type InputPatch struct {
+	Name               *string
+	Description        *string
+	FunctionID         *string
+	Headers            map[string][]string
+	StatusCode         *int
+	Body               *string
+	ResponseFromOutput *string
+	CustomDomain       *string
+	PathPrefix         *string
+	// nil slices keep the current filters, use empty slices to remove them
+	AllowedIPs      []string
+	AllowedMethods  []string
+	MaxBodySize     *int64
+	RequiredHeaders []string
+}
+
+func (p *InputPatch) apply(i *Input) {
+	patchString(&i.Name, p.Name)
+	patchString(&i.Description, p.Description)
+	patchString(&i.FunctionID, p.FunctionID)
+	patchHeaders(&i.Headers, p.Headers)
+	patchInt(&i.StatusCode, p.StatusCode)
+	patchString(&i.Body, p.Body)
+	patchString(&i.ResponseFromOutput, p.ResponseFromOutput)
+	patchString(&i.CustomDomain, p.CustomDomain)
+	patchString(&i.PathPrefix, p.PathPrefix)
+	patchStrings(&i.AllowedIPs, p.AllowedIPs)
+	patchStrings(&i.AllowedMethods, p.AllowedMethods)
+	if p.MaxBodySize != nil {
+		i.MaxBodySize = *p.MaxBodySize
+	}
+	patchStrings(&i.RequiredHeaders, p.RequiredHeaders)
+}
+
+// OutputPatch changes output settings
+type OutputPatch struct {
+	Name        *string
+	Description *string
+	FunctionID  *string
+	Headers     map[string][]string
+	Destination *string
+	Disabled    *bool
+	LockPath    *bool
+	Internal    *bool
+	Timeout     *int
+	// Rules replace the output rules, use empty rules to forward everything
+	Rules       *OutputRules
+	RetryPolicy *WebhookRetryPolicy
+	DeadLetter  *DeadLetterPolicy
+}
+
+func (p *OutputPatch) apply(o *Output) {
+	patchString(&o.Name, p.Name)
+	patchString(&o.Description, p.Description)
+	patchString(&o.FunctionID, p.FunctionID)
+	patchHeaders(&o.Headers, p.Headers)
+	patchString(&o.Destination, p.Destination)
+	patchBool(&o.Disabled, p.Disabled)
+	patchBool(&o.LockPath, p.LockPath)
+	patchBool(&o.Internal, p.Internal)
+	patchInt(&o.Timeout, p.Timeout)
+	if p.Rules != nil {
+		o.Rules = p.Rules
+	}
+	if p.RetryPolicy != nil {
+		o.RetryPolicy = p.RetryPolicy
+	}
+	if p.DeadLetter != nil {
+		o.DeadLetter = p.DeadLetter
+	}
+}
+
+// TunnelPatch changes tunnel settings
+type TunnelPatch struct {
+	Name         *string
+	Description  *string
+	Group        *string
+	Region       *string
+	Destination  *string
+	Host         *string
+	Mode         *TunnelMode
+	Protocol     *string
+	Crypto       *string
+	Auth         *TunnelAuth
+	Features     *Features
+	IngressRules *IngressRules
+}
+
+func (p *TunnelPatch) apply(t *Tunnel) {
+	patchString(&t.Name, p.Name)
+	patchString(&t.Description, p.Description)
+	patchString(&t.Group, p.Group)
+	patchString(&t.Region, p.Region)
+	patchString(&t.Destination, p.Destination)
+	patchString(&t.Host, p.Host)
+	if p.Mode != nil {
+		t.Mode = *p.Mode
+	}
+	patchString(&t.Protocol, p.Protocol)
+	patchString(&t.Crypto, p.Crypto)
+	if p.Auth != nil {
+		t.Auth = *p.Auth
+	}
+	if p.Features != nil {
+		t.Features = *p.Features
+	}
+	if p.IngressRules != nil {
+		t.IngressRules = *p.IngressRules
+	}
+}
+
+// PatchBucket changes the bucket fields set in the patch
+func (api *API) PatchBucket(ref string, patch *BucketPatch, opts *PatchOptions) (*Bucket, error) {
+	if patch.Auth != nil {
+		if err := patch.Auth.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	if err := validateDeliveryPolicies(patch.RetryPolicy, patch.DeadLetter); err != nil {
+		return nil, err
+	}
+	if err := validateBucketLimits(patch.RateLimit, patch.Quota); err != nil {
+		return nil, err
+	}
+
+	bucket, err := api.GetBucket(ref)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkUnmodified(ResourceBucket, ref, bucket.UpdatedAt, opts); err != nil {
+		return nil, err
+	}
+
+	updatedAt := bucket.UpdatedAt
+	patch.apply(bucket)
+	bucket.Inputs, bucket.Outputs = nil, nil
+
+	resp, err := api.putUnmodified("/buckets/"+bucket.ID, bucket, updatedAt)
+	if err != nil {
+		return nil, err
+	}
+	api.invalidateRefs(ResourceBucket, "")
+
+	var result Bucket
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// PatchInput changes the input fields set in the patch
+func (api *API) PatchInput(bucketRef, inputRef string, patch *InputPatch, opts *PatchOptions) (*Input, error) {
+	if err := patch.validateFilters(); err != nil {
+		return nil, err
+	}
+	bucket, err := api.GetBucket(bucketRef)
+	if err != nil {
+		return nil, err
+	}
+	inputID, err := api.ensureInputID(bucket.ID, inputRef)
+	if err != nil {
+		return nil, err
+	}
+	input := findInputByID(bucket, inputID)
+	if input == nil {
+		return nil, &NotFoundError{Resource: string(ResourceInput), Ref: inputRef}
+	}
+	if err := checkUnmodified(ResourceInput, inputRef, input.UpdatedAt, opts); err != nil {
+		return nil, err
+	}
+
+	updatedAt := input.UpdatedAt
+	patch.apply(input)
+	input.BucketID = bucket.ID
+
+	resp, err := api.putUnmodified("/buckets/"+bucket.ID+"/inputs/"+input.ID, input, updatedAt)
+	if err != nil {
+		return nil, err
+	}
+	api.invalidateRefs(ResourceInput, bucket.ID)
+
+	var result Input
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// PatchOutput changes the output fields set in the patch
+func (api *API) PatchOutput(bucketRef, outputRef string, patch *OutputPatch, opts *PatchOptions) (*Output, error) {
+	bucket, err := api.GetBucket(bucketRef)
+	if err != nil {
+		return nil, err
+	}
+	outputID, err := api.ensureOutputID(bucket.ID, outputRef)
+	if err != nil {
+		return nil, err
+	}
+	output := findOutputByID(bucket, outputID)
+	if output == nil {
+		return nil, &NotFoundError{Resource: string(ResourceOutput), Ref: outputRef}
+	}
+	if err := checkUnmodified(ResourceOutput, outputRef, output.UpdatedAt, opts); err != nil {
+		return nil, err
+	}
+
+	updatedAt := output.UpdatedAt
+	patch.apply(output)
+	output.BucketID = bucket.ID
+	if patch.Destination != nil || patch.Internal != nil {
+		if err := output.ValidateDestination(); err != nil {
+			return nil, err
+		}
+	}
+	if patch.Rules != nil || patch.RetryPolicy != nil || patch.DeadLetter != nil {
+		if err := output.validateSettings(); err != nil {
+			return nil, err
+		}
+	}
+
+	resp, err := api.putUnmodified("/buckets/"+bucket.ID+"/outputs/"+output.ID, output, updatedAt)
+	if err != nil {
+		return nil, err
+	}
+	api.invalidateRefs(ResourceOutput, bucket.ID)
+
+	var result Output
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// PatchTunnel changes the tunnel fields set in the patch
+func (api *API) PatchTunnel(ref string, patch *TunnelPatch, opts *PatchOptions) (*Tunnel, error) {
+	tunnel, err := api.GetTunnel(ref)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkUnmodified(ResourceTunnel, ref, tunnel.UpdatedAt, opts); err != nil {
+		return nil, err
+	}
+
+	updatedAt := tunnel.UpdatedAt
+	patch.apply(tunnel)
+
+	resp, err := api.putUnmodified("/tunnels/"+tunnel.ID, tunnel, updatedAt)
+	if err != nil {
+		return nil, err
+	}
+	api.invalidateRefs(ResourceTunnel, "")
+
+	var result Tunnel
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Bool returns a pointer to v, for use in patches
+func Bool(v bool) *bool { return &v }
+
+// String returns a pointer to v, for use in patches
+func String(v string) *string { return &v }
+
+// Int returns a pointer to v, for use in patches
+func Int(v int) *int { return &v }
+
+// Int64 returns a pointer to v, for use in patches
+func Int64(v int64) *int64 { return &v }
+
+// checkUnmodified compares with second precision as that's what the API
+// returns
+func checkUnmodified(kind ResourceKind, ref string, updatedAt time.Time, opts *PatchOptions) error {
+	if opts == nil || opts.UpdatedAt.IsZero() {
+		return nil
+	}
+	if opts.UpdatedAt.Unix() != updatedAt.Unix() {
+		return &ConflictError{Resource: string(kind), Ref: ref, Expected: opts.UpdatedAt, UpdatedAt: updatedAt}
+	}
+	return nil
+}
+
+// putUnmodified sends the update with If-Unmodified-Since so the server
+// rejects it if the object changed after it was read
+func (api *API) putUnmodified(uri string, body interface{}, updatedAt time.Time) ([]byte, error) {
+	headers := http.Header{}
+	if !updatedAt.IsZero() {
+		headers.Set("If-Unmodified-Since", updatedAt.UTC().Format(http.TimeFormat))
+	}
+	return api.makeRequestWithHeaders(context.TODO(), http.MethodPut, uri, body, headers)
+}
+
+func patchString(dst *string, v *string) {
+	if v != nil {
+		*dst = *v
+	}
+}
+
+func patchBool(dst *bool, v *bool) {
+	if v != nil {
+		*dst = *v
+	}
+}
+
+func patchInt(dst *int, v *int) {
+	if v != nil {
+		*dst = *v
+	}
+}
+
+func patchStrings(dst *[]string, v []string) {
+	if v != nil {
+		*dst = v
+	}
+}
+
+func patchHeaders(dst *map[string][]string, v map[string][]string) {
+	if v != nil {
+		*dst = v
+	}
+}
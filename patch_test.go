@@ -0,0 +1,90 @@
+package webhookrelay
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	patchBucket = "00000000-0000-0000-0000-0000000000c1"
+	patchOutput = "00000000-0000-0000-0000-0000000000c2"
+)
+
+func TestPatchOutput(t *testing.T) {
+	updatedAt := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	var put map[string]interface{}
+	var ifUnmodifiedSince string
+	modified := false
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Method {
+		case http.MethodGet:
+			_, _ = w.Write([]byte(`{
+				"id": "` + patchBucket + `",
+				"name": "one",
+				"outputs": [{
+					"id": "` + patchOutput + `",
+					"name": "jenkins",
+					"destination": "http://jenkins:8080",
+					"headers": {"X-Token": ["secret"]},
+					"timeout": 30,
+					"updated_at": ` + strconv.FormatInt(updatedAt.Unix(), 10) + `
+				}]
+			}`))
+		case http.MethodPut:
+			ifUnmodifiedSince = r.Header.Get("If-Unmodified-Since")
+			if modified {
+				w.WriteHeader(http.StatusPreconditionFailed)
+				return
+			}
+			put = nil
+			if err := json.NewDecoder(r.Body).Decode(&put); err != nil {
+				t.Error(err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(put)
+		}
+	}))
+	defer server.Close()
+
+	client, err := New("test-key", "test-secret", WithAPIEndpointURL(server.URL))
+	require.NoError(t, err)
+
+	output, err := client.PatchOutput(patchBucket, "jenkins", &OutputPatch{Disabled: Bool(true)}, nil)
+	require.NoError(t, err)
+	assert.True(t, output.Disabled)
+	assert.Equal(t, "Mon, 15 Jan 2024 10:30:00 GMT", ifUnmodifiedSince)
+	assert.Equal(t, true, put["disabled"])
+	assert.Equal(t, "http://jenkins:8080", put["destination"], "unset fields keep the current value")
+	assert.Equal(t, map[string]interface{}{"X-Token": []interface{}{"secret"}}, put["headers"])
+	assert.Equal(t, float64(30), put["timeout"])
+
+	_, err = client.PatchOutput(patchBucket, "jenkins", &OutputPatch{Headers: map[string][]string{}}, &PatchOptions{UpdatedAt: updatedAt})
+	require.NoError(t, err)
+	assert.Empty(t, put["headers"])
+
+	// stale version is detected before writing
+	put = nil
+	_, err = client.PatchOutput(patchBucket, "jenkins", &OutputPatch{Timeout: Int(5)}, &PatchOptions{UpdatedAt: updatedAt.Add(-time.Minute)})
+	var conflict *ConflictError
+	require.True(t, errors.As(err, &conflict))
+	assert.Equal(t, updatedAt.Unix(), conflict.UpdatedAt.Unix())
+	assert.True(t, errors.Is(err, ErrPreconditionFailed))
+	assert.Nil(t, put)
+
+	// modified between read and write
+	modified = true
+	_, err = client.PatchOutput(patchBucket, "jenkins", &OutputPatch{Timeout: Int(5)}, nil)
+	assert.True(t, errors.Is(err, ErrPreconditionFailed))
+	assert.EqualError(t, err, "HTTP status 412: precondition failed")
+}
@@ -0,0 +1,243 @@
+package webhookrelay
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Environment variables used by NewFromProfile
+const (
+	// EnvConfig overrides the config file path
+	EnvConfig = "RELAY_CONFIG"
+	// EnvProfile selects the profile when no name is given
+	EnvProfile = "RELAY_PROFILE"
+)
+
+// DefaultProfileName is used when no profile is selected
+const DefaultProfileName = "default"
+
+// ProfileConfig is a config file with named profiles, e.g.
+// ~/.webhookrelay/config.yaml:
+//
+//	default_profile: prod
+//	profiles:
+//	  prod:
+//	    key: 1a2b3c
+//	    secret_env: RELAY_PROD_SECRET
+//	  staging:
+//	    key: 4d5e6f
+//	    secret_command: [pass, show, webhookrelay/staging]
+//	    endpoint: https://staging.example.com/v1
+//	    region: au
+//	    retry:
+//	      max_retries: 5
+//	      min_delay: 1s
+//	      max_delay: 1m
+type ProfileConfig struct {
+	DefaultProfile string              `yaml:"default_profile,omitempty"`
+	Profiles       map[string]*Profile `yaml:"profiles"`
+}
+
+// Profile holds the settings of one account. The secret is a reference so
+// that the file can be shared without leaking it, exactly one of Secret,
+// SecretEnv, SecretFile and SecretCommand has to be set.
+type Profile struct {
+	Key string `yaml:"key"`
+	// Secret in plain text, prefer one of the references
+	Secret string `yaml:"secret,omitempty"`
+	// SecretEnv is an environment variable with the secret
+	SecretEnv string `yaml:"secret_env,omitempty"`
+	// SecretFile is a file with the secret, it's read again when it changes
+	SecretFile string `yaml:"secret_file,omitempty"`
+	// SecretCommand prints the secret to stdout, it runs again when the API
+	// rejects the secret
+	SecretCommand []string `yaml:"secret_command,omitempty"`
+
+	Endpoint    string `yaml:"endpoint,omitempty"`
+	WebhooksURL string `yaml:"webhooks_url,omitempty"`
+	// Region of tunnels created without one, see WithTunnelRegion
+	Region    string              `yaml:"region,omitempty"`
+	UserAgent string              `yaml:"user_agent,omitempty"`
+	Retry     *ProfileRetryPolicy `yaml:"retry,omitempty"`
+}
+
+// ProfileRetryPolicy is RetryPolicy with delays such as "1s" or "2m"
+type ProfileRetryPolicy struct {
+	MaxRetries int           `yaml:"max_retries"`
+	MinDelay   time.Duration `yaml:"min_delay"`
+	MaxDelay   time.Duration `yaml:"max_delay"`
+}
+
+// DefaultConfigPath returns RELAY_CONFIG or ~/.webhookrelay/config.yaml
+func DefaultConfigPath() (string, error) {
+	if path := os.Getenv(EnvConfig); path != "" {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".webhookrelay", "config.yaml"), nil
+}
+
+// LoadProfileConfig reads and validates a config file
+func LoadProfileConfig(path string) (*ProfileConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg ProfileConfig
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("config %s: %w", path, err)
+	}
+	for name, p := range cfg.Profiles {
+		if p == nil {
+			return nil, fmt.Errorf("config %s: profile '%s' is empty", path, name)
+		}
+		if err := p.Validate(); err != nil {
+			return nil, fmt.Errorf("config %s: profile '%s': %w", path, name, err)
+		}
+	}
+	return &cfg, nil
+}
+
+// Profile returns the named profile. An empty name selects RELAY_PROFILE,
+// the default profile of the file or "default", in that order.
+func (c *ProfileConfig) Profile(name string) (*Profile, error) {
+	if name == "" {
+		name = os.Getenv(EnvProfile)
+	}
+	if name == "" {
+		name = c.DefaultProfile
+	}
+	if name == "" {
+		name = DefaultProfileName
+	}
+	p, ok := c.Profiles[name]
+	if !ok || p == nil {
+		return nil, &NotFoundError{Resource: "profile", Ref: name}
+	}
+	return p, nil
+}
+
+// Validate checks the profile
+func (p *Profile) Validate() error {
+	if p.Key == "" {
+		return fmt.Errorf("key is required")
+	}
+	sources := 0
+	for _, set := range []bool{p.Secret != "", p.SecretEnv != "", p.SecretFile != "", len(p.SecretCommand) > 0} {
+		if set {
+			sources++
+		}
+	}
+	if sources != 1 {
+		return fmt.Errorf("exactly one of secret, secret_env, secret_file and secret_command is required")
+	}
+	if r := p.Retry; r != nil {
+		policy := RetryPolicy{MaxRetries: r.MaxRetries, MinRetryDelay: r.MinDelay, MaxRetryDelay: r.MaxDelay}
+		if err := policy.validate(); err != nil {
+			return fmt.Errorf("retry: %w", err)
+		}
+	}
+	return nil
+}
+
+// Options returns client options for the profile settings
+func (p *Profile) Options() []Option {
+	var opts []Option
+	if p.Endpoint != "" {
+		opts = append(opts, WithAPIEndpointURL(p.Endpoint))
+	}
+	if p.WebhooksURL != "" {
+		opts = append(opts, WithWebhooksURL(p.WebhooksURL))
+	}
+	if p.Region != "" {
+		opts = append(opts, WithTunnelRegion(p.Region))
+	}
+	if p.UserAgent != "" {
+		opts = append(opts, WithUserAgent(p.UserAgent))
+	}
+	if r := p.Retry; r != nil {
+		opts = append(opts, WithRetryDelays(r.MaxRetries, r.MinDelay, r.MaxDelay))
+	}
+	return opts
+}
+
+// CredentialProvider returns a provider that resolves the secret
+// reference on use. Secret files are read again when they change, command
+// output is cached until the API rejects it.
+func (p *Profile) CredentialProvider() CredentialProvider {
+	switch {
+	case p.SecretEnv != "":
+		return &profileEnvSecret{key: p.Key, env: p.SecretEnv}
+	case p.SecretFile != "":
+		provider := NewFileCredentials(p.SecretFile)
+		provider.parse = p.parseSecret
+		return provider
+	case len(p.SecretCommand) > 0:
+		provider := NewExecCredentials(p.SecretCommand[0], p.SecretCommand[1:]...)
+		provider.parse = p.parseSecret
+		return provider
+	}
+	return StaticCredentials(p.Key, p.Secret)
+}
+
+// parseSecret reads a secret file or command output, which only has the
+// secret of the profile key
+func (p *Profile) parseSecret(data []byte) (*Credentials, error) {
+	creds := &Credentials{Key: p.Key, Secret: strings.TrimSpace(string(data))}
+	if err := creds.Validate(); err != nil {
+		return nil, err
+	}
+	return creds, nil
+}
+
+// NewFromProfile creates a client from a profile of the config file at
+// DefaultConfigPath, see ProfileConfig.Profile for how the profile is
+// selected. Options are applied after the profile settings.
+func NewFromProfile(name string, opts ...Option) (*API, error) {
+	path, err := DefaultConfigPath()
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := LoadProfileConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	profile, err := cfg.Profile(name)
+	if err != nil {
+		return nil, err
+	}
+	return profile.NewClient(opts...)
+}
+
+// NewClient creates a client with the profile credentials and settings
+func (p *Profile) NewClient(opts ...Option) (*API, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return NewWithCredentials(p.CredentialProvider(), append(p.Options(), opts...)...)
+}
+
+// profileEnvSecret reads the secret of a profile from the environment on
+// each request
+type profileEnvSecret struct {
+	key string
+	env string
+}
+
+func (c *profileEnvSecret) Credentials(ctx context.Context) (*Credentials, error) {
+	secret := os.Getenv(c.env)
+	if secret == "" {
+		return nil, fmt.Errorf("%w: %s is not set", ErrNoCredentials, c.env)
+	}
+	return &Credentials{Key: c.key, Secret: secret}, nil
+}
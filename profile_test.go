@@ -0,0 +1,176 @@
+package webhookrelay
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testProfileConfig = `default_profile: prod
+profiles:
+  prod:
+    key: prod-key
+    secret_env: TEST_RELAY_PROD_SECRET
+  staging:
+    key: staging-key
+    secret_file: %s
+    endpoint: %s
+    region: au
+    user_agent: ops/1.0
+    retry:
+      max_retries: 5
+      min_delay: 2s
+      max_delay: 1m
+`
+
+func writeProfileConfig(t *testing.T, endpoint string) (configPath, secretPath string) {
+	dir := t.TempDir()
+	secretPath = filepath.Join(dir, "staging-secret")
+	require.NoError(t, os.WriteFile(secretPath, []byte("staging-secret\n"), 0600))
+	configPath = filepath.Join(dir, "config.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte(fmt.Sprintf(testProfileConfig, secretPath, endpoint)), 0600))
+	return configPath, secretPath
+}
+
+func TestLoadProfileConfig(t *testing.T) {
+	path, secretPath := writeProfileConfig(t, "https://staging.example.com/v1")
+	t.Setenv(EnvProfile, "")
+
+	cfg, err := LoadProfileConfig(path)
+	require.NoError(t, err)
+
+	p, err := cfg.Profile("")
+	require.NoError(t, err)
+	assert.Equal(t, "prod-key", p.Key)
+
+	t.Setenv(EnvProfile, "staging")
+	p, err = cfg.Profile("")
+	require.NoError(t, err)
+	assert.Equal(t, &Profile{
+		Key:        "staging-key",
+		SecretFile: secretPath,
+		Endpoint:   "https://staging.example.com/v1",
+		Region:     "au",
+		UserAgent:  "ops/1.0",
+		Retry:      &ProfileRetryPolicy{MaxRetries: 5, MinDelay: 2 * time.Second, MaxDelay: time.Minute},
+	}, p)
+
+	client, err := p.NewClient()
+	require.NoError(t, err)
+	assert.Equal(t, "https://staging.example.com/v1", client.BaseURL)
+	assert.Equal(t, "ops/1.0", client.UserAgent)
+	assert.Equal(t, "au", client.tunnelRegion)
+	assert.Equal(t, RetryPolicy{MaxRetries: 5, MinRetryDelay: 2 * time.Second, MaxRetryDelay: time.Minute}, client.retryPolicy)
+
+	_, err = cfg.Profile("customer-1")
+	assert.EqualError(t, err, "no such profile 'customer-1'")
+}
+
+func TestProfile_Validate(t *testing.T) {
+	assert.EqualError(t, (&Profile{Secret: "s"}).Validate(), "key is required")
+	assert.EqualError(t, (&Profile{Key: "k"}).Validate(), "exactly one of secret, secret_env, secret_file and secret_command is required")
+	assert.Error(t, (&Profile{Key: "k", Secret: "s", SecretEnv: "X"}).Validate())
+	assert.EqualError(t, (&Profile{Key: "k", Secret: "s", Retry: &ProfileRetryPolicy{MinDelay: time.Minute, MaxDelay: time.Second}}).Validate(), "retry: max retry delay is shorter than min retry delay")
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("profiles:\n  prod:\n    key: k\n"), 0600))
+	_, err := LoadProfileConfig(path)
+	assert.EqualError(t, err, "config "+path+": profile 'prod': exactly one of secret, secret_env, secret_file and secret_command is required")
+}
+
+func TestProfile_Credentials(t *testing.T) {
+	t.Setenv("TEST_RELAY_SECRET", "env-secret")
+	creds, err := (&Profile{Key: "k", SecretEnv: "TEST_RELAY_SECRET"}).CredentialProvider().Credentials(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, &Credentials{Key: "k", Secret: "env-secret"}, creds)
+
+	command := (&Profile{Key: "k", SecretCommand: []string{"echo", "cmd-secret"}}).CredentialProvider()
+	require.IsType(t, &ExecCredentials{}, command, "runs with the helper timeout and caching")
+	creds, err = command.Credentials(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, &Credentials{Key: "k", Secret: "cmd-secret"}, creds)
+
+	path := filepath.Join(t.TempDir(), "secret")
+	require.NoError(t, os.WriteFile(path, []byte("file-secret\n"), 0600))
+	file := (&Profile{Key: "k", SecretFile: path}).CredentialProvider()
+	creds, err = file.Credentials(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "file-secret", creds.Secret)
+
+	require.NoError(t, os.WriteFile(path, []byte("rotated-secret\n"), 0600))
+	require.NoError(t, os.Chtimes(path, time.Now(), time.Now().Add(time.Minute)))
+	creds, err = file.Credentials(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "rotated-secret", creds.Secret)
+
+	require.NoError(t, os.WriteFile(path, nil, 0600))
+	require.NoError(t, os.Chtimes(path, time.Now(), time.Now().Add(2*time.Minute)))
+	_, err = file.Credentials(context.Background())
+	assert.EqualError(t, err, "credentials file "+path+": "+ErrEmptyCredentials.Error())
+}
+
+func TestNewFromProfile(t *testing.T) {
+	var tunnel Tunnel
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key, secret, _ := r.BasicAuth()
+		if key != "staging-key" || secret != "staging-secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		assert.Equal(t, "ops/1.0", r.Header.Get("User-Agent"))
+		if err := json.NewDecoder(r.Body).Decode(&tunnel); err != nil {
+			t.Error(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(&tunnel)
+	}))
+	defer server.Close()
+
+	path, _ := writeProfileConfig(t, server.URL)
+	t.Setenv(EnvConfig, path)
+
+	client, err := NewFromProfile("staging")
+	require.NoError(t, err)
+	_, err = client.CreateTunnel(&Tunnel{Name: "dev"})
+	require.NoError(t, err)
+	assert.Equal(t, "au", tunnel.Region)
+
+	_, err = client.CreateTunnel(&Tunnel{Name: "dev", Region: "eu"})
+	require.NoError(t, err)
+	assert.Equal(t, "eu", tunnel.Region)
+
+	client, err = NewFromProfile("staging", WithTunnelRegion("us"))
+	require.NoError(t, err)
+	_, err = client.CreateTunnel(&Tunnel{Name: "dev"})
+	require.NoError(t, err)
+	assert.Equal(t, "us", tunnel.Region, "options override the profile")
+
+	_, err = NewFromProfile("missing")
+	assert.True(t, IsNotFound(err))
+}
+
+func TestWithRetryDelays(t *testing.T) {
+	client, err := New("k", "s", WithRetryDelays(2, 100*time.Millisecond, time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, RetryPolicy{MaxRetries: 2, MinRetryDelay: 100 * time.Millisecond, MaxRetryDelay: time.Second}, client.retryPolicy)
+
+	_, err = New("k", "s", WithRetryDelays(2, time.Second, time.Millisecond))
+	assert.EqualError(t, err, "options parsing failed: max retry delay is shorter than min retry delay")
+	_, err = New("k", "s", WithRetryDelays(-1, time.Second, 30*time.Second))
+	assert.EqualError(t, err, "options parsing failed: retry policy values must not be negative")
+
+	// WithRetryPolicy keeps accepting any values
+	client, err = New("k", "s", WithRetryPolicy(-1, 1, 30))
+	require.NoError(t, err)
+	assert.Equal(t, -1, client.retryPolicy.MaxRetries)
+}
@@ -0,0 +1,426 @@
+// Package recorder records HTTP interactions with the Webhook Relay API to
+// fixture files and replays them, so tests using the client can run offline:
+//
+//	rec, err := recorder.New("testdata/fixtures/list_buckets.yaml", recorder.ModeFromEnv("RELAY_RECORD"))
+//	defer rec.Stop()
+//	client, err := webhookrelay.New(key, secret, webhookrelay.WithHTTPClient(rec.Client()))
+//
+// Credentials and secrets are scrubbed before fixtures are written, see
+// DefaultRedactedKeys.
+package recorder
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Mode selects whether the recorder records or replays
+type Mode int
+
+// Recorder modes
+const (
+	// ModeReplay serves responses from the fixture file, requests without
+	// a recorded interaction fail
+	ModeReplay Mode = iota
+	// ModeRecord sends requests to the server and writes the interactions
+	// to the fixture file on Stop
+	ModeRecord
+)
+
+func (m Mode) String() string {
+	switch m {
+	case ModeReplay:
+		return "replay"
+	case ModeRecord:
+		return "record"
+	default:
+		return "unknown"
+	}
+}
+
+// ModeFromEnv returns ModeRecord if the environment variable is set to a
+// non-empty value, ModeReplay otherwise
+func ModeFromEnv(name string) Mode {
+	if os.Getenv(name) != "" {
+		return ModeRecord
+	}
+	return ModeReplay
+}
+
+// Redacted replaces scrubbed values
+const Redacted = "REDACTED"
+
+// DefaultRedactedKeys are JSON keys whose values are scrubbed from request
+// and response bodies: access token secrets and bucket or tunnel auth
+// passwords and tokens
+var DefaultRedactedKeys = []string{"password", "secret", "token"}
+
+// ErrNoInteraction is returned in replay mode when no recorded interaction
+// matches a request
+var ErrNoInteraction = errors.New("no recorded interaction")
+
+// ErrUnsupportedContent is returned when saving an interaction whose body
+// is not text
+var ErrUnsupportedContent = errors.New("unsupported content")
+
+// Fixture is the content of a fixture file
+type Fixture struct {
+	Interactions []*Interaction `yaml:"interactions"`
+}
+
+// Interaction is a recorded request and its response
+type Interaction struct {
+	Request  Request  `yaml:"request"`
+	Response Response `yaml:"response"`
+}
+
+// Request is a recorded request
+type Request struct {
+	Method string      `yaml:"method"`
+	URL    string      `yaml:"url"`
+	Header http.Header `yaml:"header,omitempty"`
+	Body   string      `yaml:"body,omitempty"`
+}
+
+// Response is a recorded response
+type Response struct {
+	StatusCode int         `yaml:"status_code"`
+	Header     http.Header `yaml:"header,omitempty"`
+	Body       string      `yaml:"body,omitempty"`
+}
+
+// Matcher reports whether a recorded request matches the request being
+// replayed. The request body has been scrubbed like recorded bodies.
+type Matcher func(r *http.Request, body string, recorded *Request) bool
+
+// DefaultMatcher matches method, path, query and body. The host is ignored
+// so fixtures replay against any API endpoint.
+func DefaultMatcher(r *http.Request, body string, recorded *Request) bool {
+	if r.Method != recorded.Method {
+		return false
+	}
+	u, err := r.URL.Parse(recorded.URL)
+	if err != nil {
+		return false
+	}
+	return u.Path == r.URL.Path && u.Query().Encode() == r.URL.Query().Encode() && body == recorded.Body
+}
+
+// Option configures the recorder
+type Option func(*Recorder)
+
+// WithTransport sets the transport used in record mode.
+// Default: http.DefaultTransport
+func WithTransport(transport http.RoundTripper) Option {
+	return func(r *Recorder) {
+		r.transport = transport
+	}
+}
+
+// WithRedactedKeys replaces DefaultRedactedKeys
+func WithRedactedKeys(keys ...string) Option {
+	return func(r *Recorder) {
+		r.redactedKeys = keys
+	}
+}
+
+// WithScrubber adds a function that modifies interactions before they are
+// saved, e.g. to remove account specific data
+func WithScrubber(scrub func(*Interaction)) Option {
+	return func(r *Recorder) {
+		r.scrubbers = append(r.scrubbers, scrub)
+	}
+}
+
+// WithMatcher replaces DefaultMatcher
+func WithMatcher(matcher Matcher) Option {
+	return func(r *Recorder) {
+		r.matcher = matcher
+	}
+}
+
+// Recorder is an http.RoundTripper that records or replays interactions
+type Recorder struct {
+	path string
+	mode Mode
+
+	transport    http.RoundTripper
+	redactedKeys []string
+	scrubbers    []func(*Interaction)
+	matcher      Matcher
+
+	mu       sync.Mutex
+	fixture  Fixture
+	replayed []bool
+}
+
+// New creates a recorder for the fixture file. In replay mode the file is
+// loaded and has to exist.
+func New(path string, mode Mode, opts ...Option) (*Recorder, error) {
+	r := &Recorder{
+		path:         path,
+		mode:         mode,
+		transport:    http.DefaultTransport,
+		redactedKeys: DefaultRedactedKeys,
+		matcher:      DefaultMatcher,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	if mode == ModeReplay {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read fixture: %w", err)
+		}
+		if err := yaml.Unmarshal(data, &r.fixture); err != nil {
+			return nil, fmt.Errorf("failed to parse fixture %s: %w", path, err)
+		}
+		r.replayed = make([]bool, len(r.fixture.Interactions))
+	}
+	return r, nil
+}
+
+// Mode returns the recorder mode
+func (r *Recorder) Mode() Mode {
+	return r.mode
+}
+
+// Client returns an HTTP client using the recorder, pass it to
+// webhookrelay.WithHTTPClient
+func (r *Recorder) Client() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// RoundTrip implements http.RoundTripper
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.mode == ModeReplay {
+		return r.replay(req, r.scrubBody(body))
+	}
+	return r.record(req, body)
+}
+
+func (r *Recorder) replay(req *http.Request, body string) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// interactions are replayed once and in order, so that repeated
+	// requests get the responses recorded for them
+	for idx, interaction := range r.fixture.Interactions {
+		if r.replayed[idx] || !r.matcher(req, body, &interaction.Request) {
+			continue
+		}
+		r.replayed[idx] = true
+
+		resp := &http.Response{
+			Status:        fmt.Sprintf("%d %s", interaction.Response.StatusCode, http.StatusText(interaction.Response.StatusCode)),
+			StatusCode:    interaction.Response.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        interaction.Response.Header.Clone(),
+			Body:          io.NopCloser(strings.NewReader(interaction.Response.Body)),
+			ContentLength: int64(len(interaction.Response.Body)),
+			Request:       req,
+		}
+		if resp.Header == nil {
+			resp.Header = make(http.Header)
+		}
+		return resp, nil
+	}
+	return nil, fmt.Errorf("%w for %s %s in %s", ErrNoInteraction, req.Method, req.URL.RequestURI(), r.path)
+}
+
+func (r *Recorder) record(req *http.Request, body string) (*http.Response, error) {
+	resp, err := r.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	interaction := &Interaction{
+		Request: Request{
+			Method: req.Method,
+			URL:    req.URL.String(),
+			Header: req.Header.Clone(),
+			Body:   body,
+		},
+		Response: Response{
+			StatusCode: resp.StatusCode,
+			Header:     resp.Header.Clone(),
+			Body:       string(respBody),
+		},
+	}
+	r.scrub(interaction)
+
+	r.mu.Lock()
+	r.fixture.Interactions = append(r.fixture.Interactions, interaction)
+	r.mu.Unlock()
+	return resp, nil
+}
+
+// scrub removes credentials and secrets from the interaction
+func (r *Recorder) scrub(interaction *Interaction) {
+	for _, header := range []http.Header{interaction.Request.Header, interaction.Response.Header} {
+		for _, name := range []string{"Authorization", "Cookie", "Set-Cookie"} {
+			if header.Get(name) != "" {
+				header.Set(name, Redacted)
+			}
+		}
+	}
+	interaction.Request.URL = scrubUserinfo(interaction.Request.URL)
+	interaction.Request.Body = r.scrubBody(interaction.Request.Body)
+	interaction.Response.Body = r.scrubBody(interaction.Response.Body)
+
+	for _, scrub := range r.scrubbers {
+		scrub(interaction)
+	}
+}
+
+// scrubUserinfo redacts credentials in the URL
+func scrubUserinfo(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.User == nil {
+		return rawURL
+	}
+	u.User = url.User(Redacted)
+	return u.String()
+}
+
+// scrubBody redacts values of redacted keys in JSON bodies, other bodies
+// are returned unchanged
+func (r *Recorder) scrubBody(body string) string {
+	if body == "" || len(r.redactedKeys) == 0 {
+		return body
+	}
+	var doc interface{}
+	if err := json.Unmarshal([]byte(body), &doc); err != nil {
+		return body
+	}
+	if !r.redact(doc) {
+		return body
+	}
+	scrubbed, err := json.Marshal(doc)
+	if err != nil {
+		return body
+	}
+	return string(scrubbed)
+}
+
+// redact replaces non-empty string values of redacted keys and reports
+// whether anything changed
+func (r *Recorder) redact(doc interface{}) bool {
+	changed := false
+	switch v := doc.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if s, ok := value.(string); ok && s != "" && r.isRedacted(key) {
+				v[key] = Redacted
+				changed = true
+				continue
+			}
+			if r.redact(value) {
+				changed = true
+			}
+		}
+	case []interface{}:
+		for _, value := range v {
+			if r.redact(value) {
+				changed = true
+			}
+		}
+	}
+	return changed
+}
+
+func (r *Recorder) isRedacted(key string) bool {
+	for _, k := range r.redactedKeys {
+		if strings.EqualFold(k, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// Stop writes recorded interactions to the fixture file. In replay mode it
+// does nothing.
+func (r *Recorder) Stop() error {
+	if r.mode != ModeRecord {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, interaction := range r.fixture.Interactions {
+		if !utf8.ValidString(interaction.Request.Body) || !utf8.ValidString(interaction.Response.Body) {
+			return fmt.Errorf("%w: %s %s has a binary body", ErrUnsupportedContent, interaction.Request.Method, interaction.Request.URL)
+		}
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&r.fixture); err != nil {
+		return err
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(r.path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(r.path, buf.Bytes(), 0644)
+}
+
+// Unused returns interactions that were not replayed, useful to check that
+// a test made all the recorded requests
+func (r *Recorder) Unused() []*Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var unused []*Interaction
+	for idx, interaction := range r.fixture.Interactions {
+		if idx < len(r.replayed) && !r.replayed[idx] {
+			unused = append(unused, interaction)
+		}
+	}
+	return unused
+}
+
+// readBody reads the request body and restores it for the transport
+func readBody(req *http.Request) (string, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return "", nil
+	}
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return "", fmt.Errorf("failed to read request body: %w", err)
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	return string(body), nil
+}
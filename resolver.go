@@ -0,0 +1,328 @@
+package webhookrelay
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ResourceKind identifies the type of object a reference points to
+type ResourceKind string
+
+// Resource kinds that can be resolved by name
+const (
+	ResourceBucket   ResourceKind = "bucket"
+	ResourceInput    ResourceKind = "input"
+	ResourceOutput   ResourceKind = "output"
+	ResourceTunnel   ResourceKind = "tunnel"
+	ResourceFunction ResourceKind = "function"
+	ResourceDomain   ResourceKind = "domain"
+)
+
+// defaultResolverCacheTTL is how long name to ID mappings are trusted before
+// they are fetched again
+const defaultResolverCacheTTL = 30 * time.Second
+
+var (
+	// ErrAmbiguousRef is matched (with errors.Is) by errors returned when a name
+	// refers to more than one object.
+	ErrAmbiguousRef = errors.New("ambiguous reference")
+
+	// ErrNameLookupDisabled is returned when a name is used as a reference
+	// while the client is configured to accept IDs only (see WithStrictIDs).
+	ErrNameLookupDisabled = errors.New("name lookups are disabled, use an ID")
+)
+
+// AmbiguousRefError is returned when a reference matches several objects
+type AmbiguousRefError struct {
+	Resource   ResourceKind
+	Ref        string
+	Candidates []string // IDs of all matching objects
+}
+
+func (e *AmbiguousRefError) Error() string {
+	return fmt.Sprintf("ambiguous %s reference '%s', candidates: %s", e.Resource, e.Ref, strings.Join(e.Candidates, ", "))
+}
+
+// Is makes errors.Is(err, ErrAmbiguousRef) work
+func (e *AmbiguousRefError) Is(target error) bool {
+	return target == ErrAmbiguousRef
+}
+
+// RefEntry is a single resolvable object - its ID and the names it can be
+// referenced by (for example tunnel name and hostname)
+type RefEntry struct {
+	ID    string
+	Names []string
+}
+
+// RefLister lists resolvable objects of a kind. Scope is a bucket ID for
+// outputs and inputs (empty scope means the whole account) and is empty for
+// all other kinds. *API implements RefLister.
+type RefLister interface {
+	ListRefs(kind ResourceKind, scope string) ([]RefEntry, error)
+}
+
+// Resolver translates references (IDs or names) into IDs. Implementations
+// must be safe for concurrent use.
+type Resolver interface {
+	// Resolve returns the ID of the object of a given kind and scope that
+	// ref refers to
+	Resolve(kind ResourceKind, scope, ref string) (string, error)
+	// Invalidate drops any cached state for the kind within the scope, an
+	// empty scope invalidates every scope of that kind
+	Invalidate(kind ResourceKind, scope string)
+}
+
+// CachingResolver is the default Resolver, it keeps name to ID mappings
+// for a configurable TTL and refreshes them on a cache miss.
+type CachingResolver struct {
+	lister RefLister
+	ttl    time.Duration
+	now    func() time.Time
+
+	mu      sync.Mutex
+	indexes map[refScope]*refIndex
+}
+
+type refScope struct {
+	kind  ResourceKind
+	scope string
+}
+
+type refIndex struct {
+	fetchedAt time.Time
+	ids       map[string][]string // name -> IDs
+}
+
+// NewCachingResolver creates a resolver that lists objects through the lister
+// and caches results for ttl. A zero ttl disables caching.
+func NewCachingResolver(lister RefLister, ttl time.Duration) *CachingResolver {
+	return &CachingResolver{
+		lister:  lister,
+		ttl:     ttl,
+		now:     time.Now,
+		indexes: make(map[refScope]*refIndex),
+	}
+}
+
+// Resolve returns the ID for the reference
+func (r *CachingResolver) Resolve(kind ResourceKind, scope, ref string) (string, error) {
+	key := refScope{kind: kind, scope: scope}
+
+	if ids, ok := r.cached(key, ref); ok {
+		return pickRef(kind, ref, ids)
+	}
+
+	entries, err := r.lister.ListRefs(kind, scope)
+	if err != nil {
+		return "", err
+	}
+	index := newRefIndex(entries, r.now())
+	r.store(key, index)
+
+	return pickRef(kind, ref, index.ids[ref])
+}
+
+// Prime stores a fresh listing for the kind and scope, it is used by callers
+// that already have the objects at hand (e.g. after listing buckets with
+// their inputs and outputs) to save a request.
+func (r *CachingResolver) Prime(kind ResourceKind, scope string, entries []RefEntry) {
+	r.store(refScope{kind: kind, scope: scope}, newRefIndex(entries, r.now()))
+}
+
+// Invalidate drops cached mappings
+func (r *CachingResolver) Invalidate(kind ResourceKind, scope string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for key := range r.indexes {
+		if key.kind == kind && (scope == "" || key.scope == scope) {
+			delete(r.indexes, key)
+		}
+	}
+}
+
+func (r *CachingResolver) store(key refScope, index *refIndex) {
+	if r.ttl <= 0 {
+		return
+	}
+	r.mu.Lock()
+	r.indexes[key] = index
+	r.mu.Unlock()
+}
+
+// cached returns IDs for the ref from a fresh index. Misses are not
+// trusted, the object might have been created by another client.
+func (r *CachingResolver) cached(key refScope, ref string) ([]string, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	index, ok := r.indexes[key]
+	if !ok {
+		return nil, false
+	}
+	if r.now().Sub(index.fetchedAt) > r.ttl {
+		delete(r.indexes, key)
+		return nil, false
+	}
+	ids, ok := index.ids[ref]
+	return ids, ok
+}
+
+func newRefIndex(entries []RefEntry, fetchedAt time.Time) *refIndex {
+	index := &refIndex{
+		fetchedAt: fetchedAt,
+		ids:       make(map[string][]string),
+	}
+	for _, entry := range entries {
+		for _, name := range entry.Names {
+			if name == "" || containsString(index.ids[name], entry.ID) {
+				continue
+			}
+			index.ids[name] = append(index.ids[name], entry.ID)
+		}
+	}
+	return index
+}
+
+func pickRef(kind ResourceKind, ref string, ids []string) (string, error) {
+	switch len(ids) {
+	case 0:
+		return "", &NotFoundError{Resource: string(kind), Ref: ref}
+	case 1:
+		return ids[0], nil
+	default:
+		candidates := append([]string(nil), ids...)
+		sort.Strings(candidates)
+		return "", &AmbiguousRefError{Resource: kind, Ref: ref, Candidates: candidates}
+	}
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// refPrimer is implemented by resolvers that can accept listings gathered
+// elsewhere
+type refPrimer interface {
+	Prime(kind ResourceKind, scope string, entries []RefEntry)
+}
+
+// resolveRef takes name/id and always returns ID (when it not fails)
+func (api *API) resolveRef(kind ResourceKind, scope, ref string) (string, error) {
+	if ref == "" {
+		return "", ErrNoRef
+	}
+	if IsUUID(ref) {
+		return ref, nil
+	}
+	if api.strictIDs {
+		return "", fmt.Errorf("%w: %s '%s'", ErrNameLookupDisabled, kind, ref)
+	}
+	return api.resolver.Resolve(kind, scope, ref)
+}
+
+// invalidateRefs drops cached name lookups after a mutation
+func (api *API) invalidateRefs(kind ResourceKind, scope string) {
+	api.resolver.Invalidate(kind, scope)
+}
+
+// ListRefs lists objects of the kind so they can be resolved by name,
+// it implements RefLister.
+func (api *API) ListRefs(kind ResourceKind, scope string) ([]RefEntry, error) {
+	switch kind {
+	case ResourceBucket:
+		buckets, err := api.ListBuckets(&BucketListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		api.primeBucketRefs(buckets)
+
+		entries := make([]RefEntry, 0, len(buckets))
+		for _, b := range buckets {
+			entries = append(entries, RefEntry{ID: b.ID, Names: []string{b.Name}})
+		}
+		return entries, nil
+	case ResourceInput:
+		inputs, err := api.ListInputs(&InputListOptions{Bucket: scope})
+		if err != nil {
+			return nil, err
+		}
+		return inputRefs(inputs), nil
+	case ResourceOutput:
+		outputs, err := api.ListOutputs(&OutputListOptions{Bucket: scope})
+		if err != nil {
+			return nil, err
+		}
+		return outputRefs(outputs), nil
+	case ResourceTunnel:
+		tunnels, err := api.ListTunnels(&TunnelListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		entries := make([]RefEntry, 0, len(tunnels))
+		for _, t := range tunnels {
+			entries = append(entries, RefEntry{ID: t.ID, Names: []string{t.Name, t.Host}})
+		}
+		return entries, nil
+	case ResourceFunction:
+		functions, err := api.ListFunctions(&FunctionListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		entries := make([]RefEntry, 0, len(functions))
+		for _, f := range functions {
+			entries = append(entries, RefEntry{ID: f.Id, Names: []string{f.Id, f.Name}})
+		}
+		return entries, nil
+	case ResourceDomain:
+		domains, err := api.ListDomainReservations(&DomainListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		entries := make([]RefEntry, 0, len(domains))
+		for _, d := range domains {
+			entries = append(entries, RefEntry{ID: d.ID, Names: []string{d.Domain}})
+		}
+		return entries, nil
+	}
+	return nil, fmt.Errorf("unknown resource kind '%s'", kind)
+}
+
+// primeBucketRefs feeds inputs and outputs that come embedded in the bucket
+// listing to the resolver so resolving them doesn't cost another request
+func (api *API) primeBucketRefs(buckets []*Bucket) {
+	primer, ok := api.resolver.(refPrimer)
+	if !ok {
+		return
+	}
+
+	for _, b := range buckets {
+		primer.Prime(ResourceInput, b.ID, inputRefs(b.Inputs))
+		primer.Prime(ResourceOutput, b.ID, outputRefs(b.Outputs))
+	}
+}
+
+func inputRefs(inputs []*Input) []RefEntry {
+	entries := make([]RefEntry, 0, len(inputs))
+	for _, i := range inputs {
+		entries = append(entries, RefEntry{ID: i.ID, Names: []string{i.Name}})
+	}
+	return entries
+}
+
+func outputRefs(outputs []*Output) []RefEntry {
+	entries := make([]RefEntry, 0, len(outputs))
+	for _, o := range outputs {
+		entries = append(entries, RefEntry{ID: o.ID, Names: []string{o.Name}})
+	}
+	return entries
+}
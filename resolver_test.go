@@ -0,0 +1,72 @@
+package webhookrelay
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeRefLister struct {
+	calls   int
+	entries []RefEntry
+}
+
+func (l *fakeRefLister) ListRefs(kind ResourceKind, scope string) ([]RefEntry, error) {
+	l.calls++
+	return l.entries, nil
+}
+
+func TestCachingResolver(t *testing.T) {
+	lister := &fakeRefLister{entries: []RefEntry{
+		{ID: "id-1", Names: []string{"tunnel-a", "a.webrelay.io"}},
+		{ID: "id-2", Names: []string{"shared"}},
+		{ID: "id-3", Names: []string{"shared"}},
+	}}
+	now := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	resolver := NewCachingResolver(lister, time.Minute)
+	resolver.now = func() time.Time { return now }
+
+	id, err := resolver.Resolve(ResourceTunnel, "", "tunnel-a")
+	assert.NoError(t, err)
+	assert.Equal(t, "id-1", id)
+
+	id, err = resolver.Resolve(ResourceTunnel, "", "a.webrelay.io")
+	assert.NoError(t, err)
+	assert.Equal(t, "id-1", id)
+	assert.Equal(t, 1, lister.calls, "second lookup should be served from cache")
+
+	_, err = resolver.Resolve(ResourceTunnel, "", "shared")
+	assert.True(t, errors.Is(err, ErrAmbiguousRef))
+	var ambiguous *AmbiguousRefError
+	if assert.True(t, errors.As(err, &ambiguous)) {
+		assert.Equal(t, []string{"id-2", "id-3"}, ambiguous.Candidates)
+	}
+
+	_, err = resolver.Resolve(ResourceTunnel, "", "missing")
+	assert.True(t, IsNotFound(err))
+	assert.Equal(t, 2, lister.calls, "misses are always refreshed")
+
+	now = now.Add(2 * time.Minute)
+	_, err = resolver.Resolve(ResourceTunnel, "", "tunnel-a")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, lister.calls, "expired entries are refreshed")
+
+	resolver.Invalidate(ResourceTunnel, "")
+	_, err = resolver.Resolve(ResourceTunnel, "", "tunnel-a")
+	assert.NoError(t, err)
+	assert.Equal(t, 4, lister.calls, "invalidated entries are refreshed")
+}
+
+func TestStrictIDs(t *testing.T) {
+	client, err := New("test-key", "test-secret", WithStrictIDs())
+	assert.NoError(t, err)
+
+	_, err = client.GetBucket("my-bucket")
+	assert.True(t, errors.Is(err, ErrNameLookupDisabled))
+
+	id, err := client.ensureBucketID("5c3a7c4b-0b8e-4c8a-9f0f-2a1b3c4d5e6f")
+	assert.NoError(t, err)
+	assert.Equal(t, "5c3a7c4b-0b8e-4c8a-9f0f-2a1b3c4d5e6f", id)
+}
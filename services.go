@@ -0,0 +1,107 @@
+package webhookrelay
+
+import (
+	"context"
+	"net/url"
+)
+
+// Service interfaces split the API by resource so that consumers can
+// depend on, and mock, only the part they use. *API implements all of
+// them, fakes are in the webhookrelaymock package.
+
+// BucketService manages buckets
+type BucketService interface {
+	ListBuckets(options *BucketListOptions) ([]*Bucket, error)
+	GetBucket(ref string) (*Bucket, error)
+	CreateBucket(options *BucketCreateOptions) (*Bucket, error)
+	UpdateBucket(options *Bucket) (*Bucket, error)
+	PatchBucket(ref string, patch *BucketPatch, opts *PatchOptions) (*Bucket, error)
+	DeleteBucket(options *BucketDeleteOptions) error
+	CloneBucket(ctx context.Context, srcRef, newName string, opts *BucketCloneOptions) (*Bucket, error)
+	SuspendBucket(ref string) (*Bucket, error)
+	ResumeBucket(ref string) (*Bucket, error)
+	GetBucketUsage(ref string) (*BucketUsage, error)
+}
+
+// InputService manages bucket inputs
+type InputService interface {
+	ListInputs(options *InputListOptions) ([]*Input, error)
+	CreateInput(options *Input) (*Input, error)
+	UpdateInput(options *Input) (*Input, error)
+	PatchInput(bucketRef, inputRef string, patch *InputPatch, opts *PatchOptions) (*Input, error)
+	DeleteInput(options *InputDeleteOptions) error
+	SetInputResponse(bucketRef, inputRef string, resp *InputResponse) (*Input, error)
+	AllowInputIPRanges(bucketRef, inputRef string, ranges []string) (*Input, error)
+	InputURL(input *Input) (*url.URL, error)
+}
+
+// OutputService manages bucket outputs
+type OutputService interface {
+	ListOutputs(options *OutputListOptions) ([]*Output, error)
+	CreateOutput(options *Output) (*Output, error)
+	UpdateOutput(options *Output) (*Output, error)
+	PatchOutput(bucketRef, outputRef string, patch *OutputPatch, opts *PatchOptions) (*Output, error)
+	DeleteOutput(options *OutputDeleteOptions) error
+}
+
+// TunnelService manages tunnels
+type TunnelService interface {
+	ListTunnels(options *TunnelListOptions) ([]*Tunnel, error)
+	GetTunnel(ref string) (*Tunnel, error)
+	CreateTunnel(options *Tunnel) (*Tunnel, error)
+	UpdateTunnel(options *Tunnel) (*Tunnel, error)
+	PatchTunnel(ref string, patch *TunnelPatch, opts *PatchOptions) (*Tunnel, error)
+	DeleteTunnel(options *TunnelDeleteOptions) error
+	TunnelURL(tunnel *Tunnel) (*url.URL, error)
+}
+
+// FunctionService manages functions and their configuration
+type FunctionService interface {
+	ListFunctions(options *FunctionListOptions) ([]*Function, error)
+	GetFunction(ref string) (*Function, error)
+	CreateFunction(opts *CreateFunctionRequest) (*Function, error)
+	UpdateFunction(options *UpdateFunctionRequest) (*Function, error)
+	DeleteFunction(options *FunctionDeleteOptions) error
+	InvokeFunction(options *InvokeOpts) (*ExecuteResponse, error)
+	ListFunctionConfigurationVariables(options *FunctionConfigurationVariablesListOptions) ([]*Variable, error)
+	SetFunctionConfigurationVariable(options *SetFunctionConfigRequest) (*Variable, error)
+	DeleteFunctionConfigurationVariable(options *FunctionConfigurationVariableDeleteOptions) error
+}
+
+// LogService reads and updates webhook logs
+type LogService interface {
+	ListWebhookLogs(options *WebhookLogsListOptions) (*WebhookLogsResponse, error)
+	GetWebhookLog(id string) (*Log, error)
+	UpdateWebhookLog(updateRequest *WebhookLogsUpdateRequest) error
+	ListDeadLetters(options *DeadLetterListOptions) (*WebhookLogsResponse, error)
+	DrainDeadLetters(ctx context.Context, options *DeadLetterDrainOptions) (int, error)
+}
+
+// TokenService manages access tokens
+type TokenService interface {
+	ListAccessTokens(options *AccessTokenListOptions) ([]*AccessToken, error)
+	CreateAccessToken(options *AccessTokenCreateOptions) (*AccessTokenCreateResponse, error)
+	UpdateAccessToken(options *AccessToken) (*AccessToken, error)
+	DeleteAccessToken(options *AccessTokenDeleteOptions) error
+	RotateAccessToken(ctx context.Context, id string, opts *AccessTokenRotateOptions) (*AccessTokenRotation, error)
+	FinishAccessTokenRotation(rotation *AccessTokenRotation) error
+	UnusedAccessTokens(days int) ([]*UnusedAccessToken, error)
+}
+
+// DomainService manages domain reservations
+type DomainService interface {
+	ListDomainReservations(options *DomainListOptions) ([]*Domain, error)
+	ReserveDomain(options *Domain) (*Domain, error)
+	DeleteDomainReservation(options *DomainDeleteOptions) error
+}
+
+var (
+	_ BucketService   = (*API)(nil)
+	_ InputService    = (*API)(nil)
+	_ OutputService   = (*API)(nil)
+	_ TunnelService   = (*API)(nil)
+	_ FunctionService = (*API)(nil)
+	_ LogService      = (*API)(nil)
+	_ TokenService    = (*API)(nil)
+	_ DomainService   = (*API)(nil)
+)
@@ -28,6 +28,22 @@ func (s AuthType) String() string {
 	}
 }
 
+// parseOptionalTime parses like parseTime, null and 0 are zero time
+func parseOptionalTime(data json.RawMessage) (time.Time, error) {
+	if len(data) == 0 || string(data) == "null" || string(data) == "0" {
+		return time.Time{}, nil
+	}
+	return parseTime(data)
+}
+
+// optionalUnix returns unix time, 0 for zero time
+func optionalUnix(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
+
 func parseTime(data json.RawMessage) (time.Time, error) {
 	if len(data) == 0 {
 		return time.Time{}, nil
@@ -81,6 +81,8 @@ type Features struct {
 }
 
 // GetURL helper
+//
+// Deprecated: GetURL ignores regions and TCP tunnels, use API.TunnelURL.
 func (t *Tunnel) GetURL() string {
 	switch t.Crypto {
 	case CryptoOff:
@@ -210,10 +212,16 @@ func (api *API) GetTunnel(ref string) (*Tunnel, error) {
 
 // CreateTunnel creates new tunnel
 func (api *API) CreateTunnel(options *Tunnel) (*Tunnel, error) {
+	if options.Region == "" && api.tunnelRegion != "" {
+		tunnel := *options
+		tunnel.Region = api.tunnelRegion
+		options = &tunnel
+	}
 	resp, err := api.makeRequest(http.MethodPost, "/tunnels", options)
 	if err != nil {
 		return nil, err
 	}
+	api.invalidateRefs(ResourceTunnel, "")
 
 	var result Tunnel
 	if err := json.Unmarshal(resp, &result); err != nil {
@@ -234,6 +242,7 @@ func (api *API) UpdateTunnel(options *Tunnel) (*Tunnel, error) {
 	if err != nil {
 		return nil, err
 	}
+	api.invalidateRefs(ResourceTunnel, "")
 
 	var result Tunnel
 	if err := json.Unmarshal(resp, &result); err != nil {
@@ -271,30 +280,12 @@ func (api *API) DeleteTunnel(options *TunnelDeleteOptions) error {
 	if err != nil {
 		return err
 	}
+	api.invalidateRefs(ResourceTunnel, "")
 
 	return nil
 }
 
+// ensureTunnelID - takes ID, name or hostname and always returns ID (when it not fails)
 func (api *API) ensureTunnelID(ref string) (string, error) {
-	if !IsUUID(ref) {
-		id, err := api.tunnelIDFromName(ref)
-		if err != nil {
-			return "", err
-		}
-		return id, nil
-	}
-	return ref, nil
-}
-
-func (api *API) tunnelIDFromName(ref string) (id string, err error) {
-	tunnels, err := api.ListTunnels(&TunnelListOptions{})
-	if err != nil {
-		return
-	}
-	for _, t := range tunnels {
-		if t.Name == ref || t.Host == ref {
-			return t.ID, nil
-		}
-	}
-	return "", fmt.Errorf("no such tunnel '%s'", ref)
+	return api.resolveRef(ResourceTunnel, "", ref)
 }
@@ -0,0 +1,245 @@
+package verify
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultTolerance is the maximum age of signed timestamps
+const DefaultTolerance = 5 * time.Minute
+
+// GitHub verifies X-Hub-Signature-256, or the legacy SHA1 X-Hub-Signature
+// when the former is not present
+type GitHub struct {
+	Secret string
+}
+
+// Verify implements Verifier
+func (v *GitHub) Verify(msg *Message) error {
+	if v.Secret == "" {
+		return ErrMissingSecret
+	}
+	if sig := msg.Header.Get("X-Hub-Signature-256"); sig != "" {
+		return verifyHex(sha256.New, v.Secret, msg.Body, strings.TrimPrefix(sig, "sha256="))
+	}
+	if sig := msg.Header.Get("X-Hub-Signature"); sig != "" {
+		return verifyHex(sha1.New, v.Secret, msg.Body, strings.TrimPrefix(sig, "sha1="))
+	}
+	return ErrMissingSignature
+}
+
+// Stripe verifies the Stripe-Signature header (t=timestamp,v1=signature).
+// Any of several v1 signatures can match, which happens while the endpoint
+// secret is being rolled.
+type Stripe struct {
+	Secret string
+	// Tolerance defaults to DefaultTolerance, negative disables the check
+	Tolerance time.Duration
+	Now       func() time.Time
+}
+
+// Verify implements Verifier
+func (v *Stripe) Verify(msg *Message) error {
+	if v.Secret == "" {
+		return ErrMissingSecret
+	}
+	header := msg.Header.Get("Stripe-Signature")
+	if header == "" {
+		return ErrMissingSignature
+	}
+
+	var timestamp string
+	var signatures []string
+	for _, part := range strings.Split(header, ",") {
+		kv := strings.SplitN(strings.TrimSpace(part), "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		switch kv[0] {
+		case "t":
+			timestamp = kv[1]
+		case "v1":
+			signatures = append(signatures, kv[1])
+		}
+	}
+	if timestamp == "" || len(signatures) == 0 {
+		return ErrMissingSignature
+	}
+	if err := checkTimestamp(timestamp, v.Tolerance, v.Now); err != nil {
+		return err
+	}
+
+	expected := sign(sha256.New, v.Secret, []byte(timestamp+"."), msg.Body)
+	for _, sig := range signatures {
+		actual, err := hex.DecodeString(sig)
+		if err == nil && equal(expected, actual) {
+			return nil
+		}
+	}
+	return ErrInvalidSignature
+}
+
+// Slack verifies X-Slack-Signature (v0=) and X-Slack-Request-Timestamp
+type Slack struct {
+	Secret string
+	// Tolerance defaults to DefaultTolerance, negative disables the check
+	Tolerance time.Duration
+	Now       func() time.Time
+}
+
+// Verify implements Verifier
+func (v *Slack) Verify(msg *Message) error {
+	if v.Secret == "" {
+		return ErrMissingSecret
+	}
+	sig := msg.Header.Get("X-Slack-Signature")
+	timestamp := msg.Header.Get("X-Slack-Request-Timestamp")
+	if sig == "" || timestamp == "" {
+		return ErrMissingSignature
+	}
+	if !strings.HasPrefix(sig, "v0=") {
+		return fmt.Errorf("%w: unsupported version", ErrInvalidSignature)
+	}
+	if err := checkTimestamp(timestamp, v.Tolerance, v.Now); err != nil {
+		return err
+	}
+
+	expected := sign(sha256.New, v.Secret, []byte("v0:"+timestamp+":"), msg.Body)
+	return compareHex(expected, strings.TrimPrefix(sig, "v0="))
+}
+
+// Shopify verifies X-Shopify-Hmac-Sha256
+type Shopify struct {
+	Secret string
+}
+
+// Verify implements Verifier
+func (v *Shopify) Verify(msg *Message) error {
+	if v.Secret == "" {
+		return ErrMissingSecret
+	}
+	sig := msg.Header.Get("X-Shopify-Hmac-Sha256")
+	if sig == "" {
+		return ErrMissingSignature
+	}
+	return compareBase64(sign(sha256.New, v.Secret, msg.Body), sig)
+}
+
+// Twilio verifies X-Twilio-Signature. Form encoded requests are signed as
+// the URL followed by the sorted POST parameters. JSON requests sign only
+// the URL, which then carries the body hash in the bodySHA256 parameter.
+type Twilio struct {
+	AuthToken string
+}
+
+// Verify implements Verifier
+func (v *Twilio) Verify(msg *Message) error {
+	if v.AuthToken == "" {
+		return ErrMissingSecret
+	}
+	sig := msg.Header.Get("X-Twilio-Signature")
+	if sig == "" {
+		return ErrMissingSignature
+	}
+	if msg.URL == "" {
+		return fmt.Errorf("twilio signatures require the request URL")
+	}
+
+	u, err := url.Parse(msg.URL)
+	if err != nil {
+		return fmt.Errorf("invalid URL: %w", err)
+	}
+
+	if bodyHash := u.Query().Get("bodySHA256"); bodyHash != "" {
+		if err := compareBase64(sign(sha1.New, v.AuthToken, []byte(msg.URL)), sig); err != nil {
+			return err
+		}
+		sum := sha256.Sum256(msg.Body)
+		if hex.EncodeToString(sum[:]) != bodyHash {
+			return fmt.Errorf("%w: body hash mismatch", ErrInvalidSignature)
+		}
+		return nil
+	}
+
+	params, err := url.ParseQuery(string(msg.Body))
+	if err != nil {
+		return fmt.Errorf("invalid form body: %w", err)
+	}
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString(msg.URL)
+	for _, k := range keys {
+		values := params[k]
+		sort.Strings(values)
+		for _, value := range values {
+			b.WriteString(k)
+			b.WriteString(value)
+		}
+	}
+	return compareBase64(sign(sha1.New, v.AuthToken, []byte(b.String())), sig)
+}
+
+func sign(h func() hash.Hash, secret string, parts ...[]byte) []byte {
+	mac := hmac.New(h, []byte(secret))
+	for _, p := range parts {
+		mac.Write(p)
+	}
+	return mac.Sum(nil)
+}
+
+func verifyHex(h func() hash.Hash, secret string, body []byte, sig string) error {
+	return compareHex(sign(h, secret, body), sig)
+}
+
+func compareHex(expected []byte, sig string) error {
+	actual, err := hex.DecodeString(sig)
+	if err != nil || !equal(expected, actual) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+func compareBase64(expected []byte, sig string) error {
+	actual, err := base64.StdEncoding.DecodeString(sig)
+	if err != nil || !equal(expected, actual) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+func checkTimestamp(timestamp string, tolerance time.Duration, now func() time.Time) error {
+	if tolerance < 0 {
+		return nil
+	}
+	if tolerance == 0 {
+		tolerance = DefaultTolerance
+	}
+	if now == nil {
+		now = time.Now
+	}
+
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: invalid timestamp '%s'", ErrTimestamp, timestamp)
+	}
+	age := now().Sub(time.Unix(unix, 0))
+	if age > tolerance || age < -tolerance {
+		return fmt.Errorf("%w: signed %s ago", ErrTimestamp, age.Round(time.Second))
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+// Package verify checks signatures of webhooks sent by popular providers.
+// Messages can be built from an incoming *http.Request or from a relayed
+// webhook log, providers are looked up by name in a Registry:
+//
+//	msg, err := verify.FromRequest(r)
+//	if err != nil {
+//		return err
+//	}
+//	err = verify.Verify("github", secret, msg)
+package verify
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/webhookrelay/webhookrelay-go"
+)
+
+// Errors returned by verifiers
+var (
+	ErrMissingSignature = errors.New("missing signature")
+	ErrInvalidSignature = errors.New("invalid signature")
+	ErrTimestamp        = errors.New("timestamp outside of tolerance")
+	ErrUnknownProvider  = errors.New("unknown provider")
+	// ErrMissingSecret is returned instead of checking a signature against
+	// an empty key, which anyone can compute
+	ErrMissingSecret = errors.New("signing secret not specified")
+)
+
+// Message is a received webhook
+type Message struct {
+	Header http.Header
+	Body   []byte
+	// URL is the full URL the provider sent the webhook to, only required by
+	// providers that sign it (Twilio)
+	URL string
+}
+
+// FromRequest reads the request body and restores it so the request can
+// still be handled afterwards
+func FromRequest(r *http.Request) (*Message, error) {
+	var body []byte
+	if r.Body != nil {
+		var err error
+		body, err = ioutil.ReadAll(r.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read body: %w", err)
+		}
+		r.Body.Close()
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+
+	scheme := "https"
+	if r.TLS == nil && r.Header.Get("X-Forwarded-Proto") != "https" {
+		scheme = "http"
+	}
+	return &Message{
+		Header: r.Header,
+		Body:   body,
+		URL:    scheme + "://" + r.Host + r.URL.RequestURI(),
+	}, nil
+}
+
+// FromLog builds a message from a webhook log. Logs don't include the URL
+// the provider used, set it for providers that sign it.
+func FromLog(l *webhookrelay.Log) *Message {
+	return &Message{Header: l.Headers.HTTPHeader(), Body: []byte(l.Body)}
+}
+
+// Verifier checks message signatures
+type Verifier interface {
+	Verify(msg *Message) error
+}
+
+// VerifierFunc adapts a function to Verifier
+type VerifierFunc func(msg *Message) error
+
+// Verify calls f(msg)
+func (f VerifierFunc) Verify(msg *Message) error {
+	return f(msg)
+}
+
+// NewVerifierFunc creates a provider verifier for the signing secret
+type NewVerifierFunc func(secret string) Verifier
+
+// Registry maps provider names to verifiers
+type Registry struct {
+	mu        sync.RWMutex
+	providers map[string]NewVerifierFunc
+}
+
+// NewRegistry creates a registry with the built-in providers: github,
+// stripe, slack, shopify and twilio
+func NewRegistry() *Registry {
+	r := &Registry{providers: make(map[string]NewVerifierFunc)}
+	r.Register("github", func(secret string) Verifier { return &GitHub{Secret: secret} })
+	r.Register("stripe", func(secret string) Verifier { return &Stripe{Secret: secret} })
+	r.Register("slack", func(secret string) Verifier { return &Slack{Secret: secret} })
+	r.Register("shopify", func(secret string) Verifier { return &Shopify{Secret: secret} })
+	r.Register("twilio", func(secret string) Verifier { return &Twilio{AuthToken: secret} })
+	return r
+}
+
+// Register adds or replaces a provider, names are case insensitive
+func (r *Registry) Register(name string, fn NewVerifierFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.providers[strings.ToLower(name)] = fn
+}
+
+// Providers returns registered provider names
+func (r *Registry) Providers() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var names []string
+	for name := range r.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Verifier returns the provider verifier for the secret
+func (r *Registry) Verifier(provider, secret string) (Verifier, error) {
+	r.mu.RLock()
+	fn, ok := r.providers[strings.ToLower(provider)]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w '%s'", ErrUnknownProvider, provider)
+	}
+	if secret == "" {
+		return nil, fmt.Errorf("%w for '%s'", ErrMissingSecret, provider)
+	}
+	return fn(secret), nil
+}
+
+// Verify checks the message with the provider verifier
+func (r *Registry) Verify(provider, secret string, msg *Message) error {
+	v, err := r.Verifier(provider, secret)
+	if err != nil {
+		return err
+	}
+	return v.Verify(msg)
+}
+
+// DefaultRegistry is used by the package level functions
+var DefaultRegistry = NewRegistry()
+
+// Register adds a provider to the default registry
+func Register(name string, fn NewVerifierFunc) {
+	DefaultRegistry.Register(name, fn)
+}
+
+// Verify checks the message with a provider from the default registry
+func Verify(provider, secret string, msg *Message) error {
+	return DefaultRegistry.Verify(provider, secret, msg)
+}
+
+// equal compares MACs in constant time
+func equal(expected, actual []byte) bool {
+	return hmac.Equal(expected, actual)
+}
@@ -0,0 +1,258 @@
+package verify
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/webhookrelay/webhookrelay-go"
+)
+
+// slackBody is the example from Slack's request signing documentation
+const slackBody = "token=xyzz0WbapA4vBCDEFasx0q6G&team_id=T1DC2JH3J&team_domain=testteamnow&channel_id=G8PSS9T3V&channel_name=foobar&user_id=U2CERLKJA&user_name=roadrunner&command=%2Fwebhook-collect&text=&response_url=https%3A%2F%2Fhooks.slack.com%2Fcommands%2FT1DC2JH3J%2F397700885554%2F96rGlfmibIGlgcZRskXaIFfN&trigger_id=398738663015.47445629121.803a0bc887a14d10d2c447fce8b6703c"
+
+func header(kv ...string) http.Header {
+	h := http.Header{}
+	for i := 0; i < len(kv); i += 2 {
+		h.Set(kv[i], kv[i+1])
+	}
+	return h
+}
+
+func at(unix int64) func() time.Time {
+	return func() time.Time { return time.Unix(unix, 0) }
+}
+
+func TestVerifiers(t *testing.T) {
+	twilioParams := "CallSid=CA1234567890ABCDE&Caller=%2B12349013030&Digits=1234&From=%2B12349013030&To=%2B18005551212"
+
+	tests := []struct {
+		name     string
+		verifier Verifier
+		msg      *Message
+		err      error
+	}{
+		{
+			// example from GitHub's webhook documentation
+			name:     "github sha256",
+			verifier: &GitHub{Secret: "It's a Secret to Everybody"},
+			msg: &Message{
+				Header: header("X-Hub-Signature-256", "sha256=757107ea0eb2509fc211221cce984b8a37570b6d7586c22c46f4379c8b043e17"),
+				Body:   []byte("Hello, World!"),
+			},
+		},
+		{
+			name:     "github wrong secret",
+			verifier: &GitHub{Secret: "wrong"},
+			msg: &Message{
+				Header: header("X-Hub-Signature-256", "sha256=757107ea0eb2509fc211221cce984b8a37570b6d7586c22c46f4379c8b043e17"),
+				Body:   []byte("Hello, World!"),
+			},
+			err: ErrInvalidSignature,
+		},
+		{
+			name:     "github missing signature",
+			verifier: &GitHub{Secret: "secret"},
+			msg:      &Message{Header: header(), Body: []byte("Hello, World!")},
+			err:      ErrMissingSignature,
+		},
+		{
+			name:     "stripe",
+			verifier: &Stripe{Secret: "whsec_test_secret", Now: at(1700000060)},
+			msg: &Message{
+				Header: header("Stripe-Signature", "t=1700000000,v1=0000,v1=8042376f6ca064adbe037642a718227dfd59047137eb49497a54c49eb0cfb724,v0=ignored"),
+				Body:   []byte(`{"id":"evt_test","object":"event"}`),
+			},
+		},
+		{
+			name:     "stripe expired",
+			verifier: &Stripe{Secret: "whsec_test_secret", Now: at(1700000000 + 301)},
+			msg: &Message{
+				Header: header("Stripe-Signature", "t=1700000000,v1=8042376f6ca064adbe037642a718227dfd59047137eb49497a54c49eb0cfb724"),
+				Body:   []byte(`{"id":"evt_test","object":"event"}`),
+			},
+			err: ErrTimestamp,
+		},
+		{
+			name:     "stripe tampered body",
+			verifier: &Stripe{Secret: "whsec_test_secret", Tolerance: -1},
+			msg: &Message{
+				Header: header("Stripe-Signature", "t=1700000000,v1=8042376f6ca064adbe037642a718227dfd59047137eb49497a54c49eb0cfb724"),
+				Body:   []byte(`{"id":"evt_other","object":"event"}`),
+			},
+			err: ErrInvalidSignature,
+		},
+		{
+			// example from Slack's request signing documentation
+			name:     "slack",
+			verifier: &Slack{Secret: "8f742231b10e8888abcd99yyyzzz85a5", Now: at(1531420618)},
+			msg: &Message{
+				Header: header(
+					"X-Slack-Signature", "v0=a2114d57b48eac39b9ad189dd8316235a7b4a8d21a10bd27519666489c69b503",
+					"X-Slack-Request-Timestamp", "1531420618",
+				),
+				Body: []byte(slackBody),
+			},
+		},
+		{
+			name:     "slack replayed",
+			verifier: &Slack{Secret: "8f742231b10e8888abcd99yyyzzz85a5", Now: at(1531420618 + 3600)},
+			msg: &Message{
+				Header: header(
+					"X-Slack-Signature", "v0=a2114d57b48eac39b9ad189dd8316235a7b4a8d21a10bd27519666489c69b503",
+					"X-Slack-Request-Timestamp", "1531420618",
+				),
+				Body: []byte(slackBody),
+			},
+			err: ErrTimestamp,
+		},
+		{
+			name:     "shopify",
+			verifier: &Shopify{Secret: "shpss_secret"},
+			msg: &Message{
+				Header: header("X-Shopify-Hmac-Sha256", "nxtu8FJrofBssQSOisbcmD6EE6967FF6LLbtSkKgcPY="),
+				Body:   []byte(`{"id":820982911946154500,"email":"jon@example.com"}`),
+			},
+		},
+		{
+			// example from Twilio's security documentation
+			name:     "twilio",
+			verifier: &Twilio{AuthToken: "12345"},
+			msg: &Message{
+				Header: header("X-Twilio-Signature", "0/KCTR6DLpKmkAf8muzZqo1nDgQ="),
+				Body:   []byte(twilioParams),
+				URL:    "https://mycompany.com/myapp.php?foo=1&bar=2",
+			},
+		},
+		{
+			name:     "twilio different url",
+			verifier: &Twilio{AuthToken: "12345"},
+			msg: &Message{
+				Header: header("X-Twilio-Signature", "0/KCTR6DLpKmkAf8muzZqo1nDgQ="),
+				Body:   []byte(twilioParams),
+				URL:    "https://mycompany.com/myapp.php?foo=1&bar=3",
+			},
+			err: ErrInvalidSignature,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.verifier.Verify(tt.msg)
+			if tt.err == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.True(t, errors.Is(err, tt.err), "expected %v, got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestEmptySecret(t *testing.T) {
+	body := []byte("Hello, World!")
+	// signatures anyone can make when the secret is not configured
+	verifiers := map[string]struct {
+		verifier Verifier
+		msg      *Message
+	}{
+		"github": {&GitHub{}, &Message{
+			Header: header("X-Hub-Signature-256", "sha256="+hex.EncodeToString(sign(sha256.New, "", body))),
+			Body:   body,
+		}},
+		"stripe": {&Stripe{Tolerance: -1}, &Message{
+			Header: header("Stripe-Signature", "t=1700000000,v1="+hex.EncodeToString(sign(sha256.New, "", []byte("1700000000."), body))),
+			Body:   body,
+		}},
+		"slack": {&Slack{Tolerance: -1}, &Message{
+			Header: header(
+				"X-Slack-Signature", "v0="+hex.EncodeToString(sign(sha256.New, "", []byte("v0:1700000000:"), body)),
+				"X-Slack-Request-Timestamp", "1700000000",
+			),
+			Body: body,
+		}},
+		"shopify": {&Shopify{}, &Message{
+			Header: header("X-Shopify-Hmac-Sha256", base64.StdEncoding.EncodeToString(sign(sha256.New, "", body))),
+			Body:   body,
+		}},
+		"twilio": {&Twilio{}, &Message{
+			Header: header("X-Twilio-Signature", base64.StdEncoding.EncodeToString(sign(sha1.New, "", []byte("https://example.com/hook")))),
+			URL:    "https://example.com/hook",
+		}},
+	}
+
+	for name, tt := range verifiers {
+		t.Run(name, func(t *testing.T) {
+			assert.True(t, errors.Is(tt.verifier.Verify(tt.msg), ErrMissingSecret))
+			assert.True(t, errors.Is(Verify(name, "", tt.msg), ErrMissingSecret))
+		})
+	}
+}
+
+func TestFromRequest(t *testing.T) {
+	var verified error
+	var body []byte
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		msg, err := FromRequest(r)
+		require.NoError(t, err)
+		verified = Verify("github", "It's a Secret to Everybody", msg)
+		body, _ = ioutil.ReadAll(r.Body)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "https://hooks.example.com/github", strings.NewReader("Hello, World!"))
+	req.Header.Set("X-Hub-Signature-256", "sha256=757107ea0eb2509fc211221cce984b8a37570b6d7586c22c46f4379c8b043e17")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.NoError(t, verified)
+	assert.Equal(t, "Hello, World!", string(body), "body can be read again")
+}
+
+func TestFromLog(t *testing.T) {
+	msg := FromLog(&webhookrelay.Log{
+		Headers: webhookrelay.Headers{"X-Shopify-Hmac-Sha256": []interface{}{"nxtu8FJrofBssQSOisbcmD6EE6967FF6LLbtSkKgcPY="}},
+		Body:    `{"id":820982911946154500,"email":"jon@example.com"}`,
+	})
+	assert.NoError(t, Verify("Shopify", "shpss_secret", msg))
+}
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry()
+	assert.Equal(t, []string{"github", "shopify", "slack", "stripe", "twilio"}, r.Providers())
+
+	_, err := r.Verifier("gitlab", "token")
+	assert.True(t, errors.Is(err, ErrUnknownProvider))
+
+	r.Register("gitlab", func(secret string) Verifier {
+		return VerifierFunc(func(msg *Message) error {
+			if msg.Header.Get("X-Gitlab-Token") != secret {
+				return ErrInvalidSignature
+			}
+			return nil
+		})
+	})
+	assert.NoError(t, r.Verify("gitlab", "token", &Message{Header: header("X-Gitlab-Token", "token")}))
+	assert.Equal(t, ErrInvalidSignature, r.Verify("gitlab", "token", &Message{Header: header()}))
+}
+
+func TestTwilioJSON(t *testing.T) {
+	v := &Twilio{AuthToken: "12345"}
+	msg := &Message{
+		Header: header("X-Twilio-Signature", "4gh0yBeWtC7BeUYJ3xBAYeij4VM="),
+		Body:   []byte(`{"CallSid":"CA1234567890ABCDE"}`),
+		URL:    "https://mycompany.com/myapp?bodySHA256=e852ec28d46c49841e1e6687b51c3dbf2b3da45f0b5f73184b3220daaa45ab3b",
+	}
+	assert.NoError(t, v.Verify(msg))
+
+	msg.Body = []byte(`{"CallSid":"CA0000000000"}`)
+	assert.True(t, errors.Is(v.Verify(msg), ErrInvalidSignature), "body must match the signed hash")
+}
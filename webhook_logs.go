@@ -3,6 +3,7 @@ package webhookrelay
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/url"
 	"strconv"
@@ -20,6 +21,9 @@ const (
 	RequestStatusFailed   RequestStatus = "failed"
 	RequestStatusStalled  RequestStatus = "stalled"
 	RequestStatusRejected RequestStatus = "rejected"
+	// RequestStatusDeadLetter - delivery retries were exhausted, see
+	// DeadLetterPolicy
+	RequestStatusDeadLetter RequestStatus = "dead_letter"
 )
 
 // Log - received webhook event
@@ -58,6 +62,28 @@ type Log struct {
 // Headers - headers are used to store request header info in the webhook log
 type Headers map[string]interface{}
 
+// HTTPHeader converts the logged headers, values can be a string or a list
+func (h Headers) HTTPHeader() http.Header {
+	header := http.Header{}
+	for key, value := range h {
+		switch v := value.(type) {
+		case string:
+			header.Add(key, v)
+		case []string:
+			for _, s := range v {
+				header.Add(key, s)
+			}
+		case []interface{}:
+			for _, s := range v {
+				header.Add(key, fmt.Sprint(s))
+			}
+		default:
+			header.Add(key, fmt.Sprint(v))
+		}
+	}
+	return header
+}
+
 // MarshalJSON converst Go time into unix time
 func (l *Log) MarshalJSON() ([]byte, error) {
 	type Alias Log
@@ -101,6 +127,7 @@ func (l *Log) UnmarshalJSON(data []byte) error {
 // WebhookLogsListOptions - list logs options
 type WebhookLogsListOptions struct {
 	BucketID string
+	OutputID string
 	Status   RequestStatus
 	From     *time.Time
 	To       *time.Time
@@ -119,7 +146,12 @@ type WebhookLogsResponse struct {
 // ListWebhookLogs lists webhook logs for an account
 func (api *API) ListWebhookLogs(options *WebhookLogsListOptions) (*WebhookLogsResponse, error) {
 
-	resp, err := api.makeRequest(http.MethodGet, "/logs", nil)
+	uri := "/logs"
+	if query := getQuery(options); query != "" {
+		uri += "?" + query
+	}
+
+	resp, err := api.makeRequest(http.MethodGet, uri, nil)
 	if err != nil {
 		return nil, errors.Wrap(err, errMakeRequestError)
 	}
@@ -142,6 +174,10 @@ func getQuery(options *WebhookLogsListOptions) string {
 		q.Add("bucket", options.BucketID)
 	}
 
+	if options.OutputID != "" {
+		q.Add("output", options.OutputID)
+	}
+
 	if options.Status != "" {
 		q.Add("status", string(options.Status))
 	}
@@ -1,7 +1,41 @@
 //go:generate jsonenums -type=RequestStatus
 package webhookrelay
 
-import "testing"
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListWebhookLogs(t *testing.T) {
+	client := getIntegrationTestClient(t, "list_webhook_logs")
+
+	buckets, err := client.ListBuckets(&BucketListOptions{})
+	require.NoError(t, err)
+	var bucket *Bucket
+	for _, b := range buckets {
+		if len(b.Inputs) > 0 {
+			bucket = b
+			break
+		}
+	}
+	require.NotNil(t, bucket, "a bucket with inputs is required")
+
+	logs, err := client.ListWebhookLogs(&WebhookLogsListOptions{
+		BucketID: bucket.ID,
+		Limit:    2,
+	})
+	require.NoError(t, err)
+	assert.True(t, logs.Total >= len(logs.Data))
+	require.Len(t, logs.Data, 2)
+
+	for _, l := range logs.Data {
+		assert.Equal(t, bucket.ID, l.BucketID)
+		assert.False(t, l.CreatedAt.IsZero())
+		assert.NotEmpty(t, l.Status)
+	}
+}
 
 func Test_getQuery(t *testing.T) {
 	type args struct {
@@ -24,6 +58,15 @@ func Test_getQuery(t *testing.T) {
 			}},
 			want: "limit=100",
 		},
+		{
+			name: "dead letters of an output",
+			args: args{options: &WebhookLogsListOptions{
+				BucketID: "b-1",
+				OutputID: "o-1",
+				Status:   RequestStatusDeadLetter,
+			}},
+			want: "bucket=b-1&output=o-1&status=dead_letter",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -13,6 +13,7 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/pkg/errors"
@@ -25,6 +26,8 @@ const userAgent = "webhookrelay-go/v1"
 const (
 	// AuthToken specifies that we should authenticate with an API key & secret
 	AuthToken = 1 << iota
+	// AuthBearer specifies that we should authenticate with a bearer token
+	AuthBearer
 )
 
 // New creates a new Webhook Relay v1 API client.
@@ -44,20 +47,51 @@ func New(key, secret string, opts ...Option) (*API, error) {
 	return api, nil
 }
 
+// NewWithCredentials creates a new Webhook Relay v1 API client that gets
+// credentials from the provider before each request, e.g. to pick up
+// rotated credentials in long-running processes.
+func NewWithCredentials(provider CredentialProvider, opts ...Option) (*API, error) {
+	if provider == nil {
+		return nil, ErrEmptyCredentials
+	}
+
+	api, err := newClient(opts...)
+	if err != nil {
+		return nil, err
+	}
+	api.credentials = provider
+
+	return api, nil
+}
+
 // API holds the configuration for the current API client. A client should not
 // be modified concurrently.
 type API struct {
+	// APIKey and APISecret are used when the client has no credential
+	// provider
 	APIKey    string
 	APISecret string
 	BaseURL   string
 	UserAgent string
+	// WebhooksURL is the base URL of input endpoints without a custom
+	// domain, defaults to BaseURL + "/webhooks"
+	WebhooksURL string
 
-	authType    int
+	credentials CredentialProvider
 	httpClient  *http.Client
 	headers     http.Header
 	retryPolicy RetryPolicy
 	rateLimiter *rate.Limiter
 	logger      Logger
+
+	resolver    Resolver
+	resolverTTL time.Duration
+	strictIDs   bool
+
+	regionsMu sync.Mutex
+	regions   []*Region
+	// tunnelRegion is set on tunnels created without a region
+	tunnelRegion string
 }
 
 // newClient provides shared logic
@@ -67,7 +101,6 @@ func newClient(opts ...Option) (*API, error) {
 	api := &API{
 		BaseURL:     apiURL,
 		headers:     make(http.Header),
-		authType:    AuthToken,
 		UserAgent:   userAgent,
 		rateLimiter: rate.NewLimiter(rate.Limit(4), 1), // 4rps equates to default api limit (1200 req/5 min)
 		retryPolicy: RetryPolicy{
@@ -75,7 +108,8 @@ func newClient(opts ...Option) (*API, error) {
 			MinRetryDelay: time.Duration(1) * time.Second,
 			MaxRetryDelay: time.Duration(30) * time.Second,
 		},
-		logger: silentLogger,
+		logger:      silentLogger,
+		resolverTTL: defaultResolverCacheTTL,
 	}
 
 	err := api.parseOptions(opts...)
@@ -89,6 +123,10 @@ func newClient(opts ...Option) (*API, error) {
 		api.httpClient = http.DefaultClient
 	}
 
+	if api.resolver == nil {
+		api.resolver = NewCachingResolver(api, api.resolverTTL)
+	}
+
 	return api, nil
 }
 
@@ -123,6 +161,16 @@ type RetryPolicy struct {
 	MaxRetryDelay time.Duration
 }
 
+func (p RetryPolicy) validate() error {
+	if p.MaxRetries < 0 || p.MinRetryDelay < 0 || p.MaxRetryDelay < 0 {
+		return fmt.Errorf("retry policy values must not be negative")
+	}
+	if p.MaxRetryDelay < p.MinRetryDelay {
+		return fmt.Errorf("max retry delay is shorter than min retry delay")
+	}
+	return nil
+}
+
 // Logger defines the interface this library needs to use logging
 // This is a subset of the methods implemented in the log package
 type Logger interface {
@@ -132,18 +180,14 @@ type Logger interface {
 // makeRequest makes a HTTP request and returns the body as a byte slice,
 // closing it before returning. params will be serialized to JSON.
 func (api *API) makeRequest(method, uri string, params interface{}) ([]byte, error) {
-	return api.makeRequestWithAuthType(context.TODO(), method, uri, params, api.authType)
+	return api.makeRequestWithHeaders(context.TODO(), method, uri, params, nil)
 }
 
 func (api *API) makeRequestContext(ctx context.Context, method, uri string, params interface{}) ([]byte, error) {
-	return api.makeRequestWithAuthType(ctx, method, uri, params, api.authType)
-}
-
-func (api *API) makeRequestWithAuthType(ctx context.Context, method, uri string, params interface{}, authType int) ([]byte, error) {
-	return api.makeRequestWithAuthTypeAndHeaders(ctx, method, uri, params, authType, nil)
+	return api.makeRequestWithHeaders(ctx, method, uri, params, nil)
 }
 
-func (api *API) makeRequestWithAuthTypeAndHeaders(ctx context.Context, method, uri string, params interface{}, authType int, headers http.Header) ([]byte, error) {
+func (api *API) makeRequestWithHeaders(ctx context.Context, method, uri string, params interface{}, headers http.Header) ([]byte, error) {
 	// Replace nil with a JSON object if needed
 	var jsonBody []byte
 	var err error
@@ -165,6 +209,7 @@ func (api *API) makeRequestWithAuthTypeAndHeaders(ctx context.Context, method, u
 	var respErr error
 	var reqBody io.Reader
 	var respBody []byte
+	refreshed := false
 	for i := 0; i <= api.retryPolicy.MaxRetries; i++ {
 		if jsonBody != nil {
 			reqBody = bytes.NewReader(jsonBody)
@@ -187,7 +232,20 @@ func (api *API) makeRequestWithAuthTypeAndHeaders(ctx context.Context, method, u
 		if err != nil {
 			return nil, errors.Wrap(err, "Error caused by request rate limiting")
 		}
-		resp, respErr = api.request(ctx, method, uri, reqBody, authType, headers)
+		creds, err := api.getCredentials(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get credentials")
+		}
+		resp, respErr = api.request(ctx, method, uri, reqBody, creds, headers)
+
+		// credentials might have been rotated, retry once with fresh ones
+		if respErr == nil && resp.StatusCode == http.StatusUnauthorized && !refreshed && api.invalidateCredentials() {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+			refreshed = true
+			i--
+			continue
+		}
 
 		// retry if the server is rate limiting us or if it failed
 		// assumes server operations are rolled back on failure
@@ -219,6 +277,12 @@ func (api *API) makeRequestWithAuthTypeAndHeaders(ctx context.Context, method, u
 		return nil, respErr
 	}
 
+	if resp.StatusCode == http.StatusNotFound {
+		if nf := notFoundFromURI(uri); nf != nil {
+			return nil, nf
+		}
+	}
+
 	switch {
 	case resp.StatusCode >= http.StatusOK && resp.StatusCode < http.StatusMultipleChoices:
 	case resp.StatusCode == http.StatusUnauthorized:
@@ -226,7 +290,7 @@ func (api *API) makeRequestWithAuthTypeAndHeaders(ctx context.Context, method, u
 	case resp.StatusCode == http.StatusForbidden:
 		return nil, errors.Errorf("HTTP status %d: insufficient permissions", resp.StatusCode)
 	case resp.StatusCode == http.StatusPreconditionFailed:
-		return nil, errors.Errorf("HTTP status %d: precondition failed", resp.StatusCode)
+		return nil, errors.Wrapf(ErrPreconditionFailed, "HTTP status %d", resp.StatusCode)
 	case resp.StatusCode == http.StatusPaymentRequired:
 		return nil, errors.Errorf("HTTP status %d: feature not available for your subscription", resp.StatusCode)
 	case resp.StatusCode == http.StatusServiceUnavailable,
@@ -252,7 +316,7 @@ func (api *API) makeRequestWithAuthTypeAndHeaders(ctx context.Context, method, u
 // request makes a HTTP request to the given API endpoint, returning the raw
 // *http.Response, or an error if one occurred. The caller is responsible for
 // closing the response body.
-func (api *API) request(ctx context.Context, method, uri string, reqBody io.Reader, authType int, headers http.Header) (*http.Response, error) {
+func (api *API) request(ctx context.Context, method, uri string, reqBody io.Reader, creds *Credentials, headers http.Header) (*http.Response, error) {
 	req, err := http.NewRequest(method, api.BaseURL+uri, reqBody)
 	if err != nil {
 		return nil, errors.Wrap(err, "HTTP request creation failed")
@@ -264,7 +328,12 @@ func (api *API) request(ctx context.Context, method, uri string, reqBody io.Read
 	copyHeader(combinedHeaders, headers)
 	req.Header = combinedHeaders
 
-	req.SetBasicAuth(api.APIKey, api.APISecret)
+	switch creds.AuthType() {
+	case AuthBearer:
+		req.Header.Set("Authorization", "Bearer "+creds.Token)
+	default:
+		req.SetBasicAuth(creds.Key, creds.Secret)
+	}
 
 	if api.UserAgent != "" {
 		req.Header.Set("User-Agent", api.UserAgent)
@@ -282,6 +351,32 @@ func (api *API) request(ctx context.Context, method, uri string, reqBody io.Read
 	return resp, nil
 }
 
+// getCredentials returns credentials from the provider or the static
+// key and secret
+func (api *API) getCredentials(ctx context.Context) (*Credentials, error) {
+	if api.credentials == nil {
+		return &Credentials{Key: api.APIKey, Secret: api.APISecret}, nil
+	}
+	creds, err := api.credentials.Credentials(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := creds.Validate(); err != nil {
+		return nil, err
+	}
+	return creds, nil
+}
+
+// invalidateCredentials drops cached credentials, it reports false if the
+// provider doesn't cache them
+func (api *API) invalidateCredentials() bool {
+	if !canInvalidate(api.credentials) {
+		return false
+	}
+	api.credentials.(credentialInvalidator).Invalidate()
+	return true
+}
+
 // copyHeader copies all headers for `source` and sets them on `target`.
 // based on https://godoc.org/github.com/golang/gddo/httputil/header#Copy
 func copyHeader(target, source http.Header) {
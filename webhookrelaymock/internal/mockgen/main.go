@@ -0,0 +1,251 @@
+// Command mockgen generates function field fakes for the interfaces
+// declared in a webhookrelay source file.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	pkgPath  = "github.com/webhookrelay/webhookrelay-go"
+	pkgName  = "webhookrelay"
+	mockName = "webhookrelaymock"
+)
+
+func main() {
+	source := flag.String("source", "../services.go", "file with the interfaces")
+	output := flag.String("o", "services_gen.go", "output file")
+	flag.Parse()
+
+	code, err := generate(*source)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(*output, code, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+type method struct {
+	name    string
+	params  []param
+	results string
+}
+
+type param struct {
+	name     string
+	typ      string
+	variadic bool
+}
+
+func generate(source string) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, source, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	imports := map[string]string{pkgName: pkgPath}
+	for _, spec := range file.Imports {
+		path, _ := strconv.Unquote(spec.Path.Value)
+		name := path[strings.LastIndex(path, "/")+1:]
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		imports[name] = path
+	}
+	used := map[string]bool{pkgName: true}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by mockgen from %s. DO NOT EDIT.\n\npackage %s\n\n", filepath.Base(source), mockName)
+	body := &bytes.Buffer{}
+
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			ts := spec.(*ast.TypeSpec)
+			iface, ok := ts.Type.(*ast.InterfaceType)
+			if !ok || !ts.Name.IsExported() {
+				continue
+			}
+			var methods []method
+			for _, field := range iface.Methods.List {
+				fn, ok := field.Type.(*ast.FuncType)
+				if !ok {
+					return nil, fmt.Errorf("%s: embedded interfaces are not supported", ts.Name.Name)
+				}
+				methods = append(methods, newMethod(field.Names[0].Name, fn, used))
+			}
+			writeFake(body, ts.Name.Name, methods)
+		}
+	}
+
+	var names []string
+	for name := range used {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(a, b int) bool { return imports[names[a]] < imports[names[b]] })
+	buf.WriteString("import (\n")
+	// standard library first
+	for _, std := range []bool{true, false} {
+		if !std {
+			buf.WriteString("\n")
+		}
+		for _, name := range names {
+			path := imports[name]
+			if isStd(path) != std {
+				continue
+			}
+			if path[strings.LastIndex(path, "/")+1:] == name {
+				fmt.Fprintf(&buf, "\t%q\n", path)
+			} else {
+				fmt.Fprintf(&buf, "\t%s %q\n", name, path)
+			}
+		}
+	}
+	buf.WriteString(")\n")
+	buf.Write(body.Bytes())
+
+	return format.Source(buf.Bytes())
+}
+
+func newMethod(name string, fn *ast.FuncType, used map[string]bool) method {
+	m := method{name: name}
+	for idx, field := range fn.Params.List {
+		typ := field.Type
+		variadic := false
+		if ellipsis, ok := typ.(*ast.Ellipsis); ok {
+			typ = ellipsis.Elt
+			variadic = true
+		}
+		typeStr := exprString(qualify(typ, used))
+		if len(field.Names) == 0 {
+			m.params = append(m.params, param{name: fmt.Sprintf("arg%d", idx), typ: typeStr, variadic: variadic})
+		}
+		for _, n := range field.Names {
+			m.params = append(m.params, param{name: n.Name, typ: typeStr, variadic: variadic})
+		}
+	}
+	if fn.Results != nil {
+		var results []string
+		for _, field := range fn.Results.List {
+			results = append(results, exprString(qualify(field.Type, used)))
+		}
+		m.results = strings.Join(results, ", ")
+		if len(results) > 1 {
+			m.results = "(" + m.results + ")"
+		}
+	}
+	return m
+}
+
+// qualify prefixes exported identifiers declared in the source package
+// with the package name and records used imports
+func qualify(expr ast.Expr, used map[string]bool) ast.Expr {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		if e.IsExported() {
+			return &ast.SelectorExpr{X: ast.NewIdent(pkgName), Sel: ast.NewIdent(e.Name)}
+		}
+		return ast.NewIdent(e.Name)
+	case *ast.SelectorExpr:
+		pkg := e.X.(*ast.Ident).Name
+		used[pkg] = true
+		return &ast.SelectorExpr{X: ast.NewIdent(pkg), Sel: ast.NewIdent(e.Sel.Name)}
+	case *ast.StarExpr:
+		return &ast.StarExpr{X: qualify(e.X, used)}
+	case *ast.ArrayType:
+		return &ast.ArrayType{Len: e.Len, Elt: qualify(e.Elt, used)}
+	case *ast.MapType:
+		return &ast.MapType{Key: qualify(e.Key, used), Value: qualify(e.Value, used)}
+	case *ast.ChanType:
+		return &ast.ChanType{Dir: e.Dir, Value: qualify(e.Value, used)}
+	case *ast.FuncType:
+		return &ast.FuncType{Params: qualifyFields(e.Params, used), Results: qualifyFields(e.Results, used)}
+	}
+	return expr
+}
+
+func qualifyFields(fields *ast.FieldList, used map[string]bool) *ast.FieldList {
+	if fields == nil {
+		return nil
+	}
+	result := &ast.FieldList{}
+	for _, f := range fields.List {
+		result.List = append(result.List, &ast.Field{Names: f.Names, Type: qualify(f.Type, used)})
+	}
+	return result
+}
+
+// exprString prints the expression, qualify drops source positions so it
+// is printed on one line
+func exprString(expr ast.Expr) string {
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, token.NewFileSet(), expr); err != nil {
+		panic(err)
+	}
+	return buf.String()
+}
+
+func writeFake(w *bytes.Buffer, name string, methods []method) {
+	fmt.Fprintf(w, "\n// %s is a fake %s.%s. Set the Func field of each\n", name, pkgName, name)
+	fmt.Fprintf(w, "// method the code under test calls, calling a method without one panics.\n")
+	fmt.Fprintf(w, "type %s struct {\n\tCallRecorder\n\n", name)
+	for _, m := range methods {
+		fmt.Fprintf(w, "\t%sFunc func(%s) %s\n", m.name, m.signature(), m.results)
+	}
+	fmt.Fprintf(w, "}\n\nvar _ %s.%s = (*%s)(nil)\n", pkgName, name, name)
+
+	for _, m := range methods {
+		var args, names []string
+		for _, p := range m.params {
+			names = append(names, p.name)
+			if p.variadic {
+				args = append(args, p.name+"...")
+			} else {
+				args = append(args, p.name)
+			}
+		}
+		fmt.Fprintf(w, "\n// %s calls %sFunc\n", m.name, m.name)
+		fmt.Fprintf(w, "func (m *%s) %s(%s) %s {\n", name, m.name, m.signature(), m.results)
+		fmt.Fprintf(w, "\tm.record(%q, %s)\n", m.name, strings.Join(names, ", "))
+		fmt.Fprintf(w, "\tif m.%sFunc == nil {\n\t\tpanic(%q)\n\t}\n", m.name, fmt.Sprintf("%s.%s called but %sFunc is not set", name, m.name, m.name))
+		if m.results == "" {
+			fmt.Fprintf(w, "\tm.%sFunc(%s)\n}\n", m.name, strings.Join(args, ", "))
+		} else {
+			fmt.Fprintf(w, "\treturn m.%sFunc(%s)\n}\n", m.name, strings.Join(args, ", "))
+		}
+	}
+}
+
+func isStd(path string) bool {
+	return !strings.Contains(strings.Split(path, "/")[0], ".")
+}
+
+func (m method) signature() string {
+	var params []string
+	for _, p := range m.params {
+		if p.variadic {
+			params = append(params, p.name+" ..."+p.typ)
+		} else {
+			params = append(params, p.name+" "+p.typ)
+		}
+	}
+	return strings.Join(params, ", ")
+}
@@ -0,0 +1,18 @@
+package main
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGeneratedUpToDate(t *testing.T) {
+	code, err := generate("../../../services.go")
+	require.NoError(t, err)
+
+	current, err := os.ReadFile("../../services_gen.go")
+	require.NoError(t, err)
+	assert.Equal(t, string(current), string(code), "services_gen.go is out of date, run go generate ./webhookrelaymock")
+}
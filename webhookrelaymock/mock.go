@@ -0,0 +1,61 @@
+// Package webhookrelaymock provides fakes of the webhookrelay service
+// interfaces for unit tests:
+//
+//	buckets := &webhookrelaymock.BucketService{
+//		GetBucketFunc: func(ref string) (*webhookrelay.Bucket, error) {
+//			return &webhookrelay.Bucket{ID: "b-1", Name: ref}, nil
+//		},
+//	}
+//	err := codeUnderTest(buckets)
+//	calls := buckets.Calls("GetBucket")
+//
+// For tests against a fake API server see the webhookrelaytest package.
+package webhookrelaymock
+
+//go:generate go run ./internal/mockgen -source ../services.go -o services_gen.go
+
+import "sync"
+
+// Call is a recorded method call
+type Call struct {
+	Method string
+	Args   []interface{}
+}
+
+// CallRecorder records calls of fake methods, it is embedded in every fake
+type CallRecorder struct {
+	mu    sync.Mutex
+	calls []Call
+}
+
+func (r *CallRecorder) record(method string, args ...interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, Call{Method: method, Args: args})
+}
+
+// Calls returns calls of the method in order, all calls if method is empty
+func (r *CallRecorder) Calls(method string) []Call {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var calls []Call
+	for _, c := range r.calls {
+		if method == "" || c.Method == method {
+			calls = append(calls, c)
+		}
+	}
+	return calls
+}
+
+// CallCount returns the number of calls of the method
+func (r *CallRecorder) CallCount(method string) int {
+	return len(r.Calls(method))
+}
+
+// Reset forgets recorded calls
+func (r *CallRecorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = nil
+}
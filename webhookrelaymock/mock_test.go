@@ -0,0 +1,59 @@
+package webhookrelaymock
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/webhookrelay/webhookrelay-go"
+)
+
+// disableOutputs is an example of code that depends on service interfaces
+func disableOutputs(buckets webhookrelay.BucketService, outputs webhookrelay.OutputService, bucketRef string) error {
+	bucket, err := buckets.GetBucket(bucketRef)
+	if err != nil {
+		return err
+	}
+	for _, o := range bucket.Outputs {
+		_, err := outputs.PatchOutput(bucket.ID, o.ID, &webhookrelay.OutputPatch{Disabled: webhookrelay.Bool(true)}, nil)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestFakes(t *testing.T) {
+	buckets := &BucketService{
+		GetBucketFunc: func(ref string) (*webhookrelay.Bucket, error) {
+			return &webhookrelay.Bucket{ID: "b-1", Name: ref, Outputs: []*webhookrelay.Output{{ID: "o-1"}, {ID: "o-2"}}}, nil
+		},
+	}
+	outputs := &OutputService{
+		PatchOutputFunc: func(bucketRef, outputRef string, patch *webhookrelay.OutputPatch, opts *webhookrelay.PatchOptions) (*webhookrelay.Output, error) {
+			if outputRef == "o-2" {
+				return nil, errors.New("boom")
+			}
+			return &webhookrelay.Output{ID: outputRef, Disabled: *patch.Disabled}, nil
+		},
+	}
+
+	err := disableOutputs(buckets, outputs, "github")
+	assert.EqualError(t, err, "boom")
+
+	assert.Equal(t, 1, buckets.CallCount("GetBucket"))
+	calls := outputs.Calls("PatchOutput")
+	require.Len(t, calls, 2)
+	assert.Equal(t, "b-1", calls[0].Args[0])
+	assert.Equal(t, "o-2", calls[1].Args[1])
+	assert.Len(t, outputs.Calls(""), 2)
+
+	outputs.Reset()
+	assert.Equal(t, 0, outputs.CallCount("PatchOutput"))
+
+	assert.PanicsWithValue(t, "BucketService.ListBuckets called but ListBucketsFunc is not set", func() {
+		_, _ = buckets.ListBuckets(&webhookrelay.BucketListOptions{})
+	})
+}
@@ -0,0 +1,622 @@
+// Code generated by mockgen from services.go. DO NOT EDIT.
+
+package webhookrelaymock
+
+import (
+	"context"
+	"net/url"
+
+	webhookrelay "github.com/webhookrelay/webhookrelay-go"
+)
+
+// BucketService is a fake webhookrelay.BucketService. Set the Func field of each
+// method the code under test calls, calling a method without one panics.
+type BucketService struct {
+	CallRecorder
+
+	ListBucketsFunc    func(options *webhookrelay.BucketListOptions) ([]*webhookrelay.Bucket, error)
+	GetBucketFunc      func(ref string) (*webhookrelay.Bucket, error)
+	CreateBucketFunc   func(options *webhookrelay.BucketCreateOptions) (*webhookrelay.Bucket, error)
+	UpdateBucketFunc   func(options *webhookrelay.Bucket) (*webhookrelay.Bucket, error)
+	PatchBucketFunc    func(ref string, patch *webhookrelay.BucketPatch, opts *webhookrelay.PatchOptions) (*webhookrelay.Bucket, error)
+	DeleteBucketFunc   func(options *webhookrelay.BucketDeleteOptions) error
+	CloneBucketFunc    func(ctx context.Context, srcRef string, newName string, opts *webhookrelay.BucketCloneOptions) (*webhookrelay.Bucket, error)
+	SuspendBucketFunc  func(ref string) (*webhookrelay.Bucket, error)
+	ResumeBucketFunc   func(ref string) (*webhookrelay.Bucket, error)
+	GetBucketUsageFunc func(ref string) (*webhookrelay.BucketUsage, error)
+}
+
+var _ webhookrelay.BucketService = (*BucketService)(nil)
+
+// ListBuckets calls ListBucketsFunc
+func (m *BucketService) ListBuckets(options *webhookrelay.BucketListOptions) ([]*webhookrelay.Bucket, error) {
+	m.record("ListBuckets", options)
+	if m.ListBucketsFunc == nil {
+		panic("BucketService.ListBuckets called but ListBucketsFunc is not set")
+	}
+	return m.ListBucketsFunc(options)
+}
+
+// GetBucket calls GetBucketFunc
+func (m *BucketService) GetBucket(ref string) (*webhookrelay.Bucket, error) {
+	m.record("GetBucket", ref)
+	if m.GetBucketFunc == nil {
+		panic("BucketService.GetBucket called but GetBucketFunc is not set")
+	}
+	return m.GetBucketFunc(ref)
+}
+
+// CreateBucket calls CreateBucketFunc
+func (m *BucketService) CreateBucket(options *webhookrelay.BucketCreateOptions) (*webhookrelay.Bucket, error) {
+	m.record("CreateBucket", options)
+	if m.CreateBucketFunc == nil {
+		panic("BucketService.CreateBucket called but CreateBucketFunc is not set")
+	}
+	return m.CreateBucketFunc(options)
+}
+
+// UpdateBucket calls UpdateBucketFunc
+func (m *BucketService) UpdateBucket(options *webhookrelay.Bucket) (*webhookrelay.Bucket, error) {
+	m.record("UpdateBucket", options)
+	if m.UpdateBucketFunc == nil {
+		panic("BucketService.UpdateBucket called but UpdateBucketFunc is not set")
+	}
+	return m.UpdateBucketFunc(options)
+}
+
+// PatchBucket calls PatchBucketFunc
+func (m *BucketService) PatchBucket(ref string, patch *webhookrelay.BucketPatch, opts *webhookrelay.PatchOptions) (*webhookrelay.Bucket, error) {
+	m.record("PatchBucket", ref, patch, opts)
+	if m.PatchBucketFunc == nil {
+		panic("BucketService.PatchBucket called but PatchBucketFunc is not set")
+	}
+	return m.PatchBucketFunc(ref, patch, opts)
+}
+
+// DeleteBucket calls DeleteBucketFunc
+func (m *BucketService) DeleteBucket(options *webhookrelay.BucketDeleteOptions) error {
+	m.record("DeleteBucket", options)
+	if m.DeleteBucketFunc == nil {
+		panic("BucketService.DeleteBucket called but DeleteBucketFunc is not set")
+	}
+	return m.DeleteBucketFunc(options)
+}
+
+// CloneBucket calls CloneBucketFunc
+func (m *BucketService) CloneBucket(ctx context.Context, srcRef string, newName string, opts *webhookrelay.BucketCloneOptions) (*webhookrelay.Bucket, error) {
+	m.record("CloneBucket", ctx, srcRef, newName, opts)
+	if m.CloneBucketFunc == nil {
+		panic("BucketService.CloneBucket called but CloneBucketFunc is not set")
+	}
+	return m.CloneBucketFunc(ctx, srcRef, newName, opts)
+}
+
+// SuspendBucket calls SuspendBucketFunc
+func (m *BucketService) SuspendBucket(ref string) (*webhookrelay.Bucket, error) {
+	m.record("SuspendBucket", ref)
+	if m.SuspendBucketFunc == nil {
+		panic("BucketService.SuspendBucket called but SuspendBucketFunc is not set")
+	}
+	return m.SuspendBucketFunc(ref)
+}
+
+// ResumeBucket calls ResumeBucketFunc
+func (m *BucketService) ResumeBucket(ref string) (*webhookrelay.Bucket, error) {
+	m.record("ResumeBucket", ref)
+	if m.ResumeBucketFunc == nil {
+		panic("BucketService.ResumeBucket called but ResumeBucketFunc is not set")
+	}
+	return m.ResumeBucketFunc(ref)
+}
+
+// GetBucketUsage calls GetBucketUsageFunc
+func (m *BucketService) GetBucketUsage(ref string) (*webhookrelay.BucketUsage, error) {
+	m.record("GetBucketUsage", ref)
+	if m.GetBucketUsageFunc == nil {
+		panic("BucketService.GetBucketUsage called but GetBucketUsageFunc is not set")
+	}
+	return m.GetBucketUsageFunc(ref)
+}
+
+// InputService is a fake webhookrelay.InputService. Set the Func field of each
+// method the code under test calls, calling a method without one panics.
+type InputService struct {
+	CallRecorder
+
+	ListInputsFunc         func(options *webhookrelay.InputListOptions) ([]*webhookrelay.Input, error)
+	CreateInputFunc        func(options *webhookrelay.Input) (*webhookrelay.Input, error)
+	UpdateInputFunc        func(options *webhookrelay.Input) (*webhookrelay.Input, error)
+	PatchInputFunc         func(bucketRef string, inputRef string, patch *webhookrelay.InputPatch, opts *webhookrelay.PatchOptions) (*webhookrelay.Input, error)
+	DeleteInputFunc        func(options *webhookrelay.InputDeleteOptions) error
+	SetInputResponseFunc   func(bucketRef string, inputRef string, resp *webhookrelay.InputResponse) (*webhookrelay.Input, error)
+	AllowInputIPRangesFunc func(bucketRef string, inputRef string, ranges []string) (*webhookrelay.Input, error)
+	InputURLFunc           func(input *webhookrelay.Input) (*url.URL, error)
+}
+
+var _ webhookrelay.InputService = (*InputService)(nil)
+
+// ListInputs calls ListInputsFunc
+func (m *InputService) ListInputs(options *webhookrelay.InputListOptions) ([]*webhookrelay.Input, error) {
+	m.record("ListInputs", options)
+	if m.ListInputsFunc == nil {
+		panic("InputService.ListInputs called but ListInputsFunc is not set")
+	}
+	return m.ListInputsFunc(options)
+}
+
+// CreateInput calls CreateInputFunc
+func (m *InputService) CreateInput(options *webhookrelay.Input) (*webhookrelay.Input, error) {
+	m.record("CreateInput", options)
+	if m.CreateInputFunc == nil {
+		panic("InputService.CreateInput called but CreateInputFunc is not set")
+	}
+	return m.CreateInputFunc(options)
+}
+
+// UpdateInput calls UpdateInputFunc
+func (m *InputService) UpdateInput(options *webhookrelay.Input) (*webhookrelay.Input, error) {
+	m.record("UpdateInput", options)
+	if m.UpdateInputFunc == nil {
+		panic("InputService.UpdateInput called but UpdateInputFunc is not set")
+	}
+	return m.UpdateInputFunc(options)
+}
+
+// PatchInput calls PatchInputFunc
+func (m *InputService) PatchInput(bucketRef string, inputRef string, patch *webhookrelay.InputPatch, opts *webhookrelay.PatchOptions) (*webhookrelay.Input, error) {
+	m.record("PatchInput", bucketRef, inputRef, patch, opts)
+	if m.PatchInputFunc == nil {
+		panic("InputService.PatchInput called but PatchInputFunc is not set")
+	}
+	return m.PatchInputFunc(bucketRef, inputRef, patch, opts)
+}
+
+// DeleteInput calls DeleteInputFunc
+func (m *InputService) DeleteInput(options *webhookrelay.InputDeleteOptions) error {
+	m.record("DeleteInput", options)
+	if m.DeleteInputFunc == nil {
+		panic("InputService.DeleteInput called but DeleteInputFunc is not set")
+	}
+	return m.DeleteInputFunc(options)
+}
+
+// SetInputResponse calls SetInputResponseFunc
+func (m *InputService) SetInputResponse(bucketRef string, inputRef string, resp *webhookrelay.InputResponse) (*webhookrelay.Input, error) {
+	m.record("SetInputResponse", bucketRef, inputRef, resp)
+	if m.SetInputResponseFunc == nil {
+		panic("InputService.SetInputResponse called but SetInputResponseFunc is not set")
+	}
+	return m.SetInputResponseFunc(bucketRef, inputRef, resp)
+}
+
+// AllowInputIPRanges calls AllowInputIPRangesFunc
+func (m *InputService) AllowInputIPRanges(bucketRef string, inputRef string, ranges []string) (*webhookrelay.Input, error) {
+	m.record("AllowInputIPRanges", bucketRef, inputRef, ranges)
+	if m.AllowInputIPRangesFunc == nil {
+		panic("InputService.AllowInputIPRanges called but AllowInputIPRangesFunc is not set")
+	}
+	return m.AllowInputIPRangesFunc(bucketRef, inputRef, ranges)
+}
+
+// InputURL calls InputURLFunc
+func (m *InputService) InputURL(input *webhookrelay.Input) (*url.URL, error) {
+	m.record("InputURL", input)
+	if m.InputURLFunc == nil {
+		panic("InputService.InputURL called but InputURLFunc is not set")
+	}
+	return m.InputURLFunc(input)
+}
+
+// OutputService is a fake webhookrelay.OutputService. Set the Func field of each
+// method the code under test calls, calling a method without one panics.
+type OutputService struct {
+	CallRecorder
+
+	ListOutputsFunc  func(options *webhookrelay.OutputListOptions) ([]*webhookrelay.Output, error)
+	CreateOutputFunc func(options *webhookrelay.Output) (*webhookrelay.Output, error)
+	UpdateOutputFunc func(options *webhookrelay.Output) (*webhookrelay.Output, error)
+	PatchOutputFunc  func(bucketRef string, outputRef string, patch *webhookrelay.OutputPatch, opts *webhookrelay.PatchOptions) (*webhookrelay.Output, error)
+	DeleteOutputFunc func(options *webhookrelay.OutputDeleteOptions) error
+}
+
+var _ webhookrelay.OutputService = (*OutputService)(nil)
+
+// ListOutputs calls ListOutputsFunc
+func (m *OutputService) ListOutputs(options *webhookrelay.OutputListOptions) ([]*webhookrelay.Output, error) {
+	m.record("ListOutputs", options)
+	if m.ListOutputsFunc == nil {
+		panic("OutputService.ListOutputs called but ListOutputsFunc is not set")
+	}
+	return m.ListOutputsFunc(options)
+}
+
+// CreateOutput calls CreateOutputFunc
+func (m *OutputService) CreateOutput(options *webhookrelay.Output) (*webhookrelay.Output, error) {
+	m.record("CreateOutput", options)
+	if m.CreateOutputFunc == nil {
+		panic("OutputService.CreateOutput called but CreateOutputFunc is not set")
+	}
+	return m.CreateOutputFunc(options)
+}
+
+// UpdateOutput calls UpdateOutputFunc
+func (m *OutputService) UpdateOutput(options *webhookrelay.Output) (*webhookrelay.Output, error) {
+	m.record("UpdateOutput", options)
+	if m.UpdateOutputFunc == nil {
+		panic("OutputService.UpdateOutput called but UpdateOutputFunc is not set")
+	}
+	return m.UpdateOutputFunc(options)
+}
+
+// PatchOutput calls PatchOutputFunc
+func (m *OutputService) PatchOutput(bucketRef string, outputRef string, patch *webhookrelay.OutputPatch, opts *webhookrelay.PatchOptions) (*webhookrelay.Output, error) {
+	m.record("PatchOutput", bucketRef, outputRef, patch, opts)
+	if m.PatchOutputFunc == nil {
+		panic("OutputService.PatchOutput called but PatchOutputFunc is not set")
+	}
+	return m.PatchOutputFunc(bucketRef, outputRef, patch, opts)
+}
+
+// DeleteOutput calls DeleteOutputFunc
+func (m *OutputService) DeleteOutput(options *webhookrelay.OutputDeleteOptions) error {
+	m.record("DeleteOutput", options)
+	if m.DeleteOutputFunc == nil {
+		panic("OutputService.DeleteOutput called but DeleteOutputFunc is not set")
+	}
+	return m.DeleteOutputFunc(options)
+}
+
+// TunnelService is a fake webhookrelay.TunnelService. Set the Func field of each
+// method the code under test calls, calling a method without one panics.
+type TunnelService struct {
+	CallRecorder
+
+	ListTunnelsFunc  func(options *webhookrelay.TunnelListOptions) ([]*webhookrelay.Tunnel, error)
+	GetTunnelFunc    func(ref string) (*webhookrelay.Tunnel, error)
+	CreateTunnelFunc func(options *webhookrelay.Tunnel) (*webhookrelay.Tunnel, error)
+	UpdateTunnelFunc func(options *webhookrelay.Tunnel) (*webhookrelay.Tunnel, error)
+	PatchTunnelFunc  func(ref string, patch *webhookrelay.TunnelPatch, opts *webhookrelay.PatchOptions) (*webhookrelay.Tunnel, error)
+	DeleteTunnelFunc func(options *webhookrelay.TunnelDeleteOptions) error
+	TunnelURLFunc    func(tunnel *webhookrelay.Tunnel) (*url.URL, error)
+}
+
+var _ webhookrelay.TunnelService = (*TunnelService)(nil)
+
+// ListTunnels calls ListTunnelsFunc
+func (m *TunnelService) ListTunnels(options *webhookrelay.TunnelListOptions) ([]*webhookrelay.Tunnel, error) {
+	m.record("ListTunnels", options)
+	if m.ListTunnelsFunc == nil {
+		panic("TunnelService.ListTunnels called but ListTunnelsFunc is not set")
+	}
+	return m.ListTunnelsFunc(options)
+}
+
+// GetTunnel calls GetTunnelFunc
+func (m *TunnelService) GetTunnel(ref string) (*webhookrelay.Tunnel, error) {
+	m.record("GetTunnel", ref)
+	if m.GetTunnelFunc == nil {
+		panic("TunnelService.GetTunnel called but GetTunnelFunc is not set")
+	}
+	return m.GetTunnelFunc(ref)
+}
+
+// CreateTunnel calls CreateTunnelFunc
+func (m *TunnelService) CreateTunnel(options *webhookrelay.Tunnel) (*webhookrelay.Tunnel, error) {
+	m.record("CreateTunnel", options)
+	if m.CreateTunnelFunc == nil {
+		panic("TunnelService.CreateTunnel called but CreateTunnelFunc is not set")
+	}
+	return m.CreateTunnelFunc(options)
+}
+
+// UpdateTunnel calls UpdateTunnelFunc
+func (m *TunnelService) UpdateTunnel(options *webhookrelay.Tunnel) (*webhookrelay.Tunnel, error) {
+	m.record("UpdateTunnel", options)
+	if m.UpdateTunnelFunc == nil {
+		panic("TunnelService.UpdateTunnel called but UpdateTunnelFunc is not set")
+	}
+	return m.UpdateTunnelFunc(options)
+}
+
+// PatchTunnel calls PatchTunnelFunc
+func (m *TunnelService) PatchTunnel(ref string, patch *webhookrelay.TunnelPatch, opts *webhookrelay.PatchOptions) (*webhookrelay.Tunnel, error) {
+	m.record("PatchTunnel", ref, patch, opts)
+	if m.PatchTunnelFunc == nil {
+		panic("TunnelService.PatchTunnel called but PatchTunnelFunc is not set")
+	}
+	return m.PatchTunnelFunc(ref, patch, opts)
+}
+
+// DeleteTunnel calls DeleteTunnelFunc
+func (m *TunnelService) DeleteTunnel(options *webhookrelay.TunnelDeleteOptions) error {
+	m.record("DeleteTunnel", options)
+	if m.DeleteTunnelFunc == nil {
+		panic("TunnelService.DeleteTunnel called but DeleteTunnelFunc is not set")
+	}
+	return m.DeleteTunnelFunc(options)
+}
+
+// TunnelURL calls TunnelURLFunc
+func (m *TunnelService) TunnelURL(tunnel *webhookrelay.Tunnel) (*url.URL, error) {
+	m.record("TunnelURL", tunnel)
+	if m.TunnelURLFunc == nil {
+		panic("TunnelService.TunnelURL called but TunnelURLFunc is not set")
+	}
+	return m.TunnelURLFunc(tunnel)
+}
+
+// FunctionService is a fake webhookrelay.FunctionService. Set the Func field of each
+// method the code under test calls, calling a method without one panics.
+type FunctionService struct {
+	CallRecorder
+
+	ListFunctionsFunc                       func(options *webhookrelay.FunctionListOptions) ([]*webhookrelay.Function, error)
+	GetFunctionFunc                         func(ref string) (*webhookrelay.Function, error)
+	CreateFunctionFunc                      func(opts *webhookrelay.CreateFunctionRequest) (*webhookrelay.Function, error)
+	UpdateFunctionFunc                      func(options *webhookrelay.UpdateFunctionRequest) (*webhookrelay.Function, error)
+	DeleteFunctionFunc                      func(options *webhookrelay.FunctionDeleteOptions) error
+	InvokeFunctionFunc                      func(options *webhookrelay.InvokeOpts) (*webhookrelay.ExecuteResponse, error)
+	ListFunctionConfigurationVariablesFunc  func(options *webhookrelay.FunctionConfigurationVariablesListOptions) ([]*webhookrelay.Variable, error)
+	SetFunctionConfigurationVariableFunc    func(options *webhookrelay.SetFunctionConfigRequest) (*webhookrelay.Variable, error)
+	DeleteFunctionConfigurationVariableFunc func(options *webhookrelay.FunctionConfigurationVariableDeleteOptions) error
+}
+
+var _ webhookrelay.FunctionService = (*FunctionService)(nil)
+
+// ListFunctions calls ListFunctionsFunc
+func (m *FunctionService) ListFunctions(options *webhookrelay.FunctionListOptions) ([]*webhookrelay.Function, error) {
+	m.record("ListFunctions", options)
+	if m.ListFunctionsFunc == nil {
+		panic("FunctionService.ListFunctions called but ListFunctionsFunc is not set")
+	}
+	return m.ListFunctionsFunc(options)
+}
+
+// GetFunction calls GetFunctionFunc
+func (m *FunctionService) GetFunction(ref string) (*webhookrelay.Function, error) {
+	m.record("GetFunction", ref)
+	if m.GetFunctionFunc == nil {
+		panic("FunctionService.GetFunction called but GetFunctionFunc is not set")
+	}
+	return m.GetFunctionFunc(ref)
+}
+
+// CreateFunction calls CreateFunctionFunc
+func (m *FunctionService) CreateFunction(opts *webhookrelay.CreateFunctionRequest) (*webhookrelay.Function, error) {
+	m.record("CreateFunction", opts)
+	if m.CreateFunctionFunc == nil {
+		panic("FunctionService.CreateFunction called but CreateFunctionFunc is not set")
+	}
+	return m.CreateFunctionFunc(opts)
+}
+
+// UpdateFunction calls UpdateFunctionFunc
+func (m *FunctionService) UpdateFunction(options *webhookrelay.UpdateFunctionRequest) (*webhookrelay.Function, error) {
+	m.record("UpdateFunction", options)
+	if m.UpdateFunctionFunc == nil {
+		panic("FunctionService.UpdateFunction called but UpdateFunctionFunc is not set")
+	}
+	return m.UpdateFunctionFunc(options)
+}
+
+// DeleteFunction calls DeleteFunctionFunc
+func (m *FunctionService) DeleteFunction(options *webhookrelay.FunctionDeleteOptions) error {
+	m.record("DeleteFunction", options)
+	if m.DeleteFunctionFunc == nil {
+		panic("FunctionService.DeleteFunction called but DeleteFunctionFunc is not set")
+	}
+	return m.DeleteFunctionFunc(options)
+}
+
+// InvokeFunction calls InvokeFunctionFunc
+func (m *FunctionService) InvokeFunction(options *webhookrelay.InvokeOpts) (*webhookrelay.ExecuteResponse, error) {
+	m.record("InvokeFunction", options)
+	if m.InvokeFunctionFunc == nil {
+		panic("FunctionService.InvokeFunction called but InvokeFunctionFunc is not set")
+	}
+	return m.InvokeFunctionFunc(options)
+}
+
+// ListFunctionConfigurationVariables calls ListFunctionConfigurationVariablesFunc
+func (m *FunctionService) ListFunctionConfigurationVariables(options *webhookrelay.FunctionConfigurationVariablesListOptions) ([]*webhookrelay.Variable, error) {
+	m.record("ListFunctionConfigurationVariables", options)
+	if m.ListFunctionConfigurationVariablesFunc == nil {
+		panic("FunctionService.ListFunctionConfigurationVariables called but ListFunctionConfigurationVariablesFunc is not set")
+	}
+	return m.ListFunctionConfigurationVariablesFunc(options)
+}
+
+// SetFunctionConfigurationVariable calls SetFunctionConfigurationVariableFunc
+func (m *FunctionService) SetFunctionConfigurationVariable(options *webhookrelay.SetFunctionConfigRequest) (*webhookrelay.Variable, error) {
+	m.record("SetFunctionConfigurationVariable", options)
+	if m.SetFunctionConfigurationVariableFunc == nil {
+		panic("FunctionService.SetFunctionConfigurationVariable called but SetFunctionConfigurationVariableFunc is not set")
+	}
+	return m.SetFunctionConfigurationVariableFunc(options)
+}
+
+// DeleteFunctionConfigurationVariable calls DeleteFunctionConfigurationVariableFunc
+func (m *FunctionService) DeleteFunctionConfigurationVariable(options *webhookrelay.FunctionConfigurationVariableDeleteOptions) error {
+	m.record("DeleteFunctionConfigurationVariable", options)
+	if m.DeleteFunctionConfigurationVariableFunc == nil {
+		panic("FunctionService.DeleteFunctionConfigurationVariable called but DeleteFunctionConfigurationVariableFunc is not set")
+	}
+	return m.DeleteFunctionConfigurationVariableFunc(options)
+}
+
+// LogService is a fake webhookrelay.LogService. Set the Func field of each
+// method the code under test calls, calling a method without one panics.
+type LogService struct {
+	CallRecorder
+
+	ListWebhookLogsFunc  func(options *webhookrelay.WebhookLogsListOptions) (*webhookrelay.WebhookLogsResponse, error)
+	GetWebhookLogFunc    func(id string) (*webhookrelay.Log, error)
+	UpdateWebhookLogFunc func(updateRequest *webhookrelay.WebhookLogsUpdateRequest) error
+	ListDeadLettersFunc  func(options *webhookrelay.DeadLetterListOptions) (*webhookrelay.WebhookLogsResponse, error)
+	DrainDeadLettersFunc func(ctx context.Context, options *webhookrelay.DeadLetterDrainOptions) (int, error)
+}
+
+var _ webhookrelay.LogService = (*LogService)(nil)
+
+// ListWebhookLogs calls ListWebhookLogsFunc
+func (m *LogService) ListWebhookLogs(options *webhookrelay.WebhookLogsListOptions) (*webhookrelay.WebhookLogsResponse, error) {
+	m.record("ListWebhookLogs", options)
+	if m.ListWebhookLogsFunc == nil {
+		panic("LogService.ListWebhookLogs called but ListWebhookLogsFunc is not set")
+	}
+	return m.ListWebhookLogsFunc(options)
+}
+
+// GetWebhookLog calls GetWebhookLogFunc
+func (m *LogService) GetWebhookLog(id string) (*webhookrelay.Log, error) {
+	m.record("GetWebhookLog", id)
+	if m.GetWebhookLogFunc == nil {
+		panic("LogService.GetWebhookLog called but GetWebhookLogFunc is not set")
+	}
+	return m.GetWebhookLogFunc(id)
+}
+
+// UpdateWebhookLog calls UpdateWebhookLogFunc
+func (m *LogService) UpdateWebhookLog(updateRequest *webhookrelay.WebhookLogsUpdateRequest) error {
+	m.record("UpdateWebhookLog", updateRequest)
+	if m.UpdateWebhookLogFunc == nil {
+		panic("LogService.UpdateWebhookLog called but UpdateWebhookLogFunc is not set")
+	}
+	return m.UpdateWebhookLogFunc(updateRequest)
+}
+
+// ListDeadLetters calls ListDeadLettersFunc
+func (m *LogService) ListDeadLetters(options *webhookrelay.DeadLetterListOptions) (*webhookrelay.WebhookLogsResponse, error) {
+	m.record("ListDeadLetters", options)
+	if m.ListDeadLettersFunc == nil {
+		panic("LogService.ListDeadLetters called but ListDeadLettersFunc is not set")
+	}
+	return m.ListDeadLettersFunc(options)
+}
+
+// DrainDeadLetters calls DrainDeadLettersFunc
+func (m *LogService) DrainDeadLetters(ctx context.Context, options *webhookrelay.DeadLetterDrainOptions) (int, error) {
+	m.record("DrainDeadLetters", ctx, options)
+	if m.DrainDeadLettersFunc == nil {
+		panic("LogService.DrainDeadLetters called but DrainDeadLettersFunc is not set")
+	}
+	return m.DrainDeadLettersFunc(ctx, options)
+}
+
+// TokenService is a fake webhookrelay.TokenService. Set the Func field of each
+// method the code under test calls, calling a method without one panics.
+type TokenService struct {
+	CallRecorder
+
+	ListAccessTokensFunc          func(options *webhookrelay.AccessTokenListOptions) ([]*webhookrelay.AccessToken, error)
+	CreateAccessTokenFunc         func(options *webhookrelay.AccessTokenCreateOptions) (*webhookrelay.AccessTokenCreateResponse, error)
+	UpdateAccessTokenFunc         func(options *webhookrelay.AccessToken) (*webhookrelay.AccessToken, error)
+	DeleteAccessTokenFunc         func(options *webhookrelay.AccessTokenDeleteOptions) error
+	RotateAccessTokenFunc         func(ctx context.Context, id string, opts *webhookrelay.AccessTokenRotateOptions) (*webhookrelay.AccessTokenRotation, error)
+	FinishAccessTokenRotationFunc func(rotation *webhookrelay.AccessTokenRotation) error
+	UnusedAccessTokensFunc        func(days int) ([]*webhookrelay.UnusedAccessToken, error)
+}
+
+var _ webhookrelay.TokenService = (*TokenService)(nil)
+
+// ListAccessTokens calls ListAccessTokensFunc
+func (m *TokenService) ListAccessTokens(options *webhookrelay.AccessTokenListOptions) ([]*webhookrelay.AccessToken, error) {
+	m.record("ListAccessTokens", options)
+	if m.ListAccessTokensFunc == nil {
+		panic("TokenService.ListAccessTokens called but ListAccessTokensFunc is not set")
+	}
+	return m.ListAccessTokensFunc(options)
+}
+
+// CreateAccessToken calls CreateAccessTokenFunc
+func (m *TokenService) CreateAccessToken(options *webhookrelay.AccessTokenCreateOptions) (*webhookrelay.AccessTokenCreateResponse, error) {
+	m.record("CreateAccessToken", options)
+	if m.CreateAccessTokenFunc == nil {
+		panic("TokenService.CreateAccessToken called but CreateAccessTokenFunc is not set")
+	}
+	return m.CreateAccessTokenFunc(options)
+}
+
+// UpdateAccessToken calls UpdateAccessTokenFunc
+func (m *TokenService) UpdateAccessToken(options *webhookrelay.AccessToken) (*webhookrelay.AccessToken, error) {
+	m.record("UpdateAccessToken", options)
+	if m.UpdateAccessTokenFunc == nil {
+		panic("TokenService.UpdateAccessToken called but UpdateAccessTokenFunc is not set")
+	}
+	return m.UpdateAccessTokenFunc(options)
+}
+
+// DeleteAccessToken calls DeleteAccessTokenFunc
+func (m *TokenService) DeleteAccessToken(options *webhookrelay.AccessTokenDeleteOptions) error {
+	m.record("DeleteAccessToken", options)
+	if m.DeleteAccessTokenFunc == nil {
+		panic("TokenService.DeleteAccessToken called but DeleteAccessTokenFunc is not set")
+	}
+	return m.DeleteAccessTokenFunc(options)
+}
+
+// RotateAccessToken calls RotateAccessTokenFunc
+func (m *TokenService) RotateAccessToken(ctx context.Context, id string, opts *webhookrelay.AccessTokenRotateOptions) (*webhookrelay.AccessTokenRotation, error) {
+	m.record("RotateAccessToken", ctx, id, opts)
+	if m.RotateAccessTokenFunc == nil {
+		panic("TokenService.RotateAccessToken called but RotateAccessTokenFunc is not set")
+	}
+	return m.RotateAccessTokenFunc(ctx, id, opts)
+}
+
+// FinishAccessTokenRotation calls FinishAccessTokenRotationFunc
+func (m *TokenService) FinishAccessTokenRotation(rotation *webhookrelay.AccessTokenRotation) error {
+	m.record("FinishAccessTokenRotation", rotation)
+	if m.FinishAccessTokenRotationFunc == nil {
+		panic("TokenService.FinishAccessTokenRotation called but FinishAccessTokenRotationFunc is not set")
+	}
+	return m.FinishAccessTokenRotationFunc(rotation)
+}
+
+// UnusedAccessTokens calls UnusedAccessTokensFunc
+func (m *TokenService) UnusedAccessTokens(days int) ([]*webhookrelay.UnusedAccessToken, error) {
+	m.record("UnusedAccessTokens", days)
+	if m.UnusedAccessTokensFunc == nil {
+		panic("TokenService.UnusedAccessTokens called but UnusedAccessTokensFunc is not set")
+	}
+	return m.UnusedAccessTokensFunc(days)
+}
+
+// DomainService is a fake webhookrelay.DomainService. Set the Func field of each
+// method the code under test calls, calling a method without one panics.
+type DomainService struct {
+	CallRecorder
+
+	ListDomainReservationsFunc  func(options *webhookrelay.DomainListOptions) ([]*webhookrelay.Domain, error)
+	ReserveDomainFunc           func(options *webhookrelay.Domain) (*webhookrelay.Domain, error)
+	DeleteDomainReservationFunc func(options *webhookrelay.DomainDeleteOptions) error
+}
+
+var _ webhookrelay.DomainService = (*DomainService)(nil)
+
+// ListDomainReservations calls ListDomainReservationsFunc
+func (m *DomainService) ListDomainReservations(options *webhookrelay.DomainListOptions) ([]*webhookrelay.Domain, error) {
+	m.record("ListDomainReservations", options)
+	if m.ListDomainReservationsFunc == nil {
+		panic("DomainService.ListDomainReservations called but ListDomainReservationsFunc is not set")
+	}
+	return m.ListDomainReservationsFunc(options)
+}
+
+// ReserveDomain calls ReserveDomainFunc
+func (m *DomainService) ReserveDomain(options *webhookrelay.Domain) (*webhookrelay.Domain, error) {
+	m.record("ReserveDomain", options)
+	if m.ReserveDomainFunc == nil {
+		panic("DomainService.ReserveDomain called but ReserveDomainFunc is not set")
+	}
+	return m.ReserveDomainFunc(options)
+}
+
+// DeleteDomainReservation calls DeleteDomainReservationFunc
+func (m *DomainService) DeleteDomainReservation(options *webhookrelay.DomainDeleteOptions) error {
+	m.record("DeleteDomainReservation", options)
+	if m.DeleteDomainReservationFunc == nil {
+		panic("DomainService.DeleteDomainReservation called but DeleteDomainReservationFunc is not set")
+	}
+	return m.DeleteDomainReservationFunc(options)
+}
@@ -0,0 +1,186 @@
+package webhookrelaytest
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/webhookrelay/webhookrelay-go"
+)
+
+func (s *Server) handleTokens(w http.ResponseWriter, r *http.Request, path []string) {
+	if len(path) == 0 {
+		switch r.Method {
+		case http.MethodGet:
+			tokens := make([]*webhookrelay.AccessToken, len(s.tokens))
+			for idx, t := range s.tokens {
+				tokens[idx] = &t.AccessToken
+			}
+			writeJSON(w, http.StatusOK, tokens)
+		case http.MethodPost:
+			s.createToken(w, r)
+		default:
+			methodNotAllowed(w, r)
+		}
+		return
+	}
+
+	idx := -1
+	for i, t := range s.tokens {
+		if t.ID == path[0] {
+			idx = i
+		}
+	}
+	if idx < 0 || len(path) > 1 {
+		writeNotFound(w, "token", path[0])
+		return
+	}
+	t := s.tokens[idx]
+
+	switch r.Method {
+	case http.MethodPut:
+		var update webhookrelay.AccessToken
+		if !decode(w, r, &update) {
+			return
+		}
+		if status, err := validateToken(update.APIAccess, &update.Scopes); err != nil {
+			writeError(w, status, "%s", err)
+			return
+		}
+		update.ID = t.ID
+		update.CreatedAt = t.CreatedAt
+		update.LastLogin = t.LastLogin
+		update.UpdatedAt = s.timestamp()
+		t.AccessToken = update
+		writeJSON(w, http.StatusOK, &t.AccessToken)
+	case http.MethodDelete:
+		s.tokens = append(s.tokens[:idx], s.tokens[idx+1:]...)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		methodNotAllowed(w, r)
+	}
+}
+
+func (s *Server) createToken(w http.ResponseWriter, r *http.Request) {
+	var opts webhookrelay.AccessTokenCreateOptions
+	if !decode(w, r, &opts) {
+		return
+	}
+	if status, err := validateToken(opts.APIAccess, &opts.Scopes); err != nil {
+		writeError(w, status, "%s", err)
+		return
+	}
+
+	t := &token{secret: randomString(32)}
+	t.ID = newID()
+	t.CreatedAt = s.timestamp()
+	t.UpdatedAt = t.CreatedAt
+	t.Description = opts.Description
+	t.Scopes = opts.Scopes
+	t.APIAccess = opts.APIAccess
+	t.ExpiresAt = opts.ExpiresAt
+	t.Active = true
+	s.tokens = append(s.tokens, t)
+
+	writeJSON(w, http.StatusCreated, &webhookrelay.AccessTokenCreateResponse{Key: t.ID, Secret: t.secret})
+}
+
+func validateToken(apiAccess webhookrelay.AccessTokenAPIAccess, scopes *webhookrelay.AccessTokenScopes) (int, error) {
+	switch apiAccess {
+	case "", webhookrelay.AccessTokenAPIAccessEnabled, webhookrelay.AccessTokenAPIAccessDisabled:
+	default:
+		return http.StatusBadRequest, fmt.Errorf("invalid API access '%s'", apiAccess)
+	}
+	if err := scopes.Validate(); err != nil {
+		return http.StatusBadRequest, err
+	}
+	return 0, nil
+}
+
+func (s *Server) handleDomains(w http.ResponseWriter, r *http.Request, path []string) {
+	if len(path) == 0 {
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(w, http.StatusOK, s.domains)
+		case http.MethodPost:
+			var domain webhookrelay.Domain
+			if !decode(w, r, &domain) {
+				return
+			}
+			if domain.Domain == "" {
+				writeError(w, http.StatusBadRequest, "domain is required")
+				return
+			}
+			if s.findDomain(domain.Domain) >= 0 {
+				writeError(w, http.StatusConflict, "domain '%s' is already reserved", domain.Domain)
+				return
+			}
+			domain.ID = newID()
+			domain.CreatedAt = s.timestamp()
+			domain.UpdatedAt = domain.CreatedAt
+			s.domains = append(s.domains, &domain)
+			writeJSON(w, http.StatusCreated, &domain)
+		default:
+			methodNotAllowed(w, r)
+		}
+		return
+	}
+
+	idx := s.findDomain(path[0])
+	if idx < 0 || len(path) > 1 {
+		writeNotFound(w, "domain", path[0])
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, s.domains[idx])
+	case http.MethodDelete:
+		s.domains = append(s.domains[:idx], s.domains[idx+1:]...)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		methodNotAllowed(w, r)
+	}
+}
+
+// findDomain returns the index of the reservation by ID or domain, -1 if
+// not found
+func (s *Server) findDomain(ref string) int {
+	for idx, d := range s.domains {
+		if d.ID == ref || d.Domain == ref {
+			return idx
+		}
+	}
+	return -1
+}
+
+func (s *Server) handleRegions(w http.ResponseWriter, r *http.Request, path []string) {
+	if len(path) > 0 || r.Method != http.MethodGet {
+		methodNotAllowed(w, r)
+		return
+	}
+	writeJSON(w, http.StatusOK, s.regions)
+}
+
+func (s *Server) findRegion(name string) *webhookrelay.Region {
+	for _, region := range s.regions {
+		if region.Name == name {
+			return region
+		}
+	}
+	return nil
+}
+
+func (s *Server) handleUser(w http.ResponseWriter, r *http.Request, path []string) {
+	if len(path) != 1 || path[0] != "info" || r.Method != http.MethodGet {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, &s.user)
+}
+
+func (s *Server) handleVersion(w http.ResponseWriter, r *http.Request, path []string) {
+	if len(path) > 0 || r.Method != http.MethodGet {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, &s.version)
+}
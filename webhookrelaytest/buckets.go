@@ -0,0 +1,214 @@
+package webhookrelaytest
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/webhookrelay/webhookrelay-go"
+)
+
+func (s *Server) handleBuckets(w http.ResponseWriter, r *http.Request, path []string) {
+	if len(path) == 0 {
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(w, http.StatusOK, s.buckets)
+		case http.MethodPost:
+			s.createBucket(w, r)
+		default:
+			methodNotAllowed(w, r)
+		}
+		return
+	}
+
+	bucket := s.findBucket(path[0])
+	if bucket == nil {
+		writeNotFound(w, "bucket", path[0])
+		return
+	}
+
+	switch {
+	case len(path) == 1:
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(w, http.StatusOK, bucket)
+		case http.MethodPut:
+			s.updateBucket(w, r, bucket)
+		case http.MethodDelete:
+			s.deleteBucket(bucket)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			methodNotAllowed(w, r)
+		}
+	case len(path) == 2 && path[1] == "usage" && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, s.bucketUsage(bucket))
+	case path[1] == "inputs" && len(path) <= 3:
+		s.handleInputs(w, r, bucket, path[2:])
+	case path[1] == "outputs" && len(path) <= 3:
+		s.handleOutputs(w, r, bucket, path[2:])
+	default:
+		writeError(w, http.StatusNotFound, "not found")
+	}
+}
+
+// findBucket finds the bucket by ID or name
+func (s *Server) findBucket(ref string) *webhookrelay.Bucket {
+	for _, b := range s.buckets {
+		if b.ID == ref {
+			return b
+		}
+	}
+	for _, b := range s.buckets {
+		if b.Name == ref {
+			return b
+		}
+	}
+	return nil
+}
+
+func (s *Server) createBucket(w http.ResponseWriter, r *http.Request) {
+	var opts webhookrelay.BucketCreateOptions
+	if !decode(w, r, &opts) {
+		return
+	}
+
+	bucket := &webhookrelay.Bucket{
+		Name:          opts.Name,
+		Description:   opts.Description,
+		Stream:        opts.Stream,
+		Ephemeral:     opts.Ephemeral,
+		Suspended:     opts.Suspended,
+		LargeWebhooks: opts.LargeWebhooks,
+		RetryPolicy:   opts.RetryPolicy,
+		DeadLetter:    opts.DeadLetter,
+		RateLimit:     opts.RateLimit,
+		Quota:         opts.Quota,
+	}
+	if opts.Auth != nil {
+		bucket.Auth = *opts.Auth
+	}
+	if status, err := s.validateBucket(bucket); err != nil {
+		writeError(w, status, "%s", err)
+		return
+	}
+
+	bucket.ID = newID()
+	bucket.CreatedAt = s.timestamp()
+	bucket.UpdatedAt = bucket.CreatedAt
+	bucket.Inputs = []*webhookrelay.Input{}
+	bucket.Outputs = []*webhookrelay.Output{}
+
+	if opts.DefaultInput == nil || !opts.DefaultInput.Skip {
+		input := &webhookrelay.Input{Name: DefaultInputName}
+		if in := opts.DefaultInput; in != nil {
+			setString(&input.Name, in.Name)
+			setString(&input.Description, in.Description)
+			setString(&input.FunctionID, in.FunctionID)
+			setString(&input.Body, in.Body)
+			setString(&input.ResponseFromOutput, in.ResponseFromOutput)
+			setString(&input.CustomDomain, in.CustomDomain)
+			setString(&input.PathPrefix, in.PathPrefix)
+			if in.Headers != nil {
+				input.Headers = in.Headers
+			}
+			if in.StatusCode != 0 {
+				input.StatusCode = in.StatusCode
+			}
+		}
+		s.addInput(bucket, input)
+	}
+
+	s.buckets = append(s.buckets, bucket)
+	writeJSON(w, http.StatusCreated, bucket)
+}
+
+func (s *Server) updateBucket(w http.ResponseWriter, r *http.Request, bucket *webhookrelay.Bucket) {
+	var update webhookrelay.Bucket
+	if !decode(w, r, &update) {
+		return
+	}
+	if modifiedSince(r, bucket.UpdatedAt) {
+		writeError(w, http.StatusPreconditionFailed, "bucket was modified")
+		return
+	}
+
+	update.ID = bucket.ID
+	if status, err := s.validateBucket(&update); err != nil {
+		writeError(w, status, "%s", err)
+		return
+	}
+
+	update.CreatedAt = bucket.CreatedAt
+	update.UpdatedAt = s.timestamp()
+	update.Inputs = bucket.Inputs
+	update.Outputs = bucket.Outputs
+	*bucket = update
+	writeJSON(w, http.StatusOK, bucket)
+}
+
+func (s *Server) validateBucket(bucket *webhookrelay.Bucket) (int, error) {
+	if bucket.Name == "" {
+		return http.StatusBadRequest, fmt.Errorf("bucket name is required")
+	}
+	if existing := s.findBucket(bucket.Name); existing != nil && existing.ID != bucket.ID {
+		return http.StatusConflict, fmt.Errorf("bucket '%s' already exists", bucket.Name)
+	}
+	if err := bucket.Auth.Validate(); err != nil {
+		return http.StatusBadRequest, err
+	}
+	if bucket.RetryPolicy != nil {
+		if err := bucket.RetryPolicy.Validate(); err != nil {
+			return http.StatusBadRequest, err
+		}
+	}
+	if bucket.DeadLetter != nil {
+		if err := bucket.DeadLetter.Validate(); err != nil {
+			return http.StatusBadRequest, err
+		}
+	}
+	if bucket.RateLimit != nil {
+		if err := bucket.RateLimit.Validate(); err != nil {
+			return http.StatusBadRequest, err
+		}
+	}
+	if bucket.Quota != nil {
+		if err := bucket.Quota.Validate(); err != nil {
+			return http.StatusBadRequest, err
+		}
+	}
+	return 0, nil
+}
+
+func (s *Server) deleteBucket(bucket *webhookrelay.Bucket) {
+	for idx, b := range s.buckets {
+		if b.ID == bucket.ID {
+			s.buckets = append(s.buckets[:idx], s.buckets[idx+1:]...)
+			return
+		}
+	}
+}
+
+// bucketUsage counts webhook logs of the bucket received today (UTC)
+func (s *Server) bucketUsage(bucket *webhookrelay.Bucket) *webhookrelay.BucketUsage {
+	start := s.now().UTC().Truncate(24 * time.Hour)
+	usage := &webhookrelay.BucketUsage{
+		BucketID:    bucket.ID,
+		PeriodStart: start,
+		PeriodEnd:   start.Add(24 * time.Hour),
+		Quota:       bucket.Quota,
+	}
+	for _, l := range s.logs {
+		if l.BucketID != bucket.ID || l.CreatedAt.Before(usage.PeriodStart) || !l.CreatedAt.Before(usage.PeriodEnd) {
+			continue
+		}
+		usage.Requests++
+		usage.Bytes += int64(len(l.Body))
+	}
+	return usage
+}
+
+func setString(dst *string, v string) {
+	if v != "" {
+		*dst = v
+	}
+}
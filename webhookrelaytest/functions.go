@@ -0,0 +1,219 @@
+package webhookrelaytest
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/webhookrelay/webhookrelay-go"
+)
+
+func (s *Server) handleFunctions(w http.ResponseWriter, r *http.Request, path []string) {
+	if len(path) == 0 {
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(w, http.StatusOK, s.functions)
+		case http.MethodPost:
+			s.createFunction(w, r)
+		default:
+			methodNotAllowed(w, r)
+		}
+		return
+	}
+
+	fn := s.findFunction(path[0])
+	if fn == nil {
+		writeNotFound(w, "function", path[0])
+		return
+	}
+
+	switch {
+	case len(path) == 1:
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(w, http.StatusOK, fn)
+		case http.MethodPut:
+			s.updateFunction(w, r, fn)
+		case http.MethodDelete:
+			s.deleteFunction(fn)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			methodNotAllowed(w, r)
+		}
+	case len(path) == 2 && path[1] == "invoke" && r.Method == http.MethodPost:
+		s.invokeFunction(w, r, fn)
+	case path[1] == "config" && len(path) <= 3:
+		s.handleFunctionConfig(w, r, fn, path[2:])
+	default:
+		writeError(w, http.StatusNotFound, "not found")
+	}
+}
+
+// findFunction finds the function by ID or name
+func (s *Server) findFunction(ref string) *webhookrelay.Function {
+	for _, fn := range s.functions {
+		if fn.Id == ref {
+			return fn
+		}
+	}
+	for _, fn := range s.functions {
+		if fn.Name == ref {
+			return fn
+		}
+	}
+	return nil
+}
+
+func (s *Server) createFunction(w http.ResponseWriter, r *http.Request) {
+	var req webhookrelay.FunctionRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	fn := &webhookrelay.Function{}
+	if status, err := s.applyFunction(fn, &req); err != nil {
+		writeError(w, status, "%s", err)
+		return
+	}
+
+	fn.Id = newID()
+	fn.AccountId = s.user.Data.ID
+	fn.Created = s.timestamp().Unix()
+	fn.Updated = fn.Created
+	s.functions = append(s.functions, fn)
+	writeJSON(w, http.StatusCreated, fn)
+}
+
+func (s *Server) updateFunction(w http.ResponseWriter, r *http.Request, fn *webhookrelay.Function) {
+	var req webhookrelay.FunctionRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	if status, err := s.applyFunction(fn, &req); err != nil {
+		writeError(w, status, "%s", err)
+		return
+	}
+	fn.Updated = s.timestamp().Unix()
+	writeJSON(w, http.StatusOK, fn)
+}
+
+// applyFunction validates the request and sets it on the function
+func (s *Server) applyFunction(fn *webhookrelay.Function, req *webhookrelay.FunctionRequest) (int, error) {
+	if req.Name == "" {
+		return http.StatusBadRequest, fmt.Errorf("function name is required")
+	}
+	if existing := s.findFunction(req.Name); existing != nil && existing != fn {
+		return http.StatusConflict, fmt.Errorf("function '%s' already exists", req.Name)
+	}
+	payload, err := base64.StdEncoding.DecodeString(req.Payload)
+	if err != nil {
+		return http.StatusBadRequest, fmt.Errorf("payload must be base64 encoded: %s", err)
+	}
+	driver := req.Driver
+	if driver == "" {
+		driver = "lua"
+	}
+	switch driver {
+	case "lua", "wasi":
+	default:
+		return http.StatusBadRequest, fmt.Errorf("unknown driver '%s'", driver)
+	}
+
+	fn.Name = req.Name
+	fn.Driver = driver
+	fn.Payload = payload
+	fn.PayloadSize = int64(len(payload))
+	return 0, nil
+}
+
+func (s *Server) deleteFunction(fn *webhookrelay.Function) {
+	for idx, f := range s.functions {
+		if f == fn {
+			s.functions = append(s.functions[:idx], s.functions[idx+1:]...)
+			break
+		}
+	}
+	delete(s.variables, fn.Id)
+}
+
+func (s *Server) invokeFunction(w http.ResponseWriter, r *http.Request, fn *webhookrelay.Function) {
+	var req webhookrelay.InvokeFunctionRequest
+	if !decode(w, r, &req) {
+		return
+	}
+
+	if s.InvokeFunc == nil {
+		writeJSON(w, http.StatusOK, &webhookrelay.ExecuteResponse{RequestId: newID(), FunctionId: fn.Id})
+		return
+	}
+	resp, err := s.InvokeFunc(fn, &req)
+	if err != nil {
+		writeJSON(w, http.StatusOK, &webhookrelay.ExecuteResponse{RequestId: newID(), FunctionId: fn.Id, Error: err.Error()})
+		return
+	}
+	if resp.FunctionId == "" {
+		resp.FunctionId = fn.Id
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleFunctionConfig(w http.ResponseWriter, r *http.Request, fn *webhookrelay.Function, path []string) {
+	variables := s.variables[fn.Id]
+
+	if len(path) == 0 {
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(w, http.StatusOK, &webhookrelay.ListConfigResponse{Variables: variables})
+		case http.MethodPut:
+			s.setFunctionVariable(w, r, fn)
+		default:
+			methodNotAllowed(w, r)
+		}
+		return
+	}
+
+	key, err := url.PathUnescape(path[0])
+	if err != nil || r.Method != http.MethodDelete {
+		methodNotAllowed(w, r)
+		return
+	}
+	for idx, v := range variables {
+		if v.Key == key {
+			s.variables[fn.Id] = append(variables[:idx], variables[idx+1:]...)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+	}
+	writeNotFound(w, "variable", key)
+}
+
+func (s *Server) setFunctionVariable(w http.ResponseWriter, r *http.Request, fn *webhookrelay.Function) {
+	var req webhookrelay.SetFunctionConfigRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	if req.Key == "" {
+		writeError(w, http.StatusBadRequest, "key is required")
+		return
+	}
+
+	now := s.timestamp().Unix()
+	for _, v := range s.variables[fn.Id] {
+		if v.Key == req.Key {
+			v.Value = req.Value
+			v.Updated = now
+			writeJSON(w, http.StatusOK, v)
+			return
+		}
+	}
+	v := &webhookrelay.Variable{
+		Key:        req.Key,
+		Value:      req.Value,
+		AccountId:  fn.AccountId,
+		FunctionId: fn.Id,
+		Created:    now,
+		Updated:    now,
+	}
+	s.variables[fn.Id] = append(s.variables[fn.Id], v)
+	writeJSON(w, http.StatusOK, v)
+}
@@ -0,0 +1,135 @@
+package webhookrelaytest
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/webhookrelay/webhookrelay-go"
+)
+
+func (s *Server) handleInputs(w http.ResponseWriter, r *http.Request, bucket *webhookrelay.Bucket, path []string) {
+	if len(path) == 0 {
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(w, http.StatusOK, bucket.Inputs)
+		case http.MethodPost:
+			s.createInput(w, r, bucket)
+		default:
+			methodNotAllowed(w, r)
+		}
+		return
+	}
+
+	idx := findInput(bucket, path[0])
+	if idx < 0 {
+		writeNotFound(w, "input", path[0])
+		return
+	}
+	input := bucket.Inputs[idx]
+
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, input)
+	case http.MethodPut:
+		s.updateInput(w, r, bucket, input)
+	case http.MethodDelete:
+		bucket.Inputs = append(bucket.Inputs[:idx], bucket.Inputs[idx+1:]...)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		methodNotAllowed(w, r)
+	}
+}
+
+// findInput returns the index of the input by ID or name, -1 if not found
+func findInput(bucket *webhookrelay.Bucket, ref string) int {
+	for idx, i := range bucket.Inputs {
+		if i.ID == ref {
+			return idx
+		}
+	}
+	for idx, i := range bucket.Inputs {
+		if i.Name == ref {
+			return idx
+		}
+	}
+	return -1
+}
+
+func (s *Server) createInput(w http.ResponseWriter, r *http.Request, bucket *webhookrelay.Bucket) {
+	var input webhookrelay.Input
+	if !decode(w, r, &input) {
+		return
+	}
+	input.ID = ""
+	if status, err := s.validateInput(bucket, &input); err != nil {
+		writeError(w, status, "%s", err)
+		return
+	}
+	s.addInput(bucket, &input)
+	writeJSON(w, http.StatusCreated, &input)
+}
+
+// addInput adds a validated input to the bucket, the server assigns the
+// ID and a custom domain
+func (s *Server) addInput(bucket *webhookrelay.Bucket, input *webhookrelay.Input) {
+	input.ID = newID()
+	input.BucketID = bucket.ID
+	input.CreatedAt = s.timestamp()
+	input.UpdatedAt = input.CreatedAt
+	if input.CustomDomain == "" {
+		input.CustomDomain = randomString(22) + ".hooks.webhookrelay.com"
+	}
+	bucket.Inputs = append(bucket.Inputs, input)
+}
+
+func (s *Server) updateInput(w http.ResponseWriter, r *http.Request, bucket *webhookrelay.Bucket, input *webhookrelay.Input) {
+	var update webhookrelay.Input
+	if !decode(w, r, &update) {
+		return
+	}
+	if modifiedSince(r, input.UpdatedAt) {
+		writeError(w, http.StatusPreconditionFailed, "input was modified")
+		return
+	}
+
+	update.ID = input.ID
+	if status, err := s.validateInput(bucket, &update); err != nil {
+		writeError(w, status, "%s", err)
+		return
+	}
+
+	update.BucketID = bucket.ID
+	update.CreatedAt = input.CreatedAt
+	update.UpdatedAt = s.timestamp()
+	if update.CustomDomain == "" {
+		update.CustomDomain = input.CustomDomain
+	}
+	*input = update
+	writeJSON(w, http.StatusOK, input)
+}
+
+func (s *Server) validateInput(bucket *webhookrelay.Bucket, input *webhookrelay.Input) (int, error) {
+	if input.Name == "" {
+		return http.StatusBadRequest, fmt.Errorf("input name is required")
+	}
+	if idx := findInput(bucket, input.Name); idx >= 0 && bucket.Inputs[idx].ID != input.ID {
+		return http.StatusConflict, fmt.Errorf("input '%s' already exists in bucket '%s'", input.Name, bucket.Name)
+	}
+	if input.FunctionID != "" && s.findFunction(input.FunctionID) == nil {
+		return http.StatusBadRequest, fmt.Errorf("function '%s' not found", input.FunctionID)
+	}
+	switch input.ResponseFromOutput {
+	case "", webhookrelay.AnyResponseFromOutput:
+	default:
+		if idx := findOutput(bucket, input.ResponseFromOutput); idx < 0 || bucket.Outputs[idx].ID != input.ResponseFromOutput {
+			return http.StatusBadRequest, fmt.Errorf("response from output '%s' is not an output of bucket '%s'", input.ResponseFromOutput, bucket.Name)
+		}
+	}
+	if input.StatusCode != 0 && (input.StatusCode < 100 || input.StatusCode > 599) {
+		return http.StatusBadRequest, fmt.Errorf("invalid status code %d", input.StatusCode)
+	}
+	if err := input.ValidateFilters(); err != nil {
+		return http.StatusBadRequest, err
+	}
+	return 0, nil
+}
@@ -0,0 +1,180 @@
+package webhookrelaytest
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/webhookrelay/webhookrelay-go"
+)
+
+const defaultLogsLimit = 100
+
+// AddLog stores a webhook log as if the webhook was received. ID and
+// CreatedAt are set when empty, the stored copy is returned.
+func (s *Server) AddLog(l *webhookrelay.Log) *webhookrelay.Log {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var stored webhookrelay.Log
+	copyJSON(&stored, l)
+	if stored.ID == "" {
+		stored.ID = newID()
+	}
+	if stored.CreatedAt.IsZero() {
+		stored.CreatedAt = s.timestamp()
+	}
+	if stored.UpdatedAt.IsZero() {
+		stored.UpdatedAt = stored.CreatedAt
+	}
+	if stored.AccountID == "" {
+		stored.AccountID = s.user.Data.ID
+	}
+	s.logs = append(s.logs, &stored)
+
+	var result webhookrelay.Log
+	copyJSON(&result, &stored)
+	return &result
+}
+
+// Logs returns copies of all stored webhook logs, newest first
+func (s *Server) Logs() []*webhookrelay.Log {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	logs := make([]*webhookrelay.Log, len(s.logs))
+	for idx, l := range s.sortedLogs() {
+		logs[idx] = &webhookrelay.Log{}
+		copyJSON(logs[idx], l)
+	}
+	return logs
+}
+
+func (s *Server) sortedLogs() []*webhookrelay.Log {
+	logs := append([]*webhookrelay.Log{}, s.logs...)
+	sort.SliceStable(logs, func(a, b int) bool {
+		return logs[a].CreatedAt.After(logs[b].CreatedAt)
+	})
+	return logs
+}
+
+func (s *Server) handleLogs(w http.ResponseWriter, r *http.Request, path []string) {
+	if len(path) == 0 {
+		if r.Method != http.MethodGet {
+			methodNotAllowed(w, r)
+			return
+		}
+		s.listLogs(w, r)
+		return
+	}
+
+	var l *webhookrelay.Log
+	for _, candidate := range s.logs {
+		if candidate.ID == path[0] {
+			l = candidate
+		}
+	}
+	if l == nil || len(path) > 1 {
+		writeNotFound(w, "log", path[0])
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, l)
+	case http.MethodPut:
+		var update webhookrelay.WebhookLogsUpdateRequest
+		if !decode(w, r, &update) {
+			return
+		}
+		if !validStatus(update.Status) {
+			writeError(w, http.StatusBadRequest, "invalid status '%s'", update.Status)
+			return
+		}
+		l.StatusCode = update.StatusCode
+		l.ResponseBody = update.ResponseBody
+		l.ResponseHeaders = update.ResponseHeaders
+		l.Status = update.Status
+		l.Retries = update.Retries
+		l.DurationMs = update.DurationMs
+		l.UpdatedAt = s.timestamp()
+		writeJSON(w, http.StatusOK, l)
+	default:
+		methodNotAllowed(w, r)
+	}
+}
+
+func (s *Server) listLogs(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	var from, to time.Time
+	for _, param := range []struct {
+		name string
+		dst  *time.Time
+	}{{"from", &from}, {"to", &to}} {
+		if v := q.Get(param.name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, "invalid '%s' time: %s", param.name, err)
+				return
+			}
+			*param.dst = t
+		}
+	}
+	limit, offset := defaultLogsLimit, 0
+	for _, param := range []struct {
+		name string
+		dst  *int
+	}{{"limit", &limit}, {"offset", &offset}} {
+		if v := q.Get(param.name); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				writeError(w, http.StatusBadRequest, "invalid '%s': %s", param.name, v)
+				return
+			}
+			*param.dst = n
+		}
+	}
+
+	var matching []*webhookrelay.Log
+	for _, l := range s.sortedLogs() {
+		switch {
+		case q.Get("bucket") != "" && l.BucketID != q.Get("bucket"),
+			q.Get("output") != "" && l.OutputID != q.Get("output"),
+			q.Get("status") != "" && string(l.Status) != q.Get("status"),
+			!from.IsZero() && l.CreatedAt.Before(from),
+			!to.IsZero() && l.CreatedAt.After(to):
+			continue
+		}
+		matching = append(matching, l)
+	}
+
+	resp := webhookrelay.WebhookLogsResponse{
+		Data:   []*webhookrelay.Log{},
+		Total:  len(matching),
+		Limit:  limit,
+		Offset: offset,
+	}
+	if offset < len(matching) {
+		end := offset + limit
+		if end > len(matching) {
+			end = len(matching)
+		}
+		resp.Data = matching[offset:end]
+	}
+	writeJSON(w, http.StatusOK, &resp)
+}
+
+func validStatus(status webhookrelay.RequestStatus) bool {
+	switch status {
+	case webhookrelay.RequestStatusReceived,
+		webhookrelay.RequestStatusSent,
+		webhookrelay.RequestStatusFailed,
+		webhookrelay.RequestStatusStalled,
+		webhookrelay.RequestStatusRejected,
+		webhookrelay.RequestStatusDeadLetter:
+		return true
+	}
+	return false
+}
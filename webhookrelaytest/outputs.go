@@ -0,0 +1,130 @@
+package webhookrelaytest
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/webhookrelay/webhookrelay-go"
+)
+
+func (s *Server) handleOutputs(w http.ResponseWriter, r *http.Request, bucket *webhookrelay.Bucket, path []string) {
+	if len(path) == 0 {
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(w, http.StatusOK, bucket.Outputs)
+		case http.MethodPost:
+			s.createOutput(w, r, bucket)
+		default:
+			methodNotAllowed(w, r)
+		}
+		return
+	}
+
+	idx := findOutput(bucket, path[0])
+	if idx < 0 {
+		writeNotFound(w, "output", path[0])
+		return
+	}
+	output := bucket.Outputs[idx]
+
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, output)
+	case http.MethodPut:
+		s.updateOutput(w, r, bucket, output)
+	case http.MethodDelete:
+		bucket.Outputs = append(bucket.Outputs[:idx], bucket.Outputs[idx+1:]...)
+		// inputs stop waiting for the response of a deleted output
+		for _, i := range bucket.Inputs {
+			if i.ResponseFromOutput == output.ID {
+				i.ResponseFromOutput = ""
+			}
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		methodNotAllowed(w, r)
+	}
+}
+
+// findOutput returns the index of the output by ID or name, -1 if not found
+func findOutput(bucket *webhookrelay.Bucket, ref string) int {
+	for idx, o := range bucket.Outputs {
+		if o.ID == ref {
+			return idx
+		}
+	}
+	for idx, o := range bucket.Outputs {
+		if o.Name == ref {
+			return idx
+		}
+	}
+	return -1
+}
+
+func (s *Server) createOutput(w http.ResponseWriter, r *http.Request, bucket *webhookrelay.Bucket) {
+	var output webhookrelay.Output
+	if !decode(w, r, &output) {
+		return
+	}
+	output.ID = ""
+	if status, err := s.validateOutput(bucket, &output); err != nil {
+		writeError(w, status, "%s", err)
+		return
+	}
+
+	output.ID = newID()
+	output.BucketID = bucket.ID
+	output.CreatedAt = s.timestamp()
+	output.UpdatedAt = output.CreatedAt
+	bucket.Outputs = append(bucket.Outputs, &output)
+	writeJSON(w, http.StatusCreated, &output)
+}
+
+func (s *Server) updateOutput(w http.ResponseWriter, r *http.Request, bucket *webhookrelay.Bucket, output *webhookrelay.Output) {
+	var update webhookrelay.Output
+	if !decode(w, r, &update) {
+		return
+	}
+	if modifiedSince(r, output.UpdatedAt) {
+		writeError(w, http.StatusPreconditionFailed, "output was modified")
+		return
+	}
+
+	update.ID = output.ID
+	if status, err := s.validateOutput(bucket, &update); err != nil {
+		writeError(w, status, "%s", err)
+		return
+	}
+
+	update.BucketID = bucket.ID
+	update.CreatedAt = output.CreatedAt
+	update.UpdatedAt = s.timestamp()
+	*output = update
+	writeJSON(w, http.StatusOK, output)
+}
+
+func (s *Server) validateOutput(bucket *webhookrelay.Bucket, output *webhookrelay.Output) (int, error) {
+	if output.Name == "" {
+		return http.StatusBadRequest, fmt.Errorf("output name is required")
+	}
+	if idx := findOutput(bucket, output.Name); idx >= 0 && bucket.Outputs[idx].ID != output.ID {
+		return http.StatusConflict, fmt.Errorf("output '%s' already exists in bucket '%s'", output.Name, bucket.Name)
+	}
+	if output.FunctionID != "" && s.findFunction(output.FunctionID) == nil {
+		return http.StatusBadRequest, fmt.Errorf("function '%s' not found", output.FunctionID)
+	}
+	if output.Destination == "" {
+		return http.StatusBadRequest, fmt.Errorf("output destination is required")
+	}
+	if output.Rules != nil {
+		if err := output.Rules.Validate(); err != nil {
+			return http.StatusBadRequest, err
+		}
+	}
+	if output.RetryPolicy != nil {
+		if err := output.RetryPolicy.Validate(); err != nil {
+			return http.StatusBadRequest, err
+		}
+	}
+	return 0, nil
+}
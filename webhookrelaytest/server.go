@@ -0,0 +1,317 @@
+// Package webhookrelaytest provides an in-memory fake of the Webhook Relay
+// API for hermetic tests of code that uses the webhookrelay client.
+//
+//	srv := webhookrelaytest.NewServer()
+//	defer srv.Close()
+//
+//	client, err := srv.Client()
+//	bucket, err := client.CreateBucket(&webhookrelay.BucketCreateOptions{Name: "test"})
+//
+// The fake keeps names unique, creates the default input together with a
+// bucket, returns 404 for unknown objects and 400 for invalid requests like
+// the real API. Webhook logs can't be received and are seeded with AddLog.
+package webhookrelaytest
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/webhookrelay/webhookrelay-go"
+)
+
+// Default credentials accepted by the server and used by Client
+const (
+	DefaultKey    = "test-key"
+	DefaultSecret = "test-secret"
+)
+
+// DefaultInputName is the name of the input created together with a bucket
+const DefaultInputName = "Default public endpoint"
+
+// Server is a fake Webhook Relay API server
+type Server struct {
+	*httptest.Server
+
+	key         string
+	secret      string
+	bearerToken string
+	now         func() time.Time
+
+	// InvokeFunc handles function invocations, by default the request is
+	// returned unmodified
+	InvokeFunc func(fn *webhookrelay.Function, req *webhookrelay.InvokeFunctionRequest) (*webhookrelay.ExecuteResponse, error)
+
+	mu          sync.Mutex
+	lastUpdated time.Time
+	buckets     []*webhookrelay.Bucket
+	tunnels     []*webhookrelay.Tunnel
+	functions   []*webhookrelay.Function
+	variables   map[string][]*webhookrelay.Variable // function ID -> variables
+	tokens      []*token
+	domains     []*webhookrelay.Domain
+	logs        []*webhookrelay.Log
+	regions     []*webhookrelay.Region
+	user        webhookrelay.UserInfo
+	version     webhookrelay.VersionInfo
+}
+
+// token is an access token together with its secret
+type token struct {
+	webhookrelay.AccessToken
+	secret string
+}
+
+// ServerOption configures the Server
+type ServerOption func(*Server)
+
+// WithCredentials sets the key and secret the server accepts in addition
+// to created access tokens.
+// Default: DefaultKey and DefaultSecret
+func WithCredentials(key, secret string) ServerOption {
+	return func(s *Server) {
+		s.key = key
+		s.secret = secret
+	}
+}
+
+// WithBearerToken makes the server also accept the bearer token
+func WithBearerToken(token string) ServerOption {
+	return func(s *Server) {
+		s.bearerToken = token
+	}
+}
+
+// WithClock sets the time source used for timestamps
+func WithClock(now func() time.Time) ServerOption {
+	return func(s *Server) {
+		s.now = now
+	}
+}
+
+// WithRegions replaces the default regions
+func WithRegions(regions []*webhookrelay.Region) ServerOption {
+	return func(s *Server) {
+		s.regions = regions
+	}
+}
+
+// NewServer starts a fake server, call Close when done
+func NewServer(opts ...ServerOption) *Server {
+	s := newServer(opts...)
+	s.Server = httptest.NewServer(s)
+	return s
+}
+
+// NewUnstartedServer returns a server that is not started yet, e.g. to
+// call StartTLS
+func NewUnstartedServer(opts ...ServerOption) *Server {
+	s := newServer(opts...)
+	s.Server = httptest.NewUnstartedServer(s)
+	return s
+}
+
+func newServer(opts ...ServerOption) *Server {
+	s := &Server{
+		key:       DefaultKey,
+		secret:    DefaultSecret,
+		now:       time.Now,
+		variables: make(map[string][]*webhookrelay.Variable),
+		regions: []*webhookrelay.Region{
+			{ID: newID(), Name: "eu", DomainSuffix: ".webrelay.io", ServerAddress: "eu.webrelay.io:8080"},
+			{ID: newID(), Name: "us-west", DomainSuffix: ".us-west.webrelay.io", ServerAddress: "us-west.webrelay.io:8080"},
+		},
+	}
+	s.user.Status = "ok"
+	s.user.Data.ID = newID()
+	s.user.Data.Username = "test"
+	s.user.Data.Email = "test@example.com"
+	s.user.Data.PlanID = "free"
+	s.user.Data.Role = "admin"
+	s.version = webhookrelay.VersionInfo{Name: "webhookrelaytest", Version: "0.0.0", APIVersion: "v1"}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Client returns an API client for the server authenticated with the
+// server credentials
+func (s *Server) Client(opts ...webhookrelay.Option) (*webhookrelay.API, error) {
+	opts = append([]webhookrelay.Option{
+		webhookrelay.WithAPIEndpointURL(s.URL),
+		webhookrelay.WithHTTPClient(s.Server.Client()),
+	}, opts...)
+	return webhookrelay.New(s.key, s.secret, opts...)
+}
+
+// SetCredentials replaces the accepted key and secret, e.g. to test that
+// clients pick up rotated credentials
+func (s *Server) SetCredentials(key, secret string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.key = key
+	s.secret = secret
+}
+
+// ServeHTTP implements http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.authorized(r) {
+		writeError(w, http.StatusUnauthorized, "invalid credentials")
+		return
+	}
+
+	path := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	// the API is usually mounted under /v1
+	if len(path) > 0 && path[0] == "v1" {
+		path = path[1:]
+	}
+	if len(path) == 0 || path[0] == "" {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	var handler func(w http.ResponseWriter, r *http.Request, path []string)
+	switch path[0] {
+	case "buckets":
+		handler = s.handleBuckets
+	case "tunnels":
+		handler = s.handleTunnels
+	case "functions":
+		handler = s.handleFunctions
+	case "tokens":
+		handler = s.handleTokens
+	case "domains":
+		handler = s.handleDomains
+	case "logs":
+		handler = s.handleLogs
+	case "regions":
+		handler = s.handleRegions
+	case "user":
+		handler = s.handleUser
+	case "version":
+		handler = s.handleVersion
+	default:
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+	handler(w, r, path[1:])
+}
+
+func (s *Server) authorized(r *http.Request) bool {
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		return s.bearerToken != "" && strings.TrimPrefix(auth, "Bearer ") == s.bearerToken
+	}
+	key, secret, ok := r.BasicAuth()
+	if !ok {
+		return false
+	}
+	if key == s.key && secret == s.secret {
+		return true
+	}
+	for _, t := range s.tokens {
+		if t.ID == key && t.secret == secret && t.Active && t.APIAccess != webhookrelay.AccessTokenAPIAccessDisabled && !t.Expired(s.now()) {
+			t.LastLogin = s.now().UTC().Format(time.RFC3339)
+			return true
+		}
+	}
+	return false
+}
+
+// timestamp returns the current time with second precision, the API
+// serializes unix seconds. Each call returns a later time than the
+// previous one so that every change has a distinct UpdatedAt.
+func (s *Server) timestamp() time.Time {
+	now := s.now().Truncate(time.Second)
+	if !now.After(s.lastUpdated) {
+		now = s.lastUpdated.Add(time.Second)
+	}
+	s.lastUpdated = now
+	return now
+}
+
+// modifiedSince reports whether the object was updated after the
+// If-Unmodified-Since request header
+func modifiedSince(r *http.Request, updatedAt time.Time) bool {
+	header := r.Header.Get("If-Unmodified-Since")
+	if header == "" {
+		return false
+	}
+	since, err := http.ParseTime(header)
+	if err != nil {
+		return false
+	}
+	return updatedAt.Truncate(time.Second).After(since)
+}
+
+func newID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	h := hex.EncodeToString(b)
+	return h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:]
+}
+
+func randomString(n int) string {
+	b := make([]byte, (n+1)/2)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)[:n]
+}
+
+// errorResponse is the body of error responses
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func writeError(w http.ResponseWriter, status int, format string, args ...interface{}) {
+	writeJSON(w, status, &errorResponse{Error: fmt.Sprintf(format, args...)})
+}
+
+func writeNotFound(w http.ResponseWriter, kind, ref string) {
+	writeError(w, http.StatusNotFound, "%s '%s' not found", kind, ref)
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// decode reads the JSON request body, writes 400 on failure
+func decode(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body: %s", err)
+		return false
+	}
+	return true
+}
+
+func methodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	writeError(w, http.StatusMethodNotAllowed, "method %s not allowed", r.Method)
+}
+
+// copyJSON deep copies src into dst through JSON
+func copyJSON(dst, src interface{}) {
+	data, err := json.Marshal(src)
+	if err != nil {
+		panic(err)
+	}
+	if err := json.Unmarshal(data, dst); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,329 @@
+package webhookrelaytest
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/webhookrelay/webhookrelay-go"
+	reactor_v1 "github.com/webhookrelay/webhookrelay-go/api/reactor/v1"
+)
+
+func newTestClient(t *testing.T, opts ...ServerOption) (*Server, *webhookrelay.API) {
+	t.Helper()
+	srv := NewServer(opts...)
+	t.Cleanup(srv.Close)
+	client, err := srv.Client()
+	require.NoError(t, err)
+	return srv, client
+}
+
+func TestBuckets(t *testing.T) {
+	_, client := newTestClient(t)
+
+	bucket, err := client.CreateBucket(&webhookrelay.BucketCreateOptions{Name: "github", Description: "GitHub webhooks"})
+	require.NoError(t, err)
+	require.Len(t, bucket.Inputs, 1)
+	assert.Equal(t, DefaultInputName, bucket.Inputs[0].Name)
+	assert.Equal(t, bucket.ID, bucket.Inputs[0].BucketID)
+	assert.True(t, strings.HasSuffix(bucket.Inputs[0].CustomDomain, ".hooks.webhookrelay.com"))
+
+	_, err = client.CreateBucket(&webhookrelay.BucketCreateOptions{Name: "github"})
+	assert.EqualError(t, err, `HTTP status 409: content "{\"error\":\"bucket 'github' already exists\"}\n"`)
+	_, err = client.CreateBucket(&webhookrelay.BucketCreateOptions{})
+	assert.EqualError(t, err, `{"error":"bucket name is required"}`+"\n")
+
+	skipped, err := client.CreateBucket(&webhookrelay.BucketCreateOptions{
+		Name:         "stripe",
+		DefaultInput: &webhookrelay.DefaultInputOptions{Skip: true},
+	})
+	require.NoError(t, err)
+	assert.Empty(t, skipped.Inputs)
+
+	got, err := client.GetBucket("github")
+	require.NoError(t, err)
+	assert.Equal(t, bucket.ID, got.ID)
+	assert.Equal(t, "GitHub webhooks", got.Description)
+
+	buckets, err := client.ListBuckets(&webhookrelay.BucketListOptions{})
+	require.NoError(t, err)
+	assert.Len(t, buckets, 2)
+
+	got.Description = "updated"
+	updated, err := client.UpdateBucket(got)
+	require.NoError(t, err)
+	assert.Equal(t, "updated", updated.Description)
+	assert.True(t, updated.UpdatedAt.After(bucket.UpdatedAt))
+
+	// stale read is rejected
+	_, err = client.PatchBucket(bucket.ID, &webhookrelay.BucketPatch{Description: webhookrelay.String("stale")},
+		&webhookrelay.PatchOptions{UpdatedAt: bucket.UpdatedAt})
+	assert.True(t, errors.Is(err, webhookrelay.ErrPreconditionFailed))
+
+	suspended, err := client.SuspendBucket("github")
+	require.NoError(t, err)
+	assert.True(t, suspended.Suspended)
+
+	require.NoError(t, client.DeleteBucket(&webhookrelay.BucketDeleteOptions{Ref: "stripe"}))
+	_, err = client.GetBucket(skipped.ID)
+	assert.EqualError(t, err, "no such bucket '"+skipped.ID+"'")
+	assert.True(t, webhookrelay.IsNotFound(err))
+}
+
+func TestInputsAndOutputs(t *testing.T) {
+	_, client := newTestClient(t)
+
+	bucket, err := client.CreateBucket(&webhookrelay.BucketCreateOptions{Name: "ci"})
+	require.NoError(t, err)
+
+	output, err := client.CreateOutput(&webhookrelay.Output{
+		Name:        "jenkins",
+		BucketID:    "ci",
+		Destination: "https://jenkins.example.com/github-webhook/",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, bucket.ID, output.BucketID)
+
+	_, err = client.CreateOutput(&webhookrelay.Output{Name: "jenkins", BucketID: bucket.ID, Destination: "https://example.com"})
+	assert.Error(t, err)
+
+	input, err := client.CreateInput(&webhookrelay.Input{
+		Name:               "github",
+		BucketID:           bucket.ID,
+		ResponseFromOutput: output.ID,
+		AllowedMethods:     []string{"POST"},
+	})
+	require.NoError(t, err)
+	assert.NotEmpty(t, input.CustomDomain)
+
+	_, err = client.CreateInput(&webhookrelay.Input{Name: "other", BucketID: bucket.ID, ResponseFromOutput: "missing"})
+	assert.Error(t, err)
+
+	input.Description = "from GitHub"
+	updated, err := client.UpdateInput(input)
+	require.NoError(t, err)
+	assert.Equal(t, input.CustomDomain, updated.CustomDomain)
+	assert.Equal(t, "from GitHub", updated.Description)
+
+	outputs, err := client.ListOutputs(&webhookrelay.OutputListOptions{Bucket: "ci"})
+	require.NoError(t, err)
+	assert.Len(t, outputs, 1)
+
+	require.NoError(t, client.DeleteOutput(&webhookrelay.OutputDeleteOptions{Bucket: "ci", Output: "jenkins"}))
+	got, err := client.GetBucket(bucket.ID)
+	require.NoError(t, err)
+	assert.Empty(t, got.Outputs)
+	assert.Equal(t, "", got.Inputs[1].ResponseFromOutput)
+
+	require.NoError(t, client.DeleteInput(&webhookrelay.InputDeleteOptions{Bucket: "ci", Input: "github"}))
+	err = client.DeleteInput(&webhookrelay.InputDeleteOptions{Bucket: "ci", Input: input.ID})
+	assert.Error(t, err)
+}
+
+func TestTunnelsAndDomains(t *testing.T) {
+	_, client := newTestClient(t)
+
+	tunnel, err := client.CreateTunnel(&webhookrelay.Tunnel{Name: "dev", Destination: "http://localhost:3000"})
+	require.NoError(t, err)
+	assert.Equal(t, "eu", tunnel.Region)
+	assert.NotEmpty(t, tunnel.Host)
+
+	_, err = client.CreateTunnel(&webhookrelay.Tunnel{Name: "dev"})
+	assert.Error(t, err)
+	_, err = client.CreateTunnel(&webhookrelay.Tunnel{Name: "moon", Region: "moon"})
+	assert.EqualError(t, err, `{"error":"unknown region 'moon'"}`+"\n")
+
+	u, err := client.TunnelURL(tunnel)
+	require.NoError(t, err)
+	assert.Equal(t, "https://"+tunnel.Host+".webrelay.io", u.String())
+
+	got, err := client.GetTunnel("dev")
+	require.NoError(t, err)
+	assert.Equal(t, tunnel.ID, got.ID)
+	require.NoError(t, client.DeleteTunnel(&webhookrelay.TunnelDeleteOptions{Name: "dev"}))
+
+	domain, err := client.ReserveDomain(&webhookrelay.Domain{Domain: "hooks.example.com"})
+	require.NoError(t, err)
+	_, err = client.ReserveDomain(&webhookrelay.Domain{Domain: "hooks.example.com"})
+	assert.Error(t, err)
+	domains, err := client.ListDomainReservations(&webhookrelay.DomainListOptions{})
+	require.NoError(t, err)
+	require.Len(t, domains, 1)
+	assert.Equal(t, domain.ID, domains[0].ID)
+	require.NoError(t, client.DeleteDomainReservation(&webhookrelay.DomainDeleteOptions{Ref: "hooks.example.com"}))
+}
+
+func TestFunctions(t *testing.T) {
+	srv, client := newTestClient(t)
+	srv.InvokeFunc = func(fn *webhookrelay.Function, req *webhookrelay.InvokeFunctionRequest) (*webhookrelay.ExecuteResponse, error) {
+		return &webhookrelay.ExecuteResponse{ResponseModified: true, Response: &reactor_v1.Response{Body: []byte(req.RequestBody)}}, nil
+	}
+
+	fn, err := client.CreateFunction(&webhookrelay.CreateFunctionRequest{Name: "echo", Payload: strings.NewReader("-- noop")})
+	require.NoError(t, err)
+	assert.Equal(t, "lua", fn.Driver)
+	assert.Equal(t, []byte("-- noop"), fn.Payload)
+
+	resp, err := client.InvokeFunction(&webhookrelay.InvokeOpts{ID: "echo", InvokeFunctionRequest: webhookrelay.InvokeFunctionRequest{RequestBody: "hi"}})
+	require.NoError(t, err)
+	assert.Equal(t, fn.Id, resp.FunctionId)
+	assert.Equal(t, []byte("hi"), resp.Response.Body)
+
+	_, err = client.SetFunctionConfigurationVariable(&webhookrelay.SetFunctionConfigRequest{ID: "echo", Key: "token", Value: "a"})
+	require.NoError(t, err)
+	_, err = client.SetFunctionConfigurationVariable(&webhookrelay.SetFunctionConfigRequest{ID: "echo", Key: "token", Value: "b"})
+	require.NoError(t, err)
+	variables, err := client.ListFunctionConfigurationVariables(&webhookrelay.FunctionConfigurationVariablesListOptions{ID: "echo"})
+	require.NoError(t, err)
+	require.Len(t, variables, 1)
+	assert.Equal(t, "b", variables[0].Value)
+	require.NoError(t, client.DeleteFunctionConfigurationVariable(&webhookrelay.FunctionConfigurationVariableDeleteOptions{ID: "echo", Key: "token"}))
+	assert.Error(t, client.DeleteFunctionConfigurationVariable(&webhookrelay.FunctionConfigurationVariableDeleteOptions{ID: "echo", Key: "token"}))
+
+	require.NoError(t, client.DeleteFunction(&webhookrelay.FunctionDeleteOptions{ID: "echo"}))
+	functions, err := client.ListFunctions(&webhookrelay.FunctionListOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, functions)
+}
+
+func TestAccessTokens(t *testing.T) {
+	srv, client := newTestClient(t, WithCredentials("admin", "admin-secret"))
+
+	created, err := client.CreateAccessToken(&webhookrelay.AccessTokenCreateOptions{
+		Description: "ci",
+		APIAccess:   webhookrelay.AccessTokenAPIAccessEnabled,
+	})
+	require.NoError(t, err)
+
+	tokenClient, err := webhookrelay.New(created.Key, created.Secret, webhookrelay.WithAPIEndpointURL(srv.URL))
+	require.NoError(t, err)
+	_, err = tokenClient.ListBuckets(&webhookrelay.BucketListOptions{})
+	require.NoError(t, err)
+
+	tokens, err := client.ListAccessTokens(&webhookrelay.AccessTokenListOptions{})
+	require.NoError(t, err)
+	require.Len(t, tokens, 1)
+	assert.NotEmpty(t, tokens[0].LastLogin)
+
+	tokens[0].Active = false
+	_, err = client.UpdateAccessToken(tokens[0])
+	require.NoError(t, err)
+	_, err = tokenClient.ListBuckets(&webhookrelay.BucketListOptions{})
+	assert.EqualError(t, err, "error from makeRequest: HTTP status 401: invalid credentials")
+
+	wrong, err := webhookrelay.New("admin", "wrong", webhookrelay.WithAPIEndpointURL(srv.URL))
+	require.NoError(t, err)
+	_, err = wrong.ListBuckets(&webhookrelay.BucketListOptions{})
+	assert.Error(t, err)
+
+	require.NoError(t, client.DeleteAccessToken(&webhookrelay.AccessTokenDeleteOptions{ID: created.Key}))
+}
+
+func TestAccessTokens_Expiry(t *testing.T) {
+	now := time.Now()
+	srv, client := newTestClient(t, WithClock(func() time.Time { return now }))
+
+	created, err := client.CreateAccessToken(&webhookrelay.AccessTokenCreateOptions{ExpiresAt: now.Add(time.Hour)})
+	require.NoError(t, err)
+	tokenClient, err := webhookrelay.New(created.Key, created.Secret, webhookrelay.WithAPIEndpointURL(srv.URL))
+	require.NoError(t, err)
+	_, err = tokenClient.ListBuckets(&webhookrelay.BucketListOptions{})
+	require.NoError(t, err)
+
+	now = now.Add(2 * time.Hour)
+	_, err = tokenClient.ListBuckets(&webhookrelay.BucketListOptions{})
+	assert.Error(t, err)
+
+	tokens, err := client.ListAccessTokens(&webhookrelay.AccessTokenListOptions{})
+	require.NoError(t, err)
+	require.Len(t, tokens, 1)
+	assert.True(t, tokens[0].Expired(now))
+	lastLogin, err := tokens[0].LastLoginTime()
+	require.NoError(t, err)
+	assert.False(t, lastLogin.IsZero())
+}
+
+func TestCredentials(t *testing.T) {
+	srv := NewServer(WithBearerToken("t0ken"))
+	defer srv.Close()
+
+	bearer, err := webhookrelay.NewWithCredentials(webhookrelay.StaticBearerToken("t0ken"), webhookrelay.WithAPIEndpointURL(srv.URL))
+	require.NoError(t, err)
+	_, err = bearer.ListBuckets(&webhookrelay.BucketListOptions{})
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "credentials.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("key: test-key\nsecret: test-secret\n"), 0600))
+	client, err := webhookrelay.NewWithCredentials(webhookrelay.NewFileCredentials(path), webhookrelay.WithAPIEndpointURL(srv.URL))
+	require.NoError(t, err)
+	_, err = client.ListBuckets(&webhookrelay.BucketListOptions{})
+	require.NoError(t, err)
+
+	// credentials are rotated while the client keeps running
+	srv.SetCredentials("rotated-key", "rotated-secret")
+	require.NoError(t, os.WriteFile(path, []byte("key: rotated-key\nsecret: rotated-secret\n"), 0600))
+	_, err = client.ListBuckets(&webhookrelay.BucketListOptions{})
+	require.NoError(t, err)
+}
+
+func TestLogs(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	srv, client := newTestClient(t, WithClock(func() time.Time { return now }))
+
+	bucket, err := client.CreateBucket(&webhookrelay.BucketCreateOptions{
+		Name:  "partners",
+		Quota: &webhookrelay.BucketQuota{DailyRequests: 3},
+	})
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		srv.AddLog(&webhookrelay.Log{BucketID: bucket.ID, Status: webhookrelay.RequestStatusSent, Body: "{}"})
+	}
+	dead := srv.AddLog(&webhookrelay.Log{BucketID: bucket.ID, Status: webhookrelay.RequestStatusDeadLetter})
+	srv.AddLog(&webhookrelay.Log{BucketID: bucket.ID, CreatedAt: now.Add(-48 * time.Hour)})
+
+	logs, err := client.ListWebhookLogs(&webhookrelay.WebhookLogsListOptions{BucketID: bucket.ID, Limit: 2})
+	require.NoError(t, err)
+	assert.Equal(t, 5, logs.Total)
+	assert.Len(t, logs.Data, 2)
+	assert.Equal(t, dead.ID, logs.Data[0].ID)
+
+	usage, err := client.GetBucketUsage("partners")
+	require.NoError(t, err)
+	assert.Equal(t, 4, usage.Requests)
+	assert.Equal(t, int64(6), usage.Bytes)
+	assert.True(t, usage.Exceeded())
+
+	drained, err := client.DrainDeadLetters(context.Background(), &webhookrelay.DeadLetterDrainOptions{
+		Handler: func(ctx context.Context, l *webhookrelay.Log) error { return nil },
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, drained)
+
+	got, err := client.GetWebhookLog(dead.ID)
+	require.NoError(t, err)
+	assert.Equal(t, webhookrelay.RequestStatusSent, got.Status)
+}
+
+func TestAccount(t *testing.T) {
+	_, client := newTestClient(t)
+
+	info, err := client.UserInfo()
+	require.NoError(t, err)
+	assert.Equal(t, "test", info.Data.Username)
+
+	version, err := client.GetVersion()
+	require.NoError(t, err)
+	assert.Equal(t, "webhookrelaytest", version.Name)
+
+	regions, err := client.ListRegions(&webhookrelay.RegionListOptions{})
+	require.NoError(t, err)
+	assert.Len(t, regions, 2)
+}
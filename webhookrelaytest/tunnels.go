@@ -0,0 +1,146 @@
+package webhookrelaytest
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/webhookrelay/webhookrelay-go"
+)
+
+func (s *Server) handleTunnels(w http.ResponseWriter, r *http.Request, path []string) {
+	if len(path) == 0 {
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(w, http.StatusOK, s.tunnels)
+		case http.MethodPost:
+			s.createTunnel(w, r)
+		default:
+			methodNotAllowed(w, r)
+		}
+		return
+	}
+
+	idx := s.findTunnel(path[0])
+	if idx < 0 || len(path) > 1 {
+		writeNotFound(w, "tunnel", path[0])
+		return
+	}
+	tunnel := s.tunnels[idx]
+
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, tunnel)
+	case http.MethodPut:
+		s.updateTunnel(w, r, tunnel)
+	case http.MethodDelete:
+		s.tunnels = append(s.tunnels[:idx], s.tunnels[idx+1:]...)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		methodNotAllowed(w, r)
+	}
+}
+
+// findTunnel returns the index of the tunnel by ID, name or host, -1 if
+// not found
+func (s *Server) findTunnel(ref string) int {
+	for idx, t := range s.tunnels {
+		if t.ID == ref {
+			return idx
+		}
+	}
+	for idx, t := range s.tunnels {
+		if t.Name == ref || t.Host == ref {
+			return idx
+		}
+	}
+	return -1
+}
+
+func (s *Server) createTunnel(w http.ResponseWriter, r *http.Request) {
+	var tunnel webhookrelay.Tunnel
+	if !decode(w, r, &tunnel) {
+		return
+	}
+	tunnel.ID = ""
+	if status, err := s.validateTunnel(&tunnel); err != nil {
+		writeError(w, status, "%s", err)
+		return
+	}
+
+	tunnel.ID = newID()
+	tunnel.AccountID = s.user.Data.ID
+	tunnel.CreatedAt = s.timestamp()
+	tunnel.UpdatedAt = tunnel.CreatedAt
+	s.tunnels = append(s.tunnels, &tunnel)
+	writeJSON(w, http.StatusCreated, &tunnel)
+}
+
+func (s *Server) updateTunnel(w http.ResponseWriter, r *http.Request, tunnel *webhookrelay.Tunnel) {
+	var update webhookrelay.Tunnel
+	if !decode(w, r, &update) {
+		return
+	}
+	if modifiedSince(r, tunnel.UpdatedAt) {
+		writeError(w, http.StatusPreconditionFailed, "tunnel was modified")
+		return
+	}
+
+	update.ID = tunnel.ID
+	if update.Host == "" {
+		update.Host = tunnel.Host
+	}
+	if status, err := s.validateTunnel(&update); err != nil {
+		writeError(w, status, "%s", err)
+		return
+	}
+
+	update.AccountID = tunnel.AccountID
+	update.CreatedAt = tunnel.CreatedAt
+	update.UpdatedAt = s.timestamp()
+	*tunnel = update
+	writeJSON(w, http.StatusOK, tunnel)
+}
+
+// validateTunnel checks the tunnel and sets server defaults
+func (s *Server) validateTunnel(tunnel *webhookrelay.Tunnel) (int, error) {
+	if tunnel.Name == "" {
+		return http.StatusBadRequest, fmt.Errorf("tunnel name is required")
+	}
+	if idx := s.findTunnel(tunnel.Name); idx >= 0 && s.tunnels[idx].ID != tunnel.ID {
+		return http.StatusConflict, fmt.Errorf("tunnel '%s' already exists", tunnel.Name)
+	}
+	if tunnel.Region == "" {
+		tunnel.Region = s.regions[0].Name
+	}
+	if s.findRegion(tunnel.Region) == nil {
+		return http.StatusBadRequest, fmt.Errorf("unknown region '%s'", tunnel.Region)
+	}
+	if tunnel.Host == "" {
+		tunnel.Host = randomString(12)
+	}
+	if idx := s.findTunnel(tunnel.Host); idx >= 0 && s.tunnels[idx].ID != tunnel.ID {
+		return http.StatusConflict, fmt.Errorf("host '%s' is already used", tunnel.Host)
+	}
+	switch tunnel.Protocol {
+	case "":
+		tunnel.Protocol = "http"
+	case "http", "tcp":
+	default:
+		return http.StatusBadRequest, fmt.Errorf("unknown protocol '%s'", tunnel.Protocol)
+	}
+	switch tunnel.Crypto {
+	case "":
+		tunnel.Crypto = webhookrelay.CryptoFlexible
+	case webhookrelay.CryptoOff, webhookrelay.CryptoFlexible, webhookrelay.CryptoFull, webhookrelay.CryptoFullStrict, webhookrelay.CryptoTLSPassThrough:
+	default:
+		return http.StatusBadRequest, fmt.Errorf("unknown crypto '%s'", tunnel.Crypto)
+	}
+	if tunnel.Destination == "" {
+		tunnel.Destination = "http://127.0.0.1:8000"
+	}
+	auth := webhookrelay.BucketAuth(tunnel.Auth)
+	if err := auth.Validate(); err != nil {
+		return http.StatusBadRequest, err
+	}
+	return 0, nil
+}